@@ -0,0 +1,310 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+const (
+	// DefaultInterval is the default interval between two reports.
+	DefaultInterval = 10 * time.Second
+
+	// MaxPendingEvents is the maximum number of events kept while the hub is
+	// unreachable, the oldest ones are dropped. They stay in the local journal.
+	MaxPendingEvents = 1000
+
+	requestTimeout = 10 * time.Second
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings are the settings of the agent.
+type Settings struct {
+	Hub      string
+	Interval time.Duration
+	TLS      *tls.Config
+}
+
+// Agent reports the coop to the hub and executes its commands. The coop keeps
+// following its schedule when the hub is unreachable.
+type Agent struct {
+	coopService services.CoopService
+	settings    Settings
+	client      *http.Client
+	done        chan struct{}
+	started     time.Time
+
+	mu        sync.Mutex
+	events    []journal.Event
+	lastEvent int64
+	results   []Result
+	online    bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewAgent returns a new Agent.
+func NewAgent(coopService services.CoopService, settings Settings) *Agent {
+	if settings.Interval <= 0 {
+		settings.Interval = DefaultInterval
+	}
+	settings.Hub = strings.TrimSuffix(settings.Hub, "/")
+
+	return &Agent{
+		coopService: coopService,
+		settings:    settings,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: settings.TLS,
+			},
+		},
+		done:    make(chan struct{}),
+		started: time.Now(),
+		online:  true,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Start starts reporting to the hub.
+func (a *Agent) Start() {
+	logrus.WithFields(logrus.Fields{
+		"hub": a.settings.Hub,
+	}).Infoln("Reporting to the hub")
+
+	go a.watch(a.coopService.GetCoop().Subscribe())
+	for _, d := range a.coopService.GetDoors() {
+		go a.watch(d.Subscribe())
+	}
+	go a.run()
+}
+
+// Stop stops reporting to the hub.
+func (a *Agent) Stop() {
+	close(a.done)
+}
+
+// run sends a report at every interval.
+func (a *Agent) run() {
+	ticker := time.NewTicker(a.settings.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.sync()
+
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync sends a report and executes the commands, the state of the hub is only
+// logged when it changes.
+func (a *Agent) sync() {
+	commands, err := a.report()
+
+	a.mu.Lock()
+	changed := a.online != (err == nil)
+	a.online = err == nil
+	a.mu.Unlock()
+
+	if err != nil {
+		if changed {
+			logrus.WithError(err).Warningln("The hub is unreachable, the coop keeps its schedule")
+		}
+		return
+	}
+	if changed {
+		logrus.Infoln("The hub is reachable again")
+	}
+
+	for _, command := range commands {
+		result := Result{ID: command.ID}
+		err := a.execute(command)
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"action": command.Action,
+				"door":   command.Door,
+			}).Errorln("Error while executing the command of the hub")
+			result.Error = err.Error()
+		}
+
+		a.mu.Lock()
+		a.results = append(a.results, result)
+		a.mu.Unlock()
+	}
+}
+
+// report sends the report to the hub and returns its commands. The events and
+// the results are kept for the next report if it fails.
+func (a *Agent) report() ([]Command, error) {
+	report := a.newReport()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("error while encoding the report: %s", err)
+	}
+
+	resp, err := a.client.Post(a.settings.Hub+ReportPath, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error while sending the report: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("report has been rejected: %s", resp.Status)
+	}
+
+	var response ReportResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding the response: %s", err)
+	}
+
+	// The hub has them now, the oldest events may have been dropped since
+	a.mu.Lock()
+	a.events = unreported(a.events, report.Events)
+	a.results = a.results[len(report.Results):]
+	a.mu.Unlock()
+
+	return response.Commands, nil
+}
+
+// newReport returns the report of the coop with the pending events and results.
+func (a *Agent) newReport() Report {
+	report := Report{
+		Name:        a.coopService.Name(),
+		Coop:        newSnapshot(a.coopService.GetCoop()),
+		Doors:       []Snapshot{},
+		Started:     a.started,
+		IsFanOn:     a.coopService.IsFanOn(),
+		IsFanManual: a.coopService.IsFanManual(),
+	}
+	for _, d := range a.coopService.GetDoors() {
+		report.Doors = append(report.Doors, newSnapshot(d))
+	}
+
+	inTemp, inHumidity, outTemp, outHumidity, err := a.coopService.GetTemp()
+	if err == nil {
+		report.Sensors = &Sensors{
+			InsideTemperature:  inTemp,
+			InsideHumidity:     inHumidity,
+			OutsideTemperature: outTemp,
+			OutsideHumidity:    outHumidity,
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	report.Events = append([]journal.Event{}, a.events...)
+	report.Results = append([]Result{}, a.results...)
+
+	return report
+}
+
+// execute executes the command of the hub.
+func (a *Agent) execute(command Command) error {
+	if command.Door != "" {
+		switch command.Action {
+		case ActionOpen:
+			return a.coopService.StartOpenDoor(command.Door, command.By)
+		case ActionClose:
+			return a.coopService.StartCloseDoor(command.Door, command.By)
+		case ActionStop:
+			return a.coopService.StopDoor(command.Door, command.By)
+		case ActionUpdate:
+			if command.Update == nil {
+				return fmt.Errorf("update is missing")
+			}
+			return a.coopService.UpdateDoor(command.Door, *command.Update)
+		}
+
+		return fmt.Errorf("action is not supported for a named door: %s", command.Action)
+	}
+
+	switch command.Action {
+	case ActionOpen:
+		return a.coopService.StartOpen(command.By)
+	case ActionClose:
+		return a.coopService.StartClose(command.By)
+	case ActionPartial:
+		return a.coopService.StartPartial(command.By)
+	case ActionStop:
+		return a.coopService.Stop(command.By)
+	case ActionCalibrate:
+		return a.coopService.StartCalibrate(command.By)
+	case ActionUpdate:
+		if command.Update == nil {
+			return fmt.Errorf("update is missing")
+		}
+		return a.coopService.Update(*command.Update)
+	case ActionFan:
+		a.coopService.SetFan(command.Fan)
+		return nil
+	case ActionResetFan:
+		a.coopService.ResetFan()
+		return nil
+	}
+
+	return fmt.Errorf("action does not exist: %s", command.Action)
+}
+
+// watch numbers the events of the door and keeps them until they are reported.
+func (a *Agent) watch(events <-chan journal.Event) {
+	for {
+		select {
+		case <-a.done:
+			return
+		case e := <-events:
+			a.mu.Lock()
+			a.lastEvent++
+			e.ID = a.lastEvent
+			a.events = append(a.events, e)
+			if len(a.events) > MaxPendingEvents {
+				a.events = a.events[len(a.events)-MaxPendingEvents:]
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// unreported returns the pending events that are not in the report. The events
+// are sorted by ID.
+func unreported(events, reported []journal.Event) []journal.Event {
+	if len(reported) == 0 {
+		return events
+	}
+
+	last := reported[len(reported)-1].ID
+	i := sort.Search(len(events), func(i int) bool {
+		return events[i].ID > last
+	})
+
+	return events[i:]
+}
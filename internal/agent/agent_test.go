@@ -0,0 +1,242 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/internal/services/servicestest"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+)
+
+// writeCertificate writes a certificate and its key signed by the parent, or
+// self-signed without parent.
+func writeCertificate(t *testing.T, dir, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, cn+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, cn+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	return cert, key
+}
+
+func TestAgent(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCertificate(t, dir, "ca", nil, nil)
+	writeCertificate(t, dir, "hub", ca, caKey)
+	writeCertificate(t, dir, "barn", ca, caKey)
+	writeCertificate(t, dir, "shed", ca, caKey)
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	// Hub
+	j, err := journal.NewFileJournal(file("journal-barn.jsonl"))
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	remote := NewRemote("barn", "", j, time.Minute)
+	serverTLS, err := NewServerTLSConfig(file("hub.pem"), file("hub.key"), file("ca.pem"))
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	server := httptest.NewUnstartedServer(NewHub(remote))
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+
+	// The hub rejects the commands until the agent reports
+	if err := remote.StartOpen("admin"); !errors.Is(err, services.ErrCoopOffline) {
+		t.Fatalf("should be offline, it is %v", err)
+	}
+
+	// Agent
+	c := servicestest.NewCoop()
+	s := servicestest.NewService("barn", "The barn", c)
+	clientTLS, err := NewClientTLSConfig(file("barn.pem"), file("barn.key"), file("ca.pem"))
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	a := NewAgent(s, Settings{Hub: server.URL, TLS: clientTLS})
+	defer a.Stop()
+	go a.watch(c.Subscribe())
+
+	// The events of the coop are reported with its state
+	err = c.Update("admin", coop.Closed, false, c.OpeningCondition(), c.ClosingCondition(), nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	for i := 0; len(a.newReport().Events) < 2; i++ {
+		if i == 100 {
+			t.Fatal("the transition and the configuration should be pending")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.sync()
+
+	if !remote.IsOnline() {
+		t.Fatal("the agent should be online")
+	}
+	if remote.Name() != "The barn" {
+		t.Fatalf("name should be the reported one, it is %s", remote.Name())
+	}
+	if remote.GetCoop().Status() != coop.Closed {
+		t.Fatalf("status should be closed, it is %s", remote.GetCoop().Status())
+	}
+	if remote.GetCoop().OpeningCondition().Value() != "08h00" {
+		t.Fatalf("opening condition is incorrect: %s", remote.GetCoop().OpeningCondition().Value())
+	}
+	if inTemp, _, _, _, err := remote.GetTemp(); err != nil || inTemp != 68 {
+		t.Fatalf("temperature is incorrect: %f, %v", inTemp, err)
+	}
+	events, total, err := remote.GetHistory(services.HistoryRequest{})
+	if err != nil || total != 2 || events[0].By != "admin" {
+		t.Fatalf("history is incorrect: %v, %v", events, err)
+	}
+	if len(a.newReport().Events) != 0 {
+		t.Fatal("the reported events should not be pending anymore")
+	}
+
+	// The commands of the hub are executed at the next report
+	err = remote.StartOpen("admin")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	a.sync()
+	commands := s.Commands()
+	if len(commands) != 1 || commands[0] != "open by admin" {
+		t.Fatalf("commands are incorrect: %v", commands)
+	}
+	a.sync()
+	if len(s.Commands()) != 1 {
+		t.Fatal("the command should be executed once")
+	}
+
+	// The agents are identified by their certificate
+	intruderTLS, err := NewClientTLSConfig(file("shed.pem"), file("shed.key"), file("ca.pem"))
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	_, err = NewAgent(s, Settings{Hub: server.URL, TLS: intruderTLS}).report()
+	if err == nil {
+		t.Fatal("should error for an unknown agent")
+	}
+}
+
+// newEvents returns a copy of the pending events of the agent.
+func (a *Agent) newEvents() []journal.Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]journal.Event(nil), a.events...)
+}
+
+func TestPendingEvents(t *testing.T) {
+	a := NewAgent(servicestest.NewService("barn", "The barn", nil), Settings{})
+	events := make(chan journal.Event)
+	go a.watch(events)
+	defer a.Stop()
+
+	// The first events are being reported while more events are dropping them
+	for i := 0; i < 3; i++ {
+		events <- journal.Event{Type: journal.Transition}
+	}
+	for i := 0; len(a.newEvents()) < 3; i++ {
+		if i == 100 {
+			t.Fatal("the events should be pending")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	reported := a.newEvents()
+	for i := 0; i < MaxPendingEvents; i++ {
+		events <- journal.Event{Type: journal.Transition}
+	}
+	for i := 0; a.newEvents()[MaxPendingEvents-1].ID != MaxPendingEvents+3; i++ {
+		if i == 100 {
+			t.Fatal("the events should be pending")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The unreported events are kept
+	pending := unreported(a.newEvents(), reported)
+	if len(pending) != MaxPendingEvents || pending[0].ID != 4 {
+		t.Fatalf("should be %d pending events from ID 4, it is %d from ID %d", MaxPendingEvents, len(pending), pending[0].ID)
+	}
+}
+
+func TestDuplicateReport(t *testing.T) {
+	j, err := journal.NewFileJournal(filepath.Join(t.TempDir(), "journal-barn.jsonl"))
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	remote := NewRemote("barn", "", j, time.Minute)
+
+	// The report is sent again when the response is lost
+	report := Report{
+		Started: time.Now(),
+		Events:  []journal.Event{{ID: 1, Type: journal.Transition}, {ID: 2, Type: journal.Configuration}},
+	}
+	remote.apply(report)
+	remote.apply(report)
+	if _, total, _ := j.List(journal.Query{}); total != 2 {
+		t.Fatalf("should be 2 events, it is %d", total)
+	}
+
+	// The events are numbered again when the agent restarts
+	remote.apply(Report{
+		Started: report.Started.Add(time.Hour),
+		Events:  []journal.Event{{ID: 1, Type: journal.Error}},
+	})
+	if _, total, _ := j.List(journal.Query{}); total != 3 {
+		t.Fatalf("should be 3 events, it is %d", total)
+	}
+}
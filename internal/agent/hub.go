@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// maxReportSize is the maximum size of a report.
+const maxReportSize = 1 << 20
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Hub receives the reports of the agents and answers with their commands.
+type Hub struct {
+	remotes map[string]*Remote
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewHub returns a new Hub for the remotes, the agents are identified by the
+// common name of their certificate which must be the ID of their remote.
+func NewHub(remotes ...*Remote) *Hub {
+	h := &Hub{
+		remotes: make(map[string]*Remote),
+	}
+	for _, r := range remotes {
+		h.remotes[r.ID()] = r
+	}
+
+	return h
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// ServeHTTP handles the report of an agent.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != ReportPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method is not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Identify the agent
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		http.Error(w, "client certificate is required", http.StatusUnauthorized)
+		return
+	}
+	id := r.TLS.PeerCertificates[0].Subject.CommonName
+	remote, ok := h.remotes[id]
+	if !ok {
+		logrus.WithFields(logrus.Fields{
+			"agent": id,
+		}).Warningln("Report of an unknown agent")
+		http.Error(w, "agent is unknown", http.StatusForbidden)
+		return
+	}
+
+	// Decode the report
+	var report Report
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportSize)).Decode(&report)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("report is incorrect: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if !remote.IsOnline() {
+		logrus.WithFields(logrus.Fields{
+			"coop": id,
+		}).Infoln("The agent is online")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReportResponse{
+		Commands: remote.apply(report),
+	})
+}
+
+// NewServerTLSConfig returns the TLS configuration of the hub, the agents must
+// present a certificate signed by the certificate authority.
+func NewServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading the certificate: %s", err)
+	}
+
+	pool, err := loadCA(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// NewClientTLSConfig returns the TLS configuration of an agent, the hub must
+// present a certificate signed by the certificate authority.
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading the certificate: %s", err)
+	}
+
+	pool, err := loadCA(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// loadCA returns the pool of the certificate authority of the file.
+func loadCA(caFile string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the certificate authority: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("certificate authority is incorrect: %s", caFile)
+	}
+
+	return pool, nil
+}
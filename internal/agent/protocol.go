@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// ReportPath is the path of the hub where the agents send their reports.
+const ReportPath = "/agent/v1/report"
+
+const (
+	// ActionOpen starts opening the door.
+	ActionOpen = "open"
+
+	// ActionClose starts closing the door.
+	ActionClose = "close"
+
+	// ActionPartial starts moving the door to the partial position.
+	ActionPartial = "partial"
+
+	// ActionStop stops the door.
+	ActionStop = "stop"
+
+	// ActionCalibrate starts learning the travel of the encoder of the door.
+	ActionCalibrate = "calibrate"
+
+	// ActionUpdate updates the status, the mode or the conditions of the door.
+	ActionUpdate = "update"
+
+	// ActionFan turns the fan on or off manually.
+	ActionFan = "fan"
+
+	// ActionResetFan gives the control of the fan back to the temperature.
+	ActionResetFan = "reset_fan"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Report is sent periodically by an agent to the hub. The agent is identified
+// by the common name of its certificate. The agent numbers its events from the
+// time it has started, so that the hub records them once even if a report is
+// sent again.
+type Report struct {
+	Name        string          `json:"name"`
+	Coop        Snapshot        `json:"coop"`
+	Doors       []Snapshot      `json:"doors"`
+	Sensors     *Sensors        `json:"sensors,omitempty"`
+	Started     time.Time       `json:"started"`
+	IsFanOn     bool            `json:"is_fan_on"`
+	IsFanManual bool            `json:"is_fan_manual"`
+	Events      []journal.Event `json:"events"`
+	Results     []Result        `json:"results"`
+}
+
+// ReportResponse is the response of the hub to a report, with the commands
+// to execute.
+type ReportResponse struct {
+	Commands []Command `json:"commands"`
+}
+
+// Snapshot is the state of a door of the coop, the main door included.
+type Snapshot struct {
+	Name             string             `json:"name,omitempty"`
+	Latitude         float64            `json:"latitude"`
+	Longitude        float64            `json:"longitude"`
+	Status           coop.Status        `json:"status"`
+	IsAutomatic      bool               `json:"is_automatic"`
+	LastTransition   time.Time          `json:"last_transition"`
+	OpeningCondition ConditionSnapshot  `json:"opening_condition"`
+	ClosingCondition ConditionSnapshot  `json:"closing_condition"`
+	PartialCondition *ConditionSnapshot `json:"partial_condition,omitempty"`
+	PartialPosition  float64            `json:"partial_position"`
+	Position         *float64           `json:"position,omitempty"`
+	NextOpeningTime  time.Time          `json:"next_opening_time"`
+	NextClosingTime  time.Time          `json:"next_closing_time"`
+}
+
+// ConditionSnapshot is the state of a condition.
+type ConditionSnapshot struct {
+	Mode            string    `json:"mode"`
+	Value           string    `json:"value"`
+	OpeningTime     time.Time `json:"opening_time"`
+	ClosingTime     time.Time `json:"closing_time"`
+	NextOpeningTime time.Time `json:"next_opening_time"`
+	NextClosingTime time.Time `json:"next_closing_time"`
+}
+
+// Sensors are the last readings of the sensors, temperatures in Fahrenheit.
+type Sensors struct {
+	InsideTemperature  float32 `json:"inside_temperature"`
+	InsideHumidity     float32 `json:"inside_humidity"`
+	OutsideTemperature float32 `json:"outside_temperature"`
+	OutsideHumidity    float32 `json:"outside_humidity"`
+}
+
+// Command is a command of the hub for an agent.
+type Command struct {
+	ID     int64                       `json:"id"`
+	Action string                      `json:"action"`
+	Door   string                      `json:"door,omitempty"`
+	By     string                      `json:"by"`
+	Update *services.CoopUpdateRequest `json:"update,omitempty"`
+	Fan    bool                        `json:"fan,omitempty"`
+}
+
+// Result is the result of a command executed by an agent.
+type Result struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// condition is a condition built from a snapshot.
+type condition struct {
+	snapshot ConditionSnapshot
+}
+
+// view is a door of a coop built from a snapshot.
+type view struct {
+	snapshot Snapshot
+	remote   *Remote
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// newSnapshot returns the snapshot of the door.
+func newSnapshot(c services.Coop) Snapshot {
+	latitude, longitude := c.Location()
+	s := Snapshot{
+		Name:             c.Name(),
+		Latitude:         latitude,
+		Longitude:        longitude,
+		Status:           c.Status(),
+		IsAutomatic:      c.IsAutomatic(),
+		LastTransition:   c.LastTransition(),
+		OpeningCondition: newConditionSnapshot(c.OpeningCondition()),
+		ClosingCondition: newConditionSnapshot(c.ClosingCondition()),
+		PartialPosition:  c.PartialPosition(),
+		NextOpeningTime:  c.NextOpeningTime(),
+		NextClosingTime:  c.NextClosingTime(),
+	}
+	if pc := c.PartialCondition(); pc != nil {
+		partial := newConditionSnapshot(pc)
+		s.PartialCondition = &partial
+	}
+	if position, err := c.Position(); err == nil {
+		s.Position = &position
+	}
+
+	return s
+}
+
+// newConditionSnapshot returns the snapshot of the condition.
+func newConditionSnapshot(c conditions.Condition) ConditionSnapshot {
+	return ConditionSnapshot{
+		Mode:            c.Mode(),
+		Value:           c.Value(),
+		OpeningTime:     c.OpeningTime(),
+		ClosingTime:     c.ClosingTime(),
+		NextOpeningTime: c.NextOpeningTime(),
+		NextClosingTime: c.NextClosingTime(),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Mode returns the mode of the condition.
+func (c condition) Mode() string { return c.snapshot.Mode }
+
+// Value returns the value of the condition.
+func (c condition) Value() string { return c.snapshot.Value }
+
+// OpeningTime returns the opening time of the day.
+func (c condition) OpeningTime() time.Time { return c.snapshot.OpeningTime }
+
+// ClosingTime returns the closing time of the day.
+func (c condition) ClosingTime() time.Time { return c.snapshot.ClosingTime }
+
+// NextOpeningTime returns the next opening time.
+func (c condition) NextOpeningTime() time.Time { return c.snapshot.NextOpeningTime }
+
+// NextClosingTime returns the next closing time.
+func (c condition) NextClosingTime() time.Time { return c.snapshot.NextClosingTime }
+
+// Name returns the name of the door, empty for the main door.
+func (v view) Name() string { return v.snapshot.Name }
+
+// Location returns the latitude and the longitude of the coop.
+func (v view) Location() (float64, float64) { return v.snapshot.Latitude, v.snapshot.Longitude }
+
+// Status returns the last reported status of the door.
+func (v view) Status() coop.Status { return v.snapshot.Status }
+
+// IsAutomatic returns true if the automatic mode of the door is enabled.
+func (v view) IsAutomatic() bool { return v.snapshot.IsAutomatic }
+
+// LastTransition returns the time of the last change of status.
+func (v view) LastTransition() time.Time { return v.snapshot.LastTransition }
+
+// OpeningCondition returns the opening condition of the door.
+func (v view) OpeningCondition() conditions.Condition {
+	return condition{v.snapshot.OpeningCondition}
+}
+
+// ClosingCondition returns the closing condition of the door.
+func (v view) ClosingCondition() conditions.Condition {
+	return condition{v.snapshot.ClosingCondition}
+}
+
+// PartialCondition returns the partial condition of the door, nil if the
+// partial position is not scheduled.
+func (v view) PartialCondition() conditions.Condition {
+	if v.snapshot.PartialCondition == nil {
+		return nil
+	}
+
+	return condition{*v.snapshot.PartialCondition}
+}
+
+// PartialPosition returns the partial position of the door, 0 if it is disabled.
+func (v view) PartialPosition() float64 { return v.snapshot.PartialPosition }
+
+// Position returns the last reported position of the door.
+func (v view) Position() (float64, error) {
+	if v.snapshot.Position == nil {
+		return 0, errNoPosition
+	}
+
+	return *v.snapshot.Position, nil
+}
+
+// NextOpeningTime returns the next opening time of the door.
+func (v view) NextOpeningTime() time.Time { return v.snapshot.NextOpeningTime }
+
+// NextClosingTime returns the next closing time of the door.
+func (v view) NextClosingTime() time.Time { return v.snapshot.NextClosingTime }
+
+// Subscribe returns a channel that receives every event reported for the door.
+func (v view) Subscribe() <-chan journal.Event {
+	return v.remote.subscribe(v.snapshot.Name)
+}
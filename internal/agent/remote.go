@@ -0,0 +1,381 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultOfflineAfter is the default time without report after which an agent is offline.
+const DefaultOfflineAfter = time.Minute
+
+var (
+	errNoPosition = errors.New("position has not been reported")
+	errNoReading  = errors.New("sensors have not been reported")
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Remote is a coop managed by an agent, as seen by the hub. It shows the last
+// report of the agent and queues the commands until the next report.
+type Remote struct {
+	id           string
+	name         string
+	journal      journal.Journal
+	offlineAfter time.Duration
+
+	mu          sync.Mutex
+	report      Report
+	lastSeen    time.Time
+	commands    []queued
+	lastCommand int64
+	subscribers map[string][]chan journal.Event
+
+	// The last event recorded from the agent since it has started
+	started   time.Time
+	lastEvent int64
+}
+
+// queued is a command waiting for the next report of the agent.
+type queued struct {
+	command Command
+	time    time.Time
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewRemote returns a new Remote. The reported events are recorded in the journal.
+func NewRemote(id, name string, j journal.Journal, offlineAfter time.Duration) *Remote {
+	if offlineAfter <= 0 {
+		offlineAfter = DefaultOfflineAfter
+	}
+
+	return &Remote{
+		id:           id,
+		name:         name,
+		journal:      j,
+		offlineAfter: offlineAfter,
+		report: Report{
+			Coop: Snapshot{Status: coop.Unknown},
+		},
+		subscribers: make(map[string][]chan journal.Event),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// ID returns the ID of the coop.
+func (r *Remote) ID() string {
+	return r.id
+}
+
+// Name returns the name of the coop, the one reported by the agent if it is not configured.
+func (r *Remote) Name() string {
+	if r.name != "" {
+		return r.name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.report.Name != "" {
+		return r.report.Name
+	}
+
+	return r.id
+}
+
+// LastSeen returns the time of the last report of the agent, zero if it has never reported.
+func (r *Remote) LastSeen() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastSeen
+}
+
+// IsOnline returns true if the agent has reported recently.
+func (r *Remote) IsOnline() bool {
+	lastSeen := r.LastSeen()
+
+	return !lastSeen.IsZero() && time.Since(lastSeen) < r.offlineAfter
+}
+
+// GetCoop returns the last reported state of the main door.
+func (r *Remote) GetCoop() services.Coop {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return view{snapshot: r.report.Coop, remote: r}
+}
+
+// GetDoors returns the last reported state of the named doors.
+func (r *Remote) GetDoors() []services.Coop {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doors := []services.Coop{}
+	for _, d := range r.report.Doors {
+		doors = append(doors, view{snapshot: d, remote: r})
+	}
+
+	return doors
+}
+
+// GetDoor returns the last reported state of the named door.
+func (r *Remote) GetDoor(name string) (services.Coop, error) {
+	for _, d := range r.GetDoors() {
+		if d.Name() == name {
+			return d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", services.ErrDoorNotFound, name)
+}
+
+// Update queues the update of the coop.
+func (r *Remote) Update(input services.CoopUpdateRequest) error {
+	return r.queue(Command{Action: ActionUpdate, By: input.By, Update: &input})
+}
+
+// UpdateDoor queues the update of the named door.
+func (r *Remote) UpdateDoor(name string, input services.CoopUpdateRequest) error {
+	if _, err := r.GetDoor(name); err != nil {
+		return err
+	}
+
+	return r.queue(Command{Action: ActionUpdate, Door: name, By: input.By, Update: &input})
+}
+
+// Open queues the opening of the door, it does not wait for the door.
+func (r *Remote) Open(by string) error {
+	return r.StartOpen(by)
+}
+
+// Close queues the closing of the door, it does not wait for the door.
+func (r *Remote) Close(by string) error {
+	return r.StartClose(by)
+}
+
+// StartOpen queues the opening of the door.
+func (r *Remote) StartOpen(by string) error {
+	return r.queue(Command{Action: ActionOpen, By: by})
+}
+
+// StartClose queues the closing of the door.
+func (r *Remote) StartClose(by string) error {
+	return r.queue(Command{Action: ActionClose, By: by})
+}
+
+// StartPartial queues the move of the door to the partial position.
+func (r *Remote) StartPartial(by string) error {
+	return r.queue(Command{Action: ActionPartial, By: by})
+}
+
+// StartCalibrate queues the calibration of the encoder of the door.
+func (r *Remote) StartCalibrate(by string) error {
+	return r.queue(Command{Action: ActionCalibrate, By: by})
+}
+
+// Stop queues the stop of the door.
+func (r *Remote) Stop(by string) error {
+	return r.queue(Command{Action: ActionStop, By: by})
+}
+
+// StartOpenDoor queues the opening of the named door.
+func (r *Remote) StartOpenDoor(name, by string) error {
+	return r.queueDoor(Command{Action: ActionOpen, Door: name, By: by})
+}
+
+// StartCloseDoor queues the closing of the named door.
+func (r *Remote) StartCloseDoor(name, by string) error {
+	return r.queueDoor(Command{Action: ActionClose, Door: name, By: by})
+}
+
+// StopDoor queues the stop of the named door.
+func (r *Remote) StopDoor(name, by string) error {
+	return r.queueDoor(Command{Action: ActionStop, Door: name, By: by})
+}
+
+// GetTemp returns the last reported readings of the sensors.
+func (r *Remote) GetTemp() (float32, float32, float32, float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.report.Sensors
+	if s == nil {
+		return 0, 0, 0, 0, errNoReading
+	}
+
+	return s.InsideTemperature, s.InsideHumidity, s.OutsideTemperature, s.OutsideHumidity, nil
+}
+
+// SetFan queues turning the fan on or off manually.
+func (r *Remote) SetFan(on bool) {
+	err := r.queue(Command{Action: ActionFan, Fan: on})
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"coop": r.id,
+		}).Errorln("Error while turning the fan of the agent")
+	}
+}
+
+// ResetFan queues giving the control of the fan back to the temperature.
+func (r *Remote) ResetFan() {
+	err := r.queue(Command{Action: ActionResetFan})
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"coop": r.id,
+		}).Errorln("Error while resetting the fan of the agent")
+	}
+}
+
+// IsFanOn returns true if the fan was turned on at the last report.
+func (r *Remote) IsFanOn() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.report.IsFanOn
+}
+
+// IsFanManual returns true if the fan was manual at the last report.
+func (r *Remote) IsFanManual() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.report.IsFanManual
+}
+
+// GetHistory returns a page of the reported events of the coop.
+func (r *Remote) GetHistory(input services.HistoryRequest) ([]journal.Event, int, error) {
+	return r.journal.List(input.Query())
+}
+
+// GetDoorHistory returns a page of the reported events of the named door.
+func (r *Remote) GetDoorHistory(name string, input services.HistoryRequest) ([]journal.Event, int, error) {
+	if _, err := r.GetDoor(name); err != nil {
+		return nil, 0, err
+	}
+
+	q := input.Query()
+	q.Door = name
+
+	return r.journal.List(q)
+}
+
+// apply records the report of the agent and returns the commands to execute.
+// The events already recorded are skipped, the agent sends them again when it
+// has not received the response. The commands that have waited longer than the
+// offline delay are dropped, the situation may have changed since.
+func (r *Remote) apply(report Report) []Command {
+	for _, result := range report.Results {
+		if result.Error != "" {
+			logrus.WithFields(logrus.Fields{
+				"coop":    r.id,
+				"command": result.ID,
+				"error":   result.Error,
+			}).Warningln("The agent has failed to execute the command")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The events are numbered again when the agent restarts
+	if !report.Started.Equal(r.started) {
+		r.started = report.Started
+		r.lastEvent = 0
+	}
+	for _, e := range report.Events {
+		if e.ID <= r.lastEvent {
+			continue
+		}
+		r.lastEvent = e.ID
+		r.record(e)
+	}
+
+	r.report = report
+	r.lastSeen = time.Now()
+
+	commands := []Command{}
+	for _, q := range r.commands {
+		if time.Since(q.time) < r.offlineAfter {
+			commands = append(commands, q.command)
+		}
+	}
+	r.commands = nil
+
+	return commands
+}
+
+// record records the event of the agent into the journal and sends it to the
+// subscribers of its door. The lock must be held by the caller.
+func (r *Remote) record(e journal.Event) {
+	// The hub numbers the events of its journal
+	e.ID = 0
+	err := r.journal.Append(e)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"coop": r.id,
+		}).Errorln("Error while recording the event of the agent")
+	}
+
+	for _, ch := range r.subscribers[e.Door] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// queue queues the command until the next report, it fails if the agent is offline.
+func (r *Remote) queue(command Command) error {
+	if !r.IsOnline() {
+		return fmt.Errorf("%w: %s", services.ErrCoopOffline, r.id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastCommand++
+	command.ID = r.lastCommand
+	r.commands = append(r.commands, queued{command: command, time: time.Now()})
+
+	return nil
+}
+
+// queueDoor queues the command of a named door.
+func (r *Remote) queueDoor(command Command) error {
+	if _, err := r.GetDoor(command.Door); err != nil {
+		return err
+	}
+
+	return r.queue(command)
+}
+
+// subscribe returns a channel that receives the reported events of the door.
+func (r *Remote) subscribe(door string) <-chan journal.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch := make(chan journal.Event, coop.SubscriberBufferSize)
+	r.subscribers[door] = append(r.subscribers[door], ch)
+
+	return ch
+}
@@ -7,25 +7,49 @@ import (
 	"io/ioutil"
 	"net/http"
 	"crypto/tls"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/fallais/gocoop/internal/agent"
+	"github.com/fallais/gocoop/internal/mqtt"
 	"github.com/fallais/gocoop/internal/routes"
 	"github.com/fallais/gocoop/internal/services"
 	"github.com/fallais/gocoop/internal/system"
+	"github.com/fallais/gocoop/pkg/adc"
 	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/conditions/composite"
+	"github.com/fallais/gocoop/pkg/coop/conditions/lux"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/fallais/gocoop/pkg/coop/state"
+	"github.com/fallais/gocoop/pkg/currentsense"
 	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/encoder"
+	"github.com/fallais/gocoop/pkg/fan"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/lightsensor"
+	"github.com/fallais/gocoop/pkg/limitswitch"
 	"github.com/fallais/gocoop/pkg/motor"
 	"github.com/fallais/gocoop/pkg/motor/bts7960"
 	"github.com/fallais/gocoop/pkg/motor/l293d"
 	"github.com/fallais/gocoop/pkg/motor/l298n"
+	"github.com/fallais/gocoop/pkg/motor/linearactuator"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+	"github.com/fallais/gocoop/pkg/motor/relay"
+	"github.com/fallais/gocoop/pkg/motor/servo"
+	"github.com/fallais/gocoop/pkg/motor/stepper"
+	"github.com/fallais/gocoop/pkg/notifiers"
+	"github.com/fallais/gocoop/pkg/sim"
 	"github.com/fallais/gocoop/pkg/temperature"
 
 	auth "github.com/abbot/go-http-auth"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/gorilla/mux"
-	"github.com/stianeikeland/go-rpio/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -39,6 +63,9 @@ const (
 
 var failedAttempts = make(map[string]int)
 
+// namePattern is the pattern of the IDs of the coops and of the names of the doors, they are used in the URLs.
+var namePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
 // Run is a convenient function for Cobra.
 func Run(cmd *cobra.Command, args []string) {
 	// Flags
@@ -60,59 +87,123 @@ func Run(cmd *cobra.Command, args []string) {
 		logrus.WithError(err).Fatalln("Error when reading configuration data")
 	}
 
-	// Initialize RPIO
-	err = rpio.Open()
+	// A hub does not need a coop of its own
+	local := viper.IsSet("coop") || !viper.IsSet("hub.agents")
+
+	// The simulator and the hub without coop do not need the GPIO
+	simulated, err := isSimulated(local)
 	if err != nil {
-		logrus.WithError(err).Fatalln("Error opening GPIO")
+		logrus.WithError(err).Fatalln("Error while reading the motors")
 	}
 
-	// Motor
-	var motor motor.Motor
-	logrus.WithFields(logrus.Fields{
-		"type": viper.GetString("door.motor.type"),
-	}).Infoln("Creating the motor")
-	switch viper.GetString("door.motor.type") {
-	case "l298n":
-		motor = l298n.NewL298N(viper.GetInt("door.motor.pin_1A"), viper.GetInt("door.motor.pin_1B"), viper.GetInt("door.motor.pin_enable1"))
-	case "l293d":
-		motor = l293d.NewL293D(viper.GetInt("door.motor.pin_1A"), viper.GetInt("door.motor.pin_1B"), viper.GetInt("door.motor.pin_enable1"))
-	case "bts7960":
-		motor = bts7960.NewBTS7960(viper.GetInt("door.motor.forward_PWM"), viper.GetInt("door.motor.backward_PWM"), viper.GetInt("door.motor.forward_enable"), viper.GetInt("door.motor.backward_enable"))
-	default:
-		logrus.Fatalln("Motor type does not exist")
+	// GPIO
+	chip, err := newChip(simulated)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Error opening GPIO")
 	}
-	logrus.Infoln("Successfully created the motor")
-
-	// Door
-	logrus.Infoln("Creating the door")
-	d := door.NewDoor(motor, viper.GetDuration("door.opening_duration"), viper.GetDuration("door.closing_duration"))
-	logrus.Infoln("Successfully created the door")
+	defer chip.Close()
 
 	// Notifiers
-	notifiers := system.SetupNotifiers()
+	coopNotifiers := system.SetupNotifiers()
 
-	// Temperatures and Fan
-	intempsensor := temperature.NewTemperature(viper.GetString("temperature.inside.name"), viper.GetString("temperature.inside.type"), viper.GetInt("temperature.inside.pin"))
-	outtempsensor := temperature.NewTemperature(viper.GetString("temperature.outside.name"), viper.GetString("temperature.outside.type"), viper.GetInt("temperature.outside.pin"))
+	// Coops, the one at the root of the configuration is the default coop
+	logrus.Infoln("Initializing the services")
+	var coopServices []services.CoopService
+	if local {
+		s, closeCoop, err := newCoopService(chip, services.DefaultCoopID, viper.GetViper(), filepath.Dir(configFile), "", coopNotifiers)
+		if err != nil {
+			logrus.WithError(err).Fatalln("Error while creating the coop")
+		}
+		defer closeCoop()
+		coopServices = append(coopServices, s)
+	}
+	for _, id := range sortedKeys(viper.GetViper(), "coops") {
+		cfg := viper.Sub("coops." + id)
+		if cfg == nil {
+			logrus.WithFields(logrus.Fields{
+				"coop": id,
+			}).Fatalln("The coop is not configured")
+		}
+		cfg.SetDefault("coop.name", id)
+		s, closeCoop, err := newCoopService(chip, id, cfg, filepath.Dir(configFile), id, notifiers.WithPrefix(coopNotifiers, cfg.GetString("coop.name")))
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"coop": id,
+			}).Fatalln("Error while creating the coop")
+		}
+		defer closeCoop()
+		coopServices = append(coopServices, s)
+	}
 
-	// Create the coop instance
-	isAutomaticAtStartup := false
-	notifyAtStartup := false
-	c, err := coop.New(viper.GetFloat64("coop.latitude"), viper.GetFloat64("coop.longitude"), d, viper.GetString("coop.opening.mode"), 
-	                   viper.GetString("coop.opening.value"), viper.GetString("coop.closing.mode"), viper.GetString("coop.closing.value"), 
-					   notifiers, isAutomaticAtStartup, notifyAtStartup)
-	if err != nil {
-		logrus.WithError(err).Fatalln("Error while creating the coop instance")
+	// Coops managed by the agents of the hub
+	var remotes []*agent.Remote
+	for _, id := range sortedKeys(viper.GetViper(), "hub.agents") {
+		r, err := newRemote(id, viper.GetViper(), filepath.Dir(configFile))
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"coop": id,
+			}).Fatalln("Error while creating the coop of the agent")
+		}
+		remotes = append(remotes, r)
+		coopServices = append(coopServices, r)
 	}
 
-	// Initialize Service controllers
-	logrus.Infoln("Initializing the services")
-	coopService := services.NewCoopService(c, intempsensor, outtempsensor)
+	registry, err := services.NewRegistry(coopServices...)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Error while creating the registry of the coops")
+	}
+	coopService := registry.Default()
 	logrus.Infoln("Successfully initialized the services")
 
+	// Hub
+	if len(remotes) > 0 {
+		go serveHub(remotes)
+	}
+
+	// Agent
+	if viper.GetString("agent.hub") != "" {
+		tlsConfig, err := agent.NewClientTLSConfig(viper.GetString("agent.tls_cert"), viper.GetString("agent.tls_key"), viper.GetString("agent.ca_file"))
+		if err != nil {
+			logrus.WithError(err).Fatalln("Error while loading the TLS configuration of the agent")
+		}
+		a := agent.NewAgent(coopService, agent.Settings{
+			Hub:      viper.GetString("agent.hub"),
+			Interval: viper.GetDuration("agent.interval"),
+			TLS:      tlsConfig,
+		})
+		a.Start()
+		defer a.Stop()
+	}
+
+	// Metrics
+	system.SetupMetrics(registry)
+	if interval := viper.GetDuration("metrics.sensors_interval"); interval > 0 {
+		go readSensors(registry, interval)
+	}
+
+	// MQTT
+	if viper.GetString("mqtt.broker") != "" {
+		bridge := mqtt.NewBridge(coopService, mqtt.Settings{
+			Broker:          viper.GetString("mqtt.broker"),
+			ClientID:        viper.GetString("mqtt.client_id"),
+			Username:        viper.GetString("mqtt.username"),
+			Password:        viper.GetString("mqtt.password"),
+			TopicPrefix:     viper.GetString("mqtt.topic_prefix"),
+			DiscoveryPrefix: viper.GetString("mqtt.discovery_prefix"),
+			SensorsInterval: viper.GetDuration("mqtt.sensors_interval"),
+		})
+		err = bridge.Start()
+		if err != nil {
+			logrus.WithError(err).Fatalln("Error while starting the MQTT bridge")
+		}
+		defer bridge.Stop()
+	}
+
 	// Initialize Web controllers
 	logrus.Infoln("Initializing the Web controllers")
-	miscCtrl := routes.NewMiscController(coopService)
+	miscCtrl := routes.NewMiscController(coopService, registry, "", "/coop")
+	apiCtrl := routes.NewAPIController(coopService)
+	coopsCtrl := routes.NewCoopsController(registry)
 	logrus.Infoln("Successfully initialized the Web controllers")
 
 	// Set the Basic authenticator
@@ -141,11 +232,48 @@ func Run(cmd *cobra.Command, args []string) {
 	router.HandleFunc("/logout", logoutHandler)
 	router.HandleFunc("/", authenticator.Wrap(miscCtrl.Index))
 	router.HandleFunc("/configuration", authenticator.Wrap(miscCtrl.Configuration))
+	router.HandleFunc("/history", authenticator.Wrap(miscCtrl.History))
 	router.HandleFunc("/coop/open", authenticator.Wrap(miscCtrl.OpenCoopDoorManually))
 	router.HandleFunc("/coop/close", authenticator.Wrap(miscCtrl.CloseCoopDoorManually))
+	router.HandleFunc("/coop/partial", authenticator.Wrap(miscCtrl.PartialCoopDoorManually))
 	router.HandleFunc("/coop/stop", authenticator.Wrap(miscCtrl.StopCoopDoorManually))
+	router.HandleFunc("/doors/{name}/open", authenticator.Wrap(miscCtrl.OpenDoorManually))
+	router.HandleFunc("/doors/{name}/close", authenticator.Wrap(miscCtrl.CloseDoorManually))
+	router.HandleFunc("/doors/{name}/stop", authenticator.Wrap(miscCtrl.StopDoorManually))
 	router.HandleFunc("/coop/temperature", authenticator.Wrap(miscCtrl.GetCoopTemperature))
+	router.HandleFunc("/coop/history", authenticator.Wrap(miscCtrl.GetCoopHistory))
 	router.HandleFunc("/coop/camera/still", authenticator.Wrap(miscCtrl.ProcessCaptureRequest))
+	router.Handle("/metrics", authenticator.Wrap(func(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+		promhttp.Handler().ServeHTTP(w, &r.Request)
+	}))
+
+	// API
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/openapi.yaml", openAPIHandler).Methods("GET")
+	api.HandleFunc("/coop", authenticator.Wrap(apiCtrl.GetCoop)).Methods("GET")
+	api.HandleFunc("/coop", authenticator.Wrap(apiCtrl.UpdateCoop)).Methods("PUT")
+	api.HandleFunc("/coop/conditions", authenticator.Wrap(apiCtrl.GetConditions)).Methods("GET")
+	api.HandleFunc("/coop/schedule", authenticator.Wrap(apiCtrl.GetSchedule)).Methods("GET")
+	api.HandleFunc("/coop/open", authenticator.Wrap(apiCtrl.Open)).Methods("POST")
+	api.HandleFunc("/coop/close", authenticator.Wrap(apiCtrl.Close)).Methods("POST")
+	api.HandleFunc("/coop/partial", authenticator.Wrap(apiCtrl.Partial)).Methods("POST")
+	api.HandleFunc("/coop/stop", authenticator.Wrap(apiCtrl.Stop)).Methods("POST")
+	api.HandleFunc("/coop/calibrate", authenticator.Wrap(apiCtrl.Calibrate)).Methods("POST")
+	api.HandleFunc("/coop/history", authenticator.Wrap(apiCtrl.GetHistory)).Methods("GET")
+	api.HandleFunc("/doors", authenticator.Wrap(apiCtrl.GetDoors)).Methods("GET")
+	api.HandleFunc("/doors/{name}", authenticator.Wrap(apiCtrl.GetDoor)).Methods("GET")
+	api.HandleFunc("/doors/{name}", authenticator.Wrap(apiCtrl.UpdateDoor)).Methods("PUT")
+	api.HandleFunc("/doors/{name}/open", authenticator.Wrap(apiCtrl.OpenDoor)).Methods("POST")
+	api.HandleFunc("/doors/{name}/close", authenticator.Wrap(apiCtrl.CloseDoor)).Methods("POST")
+	api.HandleFunc("/doors/{name}/stop", authenticator.Wrap(apiCtrl.StopDoor)).Methods("POST")
+	api.HandleFunc("/doors/{name}/history", authenticator.Wrap(apiCtrl.GetDoorHistory)).Methods("GET")
+	api.HandleFunc("/sensors", authenticator.Wrap(apiCtrl.GetSensors)).Methods("GET")
+	api.HandleFunc("/coops", authenticator.Wrap(coopsCtrl.GetCoops)).Methods("GET")
+
+	// Every coop, the default coop included, is also served under its ID
+	for _, s := range registry.All() {
+		handleCoop(router, api, authenticator, registry, s)
+	}
 
 	// Load TLS certificate and private key
 	cert, err := tls.LoadX509KeyPair(viper.GetString("general.tls_cert"), viper.GetString("general.tls_key"))
@@ -177,6 +305,799 @@ func Run(cmd *cobra.Command, args []string) {
 	}
 }
 
+// newCoopService returns the service of the coop configured in cfg, and a
+// function closing its doors. Its files are next to the configuration file by
+// default, with the suffix for the coops other than the default one.
+func newCoopService(chip gpio.Chip, id string, cfg *viper.Viper, dir, suffix string, notifiers []notifiers.Notifier) (services.CoopService, func(), error) {
+	if !namePattern.MatchString(id) {
+		return nil, nil, fmt.Errorf("ID of the coop is incorrect: %s", id)
+	}
+	cfg.SetDefault("coop.name", id)
+
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	// Door
+	d, closeDoor, err := newDoor(chip, id, "", cfg.Sub("door"), fileOf(cfg, "door.encoder.calibration_file", dir, "calibration.json", suffix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while creating the door: %s", err)
+	}
+	closers = append(closers, closeDoor)
+
+	// Temperatures and Fan
+	coopfan := fan.NewFan(chip.Pin(cfg.GetInt("fan.pin")))
+	intempsensor := newTemperature(chip, cfg, "temperature.inside")
+	outtempsensor := newTemperature(chip, cfg, "temperature.outside")
+
+	// Light sensor, the lux conditions of the coop and of its named doors use it
+	var monitor *lux.Monitor
+	if sub := cfg.Sub("light_sensor"); sub != nil {
+		sensor, err := newLightSensor(sub)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("error while creating the light sensor: %s", err)
+		}
+		monitor = lux.NewMonitor(id, sensor, sub.GetDuration("interval"))
+		monitor.Start()
+		closers = append(closers, monitor.Stop)
+	}
+
+	// State store
+	stateFile := fileOf(cfg, "coop.state_file", dir, "state.json", suffix)
+	logrus.WithFields(logrus.Fields{
+		"file": stateFile,
+	}).Infoln("Using the state file")
+	store := state.NewFileStore(stateFile)
+
+	// Journal of the events
+	journalFile := fileOf(cfg, "coop.journal_file", dir, "journal.jsonl", suffix)
+	logrus.WithFields(logrus.Fields{
+		"file": journalFile,
+	}).Infoln("Using the journal file")
+	j, err := journal.NewFileJournal(journalFile)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("error while opening the journal: %s", err)
+	}
+
+	// Create the coop instance
+	isAutomaticAtStartup := false
+	notifyAtStartup := false
+	c, err := coop.New(cfg.GetFloat64("coop.latitude"), cfg.GetFloat64("coop.longitude"), monitor, d, cfg.GetString("coop.opening.mode"),
+		conditionValue(cfg, "coop.opening"), cfg.GetString("coop.closing.mode"), conditionValue(cfg, "coop.closing"),
+		cfg.GetString("coop.partial.mode"), conditionValue(cfg, "coop.partial"),
+		notifiers, store, j, isAutomaticAtStartup, notifyAtStartup)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("error while creating the coop instance: %s", err)
+	}
+	c.SetRedrive(cfg.GetBool("coop.redrive_on_mismatch"))
+
+	// Partial position
+	partialPosition := cfg.GetFloat64("coop.partial.position")
+	if partialPosition < 0 || partialPosition >= 100 {
+		closeAll()
+		return nil, nil, fmt.Errorf("the partial position must be between 0 and 100: %v", partialPosition)
+	}
+	c.SetPartialPosition(partialPosition)
+
+	// Named doors, next to the main door
+	var doors []*coop.Coop
+	for _, name := range sortedKeys(cfg, "doors") {
+		named, closeNamed, err := newNamedDoor(chip, id, cfg, name, fileOf(cfg, "door.encoder.calibration_file", dir, "calibration.json", suffix), stateFile, notifiers, j, monitor)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("error while creating the %s door: %s", name, err)
+		}
+		closers = append(closers, closeNamed)
+		doors = append(doors, named)
+	}
+
+	return services.NewCoopService(id, cfg.GetString("coop.name"), c, doors, intempsensor, outtempsensor, coopfan, cfg.GetInt("fan.temp_limit")), closeAll, nil
+}
+
+// fileOf returns the file set under the key of cfg, the file with the base
+// name in the directory and with the suffix by default.
+func fileOf(cfg *viper.Viper, key, dir, base, suffix string) string {
+	if file := cfg.GetString(key); file != "" {
+		return file
+	}
+
+	file := filepath.Join(dir, base)
+	if suffix != "" {
+		file = suffixed(file, suffix)
+	}
+
+	return file
+}
+
+// conditionValue returns the value of the condition configured under the key
+// of cfg. The children of a composite condition can be listed with their mode
+// and value under conditions instead.
+func conditionValue(cfg *viper.Viper, key string) string {
+	if children, ok := cfg.Get(key + ".conditions").([]interface{}); ok {
+		return childrenValue(children)
+	}
+
+	return cfg.GetString(key + ".value")
+}
+
+// childrenValue returns the value of a composite condition with the children
+// listed in the configuration, they can be composite conditions too.
+func childrenValue(children []interface{}) string {
+	var list []composite.Child
+	for _, c := range children {
+		child := composite.Child{}
+		switch m := c.(type) {
+		case map[interface{}]interface{}:
+			child.Mode = fmt.Sprint(m["mode"])
+			child.Value = fmt.Sprint(m["value"])
+			if grandchildren, ok := m["conditions"].([]interface{}); ok {
+				child.Value = childrenValue(grandchildren)
+			}
+		case map[string]interface{}:
+			child.Mode = fmt.Sprint(m["mode"])
+			child.Value = fmt.Sprint(m["value"])
+			if grandchildren, ok := m["conditions"].([]interface{}); ok {
+				child.Value = childrenValue(grandchildren)
+			}
+		}
+		list = append(list, child)
+	}
+
+	return composite.Join(list)
+}
+
+// newDoor returns the door with the name of the coop with the ID configured in cfg, and a
+// function closing its limit switches and its encoder. The calibration of the encoder is saved in the calibration file.
+func newDoor(chip gpio.Chip, id, name string, cfg *viper.Viper, calibrationFile string) (door.Door, func(), error) {
+	if cfg == nil {
+		cfg = viper.New()
+	}
+	var closers []func() error
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	// The simulator does not need the GPIO
+	simulated := cfg.GetString("motor.type") == "sim"
+
+	// Limit switches
+	var limits *limitswitch.Limits
+	if !simulated && cfg.IsSet("stoplimit") {
+		var err error
+		limits, err = newLimits(chip, cfg.Sub("stoplimit"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while creating the limit switches: %s", err)
+		}
+		closers = append(closers, limits.Close)
+	}
+
+	// Motor
+	m, sensor, plant, err := newMotor(chip, cfg.Sub("motor"), limits)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	if sensor == nil && limits != nil {
+		sensor = limits
+	}
+
+	// Door
+	logrus.Infoln("Creating the door")
+	var safety *door.Safety
+	if cfg.IsSet("safety") {
+		safety, err = newSafety(chip, cfg.Sub("safety"), simulated)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("error while creating the safety of the door: %s", err)
+		}
+	}
+	var odometer *door.Odometer
+	if cfg.IsSet("encoder") {
+		enc, err := newEncoder(chip, cfg.Sub("encoder"), plant)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("error while creating the encoder of the door: %s", err)
+		}
+		closers = append(closers, enc.Close)
+		odometer = newOdometer(enc, calibrationFile)
+	}
+	d := door.NewDoor(id, name, m, sensor, cfg.GetDuration("opening_duration"), cfg.GetDuration("closing_duration"), safety, odometer)
+	logrus.Infoln("Successfully created the door")
+
+	return d, closeAll, nil
+}
+
+// newMotor returns the motor configured under the key of cfg, the sensor of
+// the position of the door if the motor has one, and the plant of the simulator.
+func newMotor(chip gpio.Chip, cfg *viper.Viper, limits *limitswitch.Limits) (motor.Motor, door.Sensor, *sim.Plant, error) {
+	if cfg == nil {
+		cfg = viper.New()
+	}
+
+	// Speed ramp of the motor
+	var motorRamp *ramp.Ramp
+	if cfg.IsSet("ramp") {
+		var err error
+		motorRamp, err = ramp.New(ramp.Settings{
+			Acceleration:  cfg.GetDuration("ramp.acceleration"),
+			Deceleration:  cfg.GetDuration("ramp.deceleration"),
+			Curve:         ramp.Curve(cfg.GetString("ramp.curve")),
+			Approach:      cfg.GetFloat64("ramp.approach"),
+			ApproachSpeed: cfg.GetFloat64("ramp.approach_speed"),
+			TravelTime:    cfg.GetDuration("ramp.travel_time"),
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while creating the ramp of the motor: %s", err)
+		}
+	}
+
+	var m motor.Motor
+	var sensor door.Sensor
+	var plant *sim.Plant
+	var err error
+	logrus.WithFields(logrus.Fields{
+		"type": cfg.GetString("type"),
+	}).Infoln("Creating the motor")
+	switch cfg.GetString("type") {
+	case "l298n":
+		m = l298n.NewL298N(chip.Pin(cfg.GetInt("pin_1A")), chip.Pin(cfg.GetInt("pin_1B")), chip.Pin(cfg.GetInt("pin_enable1")), limits, cfg.GetInt("pwm_open_dutycycle"), cfg.GetInt("pwm_close_dutycycle"), motorRamp)
+	case "l293d":
+		m = l293d.NewL293D(chip.Pin(cfg.GetInt("pin_1A")), chip.Pin(cfg.GetInt("pin_1B")), chip.Pin(cfg.GetInt("pin_enable1")), limits, motorRamp)
+	case "bts7960":
+		var forwardSense, reverseSense *currentsense.Sensor
+		if cfg.IsSet("current_sense") {
+			forwardSense, reverseSense, err = newCurrentSensors(cfg.Sub("current_sense"))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error while creating the current sensors: %s", err)
+			}
+		}
+		m = bts7960.NewBTS7960(chip.Pin(cfg.GetInt("forward_PWM")), chip.Pin(cfg.GetInt("backward_PWM")), chip.Pin(cfg.GetInt("forward_enable")), chip.Pin(cfg.GetInt("backward_enable")), limits, forwardSense, reverseSense, motorRamp)
+	case "stepper":
+		var pinEnable gpio.Pin
+		if cfg.IsSet("pin_enable") {
+			pinEnable = chip.Pin(cfg.GetInt("pin_enable"))
+		}
+		var microstepPins []gpio.Pin
+		for _, pin := range cfg.GetIntSlice("microstep_pins") {
+			microstepPins = append(microstepPins, chip.Pin(pin))
+		}
+		m, err = stepper.NewStepper(chip.Pin(cfg.GetInt("pin_step")), chip.Pin(cfg.GetInt("pin_dir")), pinEnable, microstepPins, limits, stepper.Settings{
+			Driver:         stepper.Driver(cfg.GetString("driver")),
+			StepsPerTravel: cfg.GetInt("steps_per_travel"),
+			Microsteps:     cfg.GetInt("microsteps"),
+			Speed:          cfg.GetFloat64("speed"),
+			Invert:         cfg.GetBool("invert"),
+			Hold:           cfg.GetBool("hold"),
+		}, motorRamp)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while creating the stepper motor: %s", err)
+		}
+	case "servo":
+		m, err = servo.NewServo(chip.Pin(cfg.GetInt("pin_pwm")), limits, servo.Settings{
+			ClosedPulse: cfg.GetDuration("closed_pulse"),
+			OpenedPulse: cfg.GetDuration("opened_pulse"),
+			TravelTime:  cfg.GetDuration("travel_time"),
+			Hold:        cfg.GetBool("hold"),
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while creating the servo: %s", err)
+		}
+	case "relay":
+		m, err = relay.NewRelay(chip.Pin(cfg.GetInt("pin_forward")), chip.Pin(cfg.GetInt("pin_backward")), limits, relay.Settings{
+			ActiveLow: cfg.GetBool("active_low"),
+			DeadTime:  cfg.GetDuration("dead_time"),
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while creating the relays of the motor: %s", err)
+		}
+	case "linear_actuator":
+		var pinEnable gpio.Pin
+		if cfg.IsSet("pin_enable") {
+			pinEnable = chip.Pin(cfg.GetInt("pin_enable"))
+		}
+		var forwardSense, reverseSense *currentsense.Sensor
+		if cfg.IsSet("current_sense") {
+			forwardSense, reverseSense, err = newCurrentSensors(cfg.Sub("current_sense"))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error while creating the current sensors: %s", err)
+			}
+		}
+		m, err = linearactuator.NewLinearActuator(chip.Pin(cfg.GetInt("pin_forward")), chip.Pin(cfg.GetInt("pin_backward")), pinEnable, limits, forwardSense, reverseSense, linearactuator.Settings{
+			Wiring:     linearactuator.Wiring(cfg.GetString("wiring")),
+			ActiveLow:  cfg.GetBool("active_low"),
+			DeadTime:   cfg.GetDuration("dead_time"),
+			TravelTime: cfg.GetDuration("travel_time"),
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while creating the linear actuator: %s", err)
+		}
+	case "sim":
+		plant = sim.NewPlant(cfg.GetDuration("travel_time"), cfg.GetFloat64("position"))
+		if cfg.IsSet("fault") {
+			plant.SetFault(sim.Fault(cfg.GetString("fault")), cfg.GetFloat64("jam_position"))
+		}
+		m = sim.NewMotor(plant)
+		sensor = plant
+	default:
+		return nil, nil, nil, fmt.Errorf("motor type does not exist: %s", cfg.GetString("type"))
+	}
+	logrus.Infoln("Successfully created the motor")
+
+	return m, sensor, plant, nil
+}
+
+// sortedKeys returns the keys of the map under the key of cfg, sorted.
+func sortedKeys(cfg *viper.Viper, key string) []string {
+	var keys []string
+	for k := range cfg.GetStringMap(key) {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// newNamedDoor returns the coop of the door with the name configured under the
+// doors key of the coop with the ID, and a function closing its door. Its state
+// and the calibration of its encoder are saved in their own files, next to the
+// ones of the coop by default, its events are recorded in the journal of the
+// coop. Its conditions are the ones of the coop unless it has its own, the lux
+// conditions use the light monitor of the coop.
+func newNamedDoor(chip gpio.Chip, id string, coopCfg *viper.Viper, name, calibrationFile, stateFile string, notifiers []notifiers.Notifier, j journal.Journal, light *lux.Monitor) (*coop.Coop, func(), error) {
+	if !namePattern.MatchString(name) {
+		return nil, nil, fmt.Errorf("name of the door is incorrect: %s", name)
+	}
+
+	cfg := coopCfg.Sub("doors." + name)
+	if cfg == nil {
+		cfg = viper.New()
+	}
+	cfg.SetDefault("opening.mode", coopCfg.GetString("coop.opening.mode"))
+	cfg.SetDefault("opening.value", conditionValue(coopCfg, "coop.opening"))
+	cfg.SetDefault("closing.mode", coopCfg.GetString("coop.closing.mode"))
+	cfg.SetDefault("closing.value", conditionValue(coopCfg, "coop.closing"))
+	cfg.SetDefault("state_file", suffixed(stateFile, name))
+	cfg.SetDefault("encoder.calibration_file", suffixed(calibrationFile, name))
+
+	d, closeDoor, err := newDoor(chip, id, name, cfg, cfg.GetString("encoder.calibration_file"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := coop.New(coopCfg.GetFloat64("coop.latitude"), coopCfg.GetFloat64("coop.longitude"), light, d, cfg.GetString("opening.mode"),
+		conditionValue(cfg, "opening"), cfg.GetString("closing.mode"), conditionValue(cfg, "closing"), "", "",
+		notifiers, state.NewFileStore(cfg.GetString("state_file")), j, false, false)
+	if err != nil {
+		closeDoor()
+		return nil, nil, err
+	}
+	c.SetName(name)
+	c.SetRedrive(coopCfg.GetBool("coop.redrive_on_mismatch"))
+
+	return c, closeDoor, nil
+}
+
+// suffixed returns the path with the suffix before its extension.
+func suffixed(path, suffix string) string {
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "-" + suffix + ext
+}
+
+// newTemperature returns the temperature sensor configured under the key of cfg.
+func newTemperature(chip gpio.Chip, cfg *viper.Viper, key string) temperature.Temperature {
+	sub := cfg.Sub(key)
+	if sub == nil {
+		sub = viper.New()
+	}
+
+	if sub.GetString("type") == "sim" {
+		sub.SetDefault("min", 50)
+		sub.SetDefault("max", 77)
+		sub.SetDefault("peak", "15h")
+		sub.SetDefault("humidity_min", 40)
+		sub.SetDefault("humidity_max", 80)
+
+		return sim.NewTemperature(sim.Curve{
+			Min:         sub.GetFloat64("min"),
+			Max:         sub.GetFloat64("max"),
+			Peak:        sub.GetDuration("peak"),
+			HumidityMin: sub.GetFloat64("humidity_min"),
+			HumidityMax: sub.GetFloat64("humidity_max"),
+		})
+	}
+
+	return temperature.NewTemperature(sub.GetString("name"), sub.GetString("type"), chip.Pin(sub.GetInt("pin")))
+}
+
+// newLimits returns the limit switches of the door configured under the stoplimit key of the door.
+func newLimits(chip gpio.Chip, sub *viper.Viper) (*limitswitch.Limits, error) {
+	sub.SetDefault("debounce", limitswitch.DefaultDebounce)
+	sub.SetDefault("pull", "up")
+	sub.SetDefault("active", "low")
+
+	pull, err := pullOf(sub.GetString("pull"))
+	if err != nil {
+		return nil, err
+	}
+	settings := limitswitch.Settings{
+		Debounce:   sub.GetDuration("debounce"),
+		Pull:       pull,
+		ActiveHigh: sub.GetString("active") == "high",
+	}
+
+	opened, err := limitswitch.New("open", chip.Pin(sub.GetInt("open_pin")), settings)
+	if err != nil {
+		return nil, err
+	}
+	closed, err := limitswitch.New("close", chip.Pin(sub.GetInt("close_pin")), settings)
+	if err != nil {
+		opened.Close()
+		return nil, err
+	}
+
+	return limitswitch.NewLimits(opened, closed), nil
+}
+
+// newEncoder returns the encoder of the door configured under the encoder key of the door.
+// The simulator counts the steps of the plant.
+func newEncoder(chip gpio.Chip, sub *viper.Viper, plant *sim.Plant) (encoder.Encoder, error) {
+	sub.SetDefault("pull", "up")
+
+	if plant != nil {
+		sub.SetDefault("counts", 1000)
+		return sim.NewEncoder(plant, sub.GetInt64("counts")), nil
+	}
+
+	pull, err := pullOf(sub.GetString("pull"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Polling misses the pulses of a fast encoder
+	if driver := viper.GetString("gpio.driver"); driver == "" || driver == "rpio" {
+		logrus.Warningln("The rpio driver watches the encoder by polling, the cdev driver is recommended")
+	}
+
+	switch sub.GetString("type") {
+	case "quadrature":
+		return encoder.NewQuadrature(chip.Pin(sub.GetInt("pin_a")), chip.Pin(sub.GetInt("pin_b")), pull, sub.GetBool("invert"))
+	case "hall":
+		return encoder.NewHall(chip.Pin(sub.GetInt("pin")), pull)
+	}
+
+	return nil, fmt.Errorf("encoder type does not exist: %s", sub.GetString("type"))
+}
+
+// newOdometer returns the odometer of the door with the travel saved in the calibration file.
+func newOdometer(enc encoder.Encoder, calibrationFile string) *door.Odometer {
+	calibration, err := encoder.LoadCalibration(calibrationFile)
+	if err != nil {
+		logrus.WithError(err).Warningln("Error while loading the calibration of the encoder, the door must be calibrated again")
+	}
+	if calibration.Travel == 0 {
+		logrus.Warningln("The encoder of the door is not calibrated")
+	}
+
+	return door.NewOdometer(enc, calibration.Travel, func(travel int64) {
+		err := encoder.SaveCalibration(calibrationFile, encoder.Calibration{Travel: travel})
+		if err != nil {
+			logrus.WithError(err).Errorln("Error while saving the calibration of the encoder")
+		}
+	})
+}
+
+// pullOf returns the pull resistor with the name.
+func pullOf(name string) (gpio.Pull, error) {
+	switch name {
+	case "up":
+		return gpio.PullUp, nil
+	case "down":
+		return gpio.PullDown, nil
+	case "none":
+		return gpio.PullNone, nil
+	}
+
+	return gpio.PullNone, fmt.Errorf("pull resistor does not exist: %s", name)
+}
+
+// newSafety returns the safety of the door configured under the safety key of the door.
+func newSafety(chip gpio.Chip, sub *viper.Viper, simulated bool) (*door.Safety, error) {
+	sub.SetDefault("retries", 3)
+	sub.SetDefault("retry_delay", door.DefaultRetryDelay)
+
+	safety := &door.Safety{
+		Retries:    sub.GetInt("retries"),
+		RetryDelay: sub.GetDuration("retry_delay"),
+	}
+
+	// Beam-break sensor
+	if sub.IsSet("beam_pin") && !simulated {
+		sub.SetDefault("beam_active", "low")
+		beam, err := limitswitch.New("beam", chip.Pin(sub.GetInt("beam_pin")), limitswitch.Settings{
+			Debounce:   limitswitch.DefaultDebounce,
+			Pull:       gpio.PullUp,
+			ActiveHigh: sub.GetString("beam_active") == "high",
+		})
+		if err != nil {
+			return nil, err
+		}
+		safety.Obstacle = beam
+	}
+
+	return safety, nil
+}
+
+// newCurrentSensors returns the current sensors of the two directions of the motor configured under the current_sense key of the motor.
+func newCurrentSensors(sub *viper.Viper) (*currentsense.Sensor, *currentsense.Sensor, error) {
+	sub.SetDefault("forward_channel", 0)
+	sub.SetDefault("reverse_channel", 1)
+	sub.SetDefault("amps_per_volt", 8.5)
+	sub.SetDefault("inrush", currentsense.DefaultInrush)
+	sub.SetDefault("interval", currentsense.DefaultInterval)
+	sub.SetDefault("idle_time", currentsense.DefaultIdleTime)
+
+	converter, err := newADC(sub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := currentsense.Settings{
+		AmpsPerVolt:  sub.GetFloat64("amps_per_volt"),
+		StallCurrent: sub.GetFloat64("stall_current"),
+		IdleCurrent:  sub.GetFloat64("idle_current"),
+		IdleTime:     sub.GetDuration("idle_time"),
+		Inrush:       sub.GetDuration("inrush"),
+		Interval:     sub.GetDuration("interval"),
+	}
+
+	return currentsense.New(converter, sub.GetInt("forward_channel"), settings), currentsense.New(converter, sub.GetInt("reverse_channel"), settings), nil
+}
+
+// newADC returns the converter configured under the adc key of sub.
+func newADC(sub *viper.Viper) (adc.ADC, error) {
+	switch sub.GetString("adc") {
+	case "mcp3008":
+		sub.SetDefault("device", "/dev/spidev0.0")
+		sub.SetDefault("speed", 1000000)
+		sub.SetDefault("vref", 3.3)
+		conn, err := adc.OpenSPI(sub.GetString("device"), uint32(sub.GetInt("speed")))
+		if err != nil {
+			return nil, err
+		}
+		return adc.NewMCP3008(conn, sub.GetFloat64("vref")), nil
+	case "ads1115":
+		sub.SetDefault("device", "/dev/i2c-1")
+		sub.SetDefault("address", 0x48)
+		sub.SetDefault("full_scale", 4.096)
+		conn, err := adc.OpenI2C(sub.GetString("device"), sub.GetInt("address"))
+		if err != nil {
+			return nil, err
+		}
+		return adc.NewADS1115(conn, sub.GetFloat64("full_scale"))
+	}
+
+	return nil, fmt.Errorf("ADC does not exist: %s", sub.GetString("adc"))
+}
+
+// newLightSensor returns the light sensor configured in sub.
+func newLightSensor(sub *viper.Viper) (lightsensor.Sensor, error) {
+	switch sub.GetString("type") {
+	case "bh1750":
+		sub.SetDefault("device", "/dev/i2c-1")
+		sub.SetDefault("address", 0x23)
+		conn, err := adc.OpenI2C(sub.GetString("device"), sub.GetInt("address"))
+		if err != nil {
+			return nil, err
+		}
+		return lightsensor.NewBH1750(conn), nil
+	case "tsl2561":
+		sub.SetDefault("device", "/dev/i2c-1")
+		sub.SetDefault("address", 0x39)
+		conn, err := adc.OpenI2C(sub.GetString("device"), sub.GetInt("address"))
+		if err != nil {
+			return nil, err
+		}
+		return lightsensor.NewTSL2561(conn), nil
+	case "ldr":
+		sub.SetDefault("channel", 0)
+		sub.SetDefault("supply", 3.3)
+		sub.SetDefault("resistor", 10000)
+		sub.SetDefault("r10", 15000)
+		sub.SetDefault("gamma", 0.7)
+		converter, err := newADC(sub)
+		if err != nil {
+			return nil, err
+		}
+		return lightsensor.NewLDR(converter, sub.GetInt("channel"), lightsensor.LDRSettings{
+			Supply:   sub.GetFloat64("supply"),
+			Resistor: sub.GetFloat64("resistor"),
+			R10:      sub.GetFloat64("r10"),
+			Gamma:    sub.GetFloat64("gamma"),
+		}), nil
+	case "sim":
+		sub.SetDefault("max", 10000)
+		sub.SetDefault("peak", "13h")
+		return sim.NewLight(sub.GetFloat64("max"), sub.GetDuration("peak")), nil
+	}
+
+	return nil, fmt.Errorf("light sensor does not exist: %s", sub.GetString("type"))
+}
+
+// isSimulated returns true if all the motors of the coops, named doors
+// included, are simulated. The GPIO chip is shared by the coops, so the
+// simulated and the real motors cannot be mixed.
+func isSimulated(local bool) (bool, error) {
+	var configs []*viper.Viper
+	if local {
+		configs = append(configs, viper.GetViper())
+	}
+	for _, id := range sortedKeys(viper.GetViper(), "coops") {
+		if cfg := viper.Sub("coops." + id); cfg != nil {
+			configs = append(configs, cfg)
+		}
+	}
+
+	simulated, real := 0, 0
+	for _, cfg := range configs {
+		types := []string{cfg.GetString("door.motor.type")}
+		for _, name := range sortedKeys(cfg, "doors") {
+			types = append(types, cfg.GetString("doors."+name+".motor.type"))
+		}
+		for _, t := range types {
+			if t == "sim" {
+				simulated++
+			} else {
+				real++
+			}
+		}
+	}
+
+	if simulated > 0 && real > 0 {
+		return false, fmt.Errorf("simulated and real motors cannot be mixed, the GPIO is shared by the coops")
+	}
+
+	return real == 0, nil
+}
+
+// newChip returns the GPIO chip configured under the gpio key. The simulator uses an in-memory chip.
+func newChip(simulated bool) (gpio.Chip, error) {
+	if simulated {
+		return gpio.NewFake(), nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"driver": viper.GetString("gpio.driver"),
+	}).Infoln("Opening the GPIO")
+	switch viper.GetString("gpio.driver") {
+	case "", "rpio":
+		return gpio.NewRPIO(viper.GetDuration("gpio.poll_interval"))
+	case "cdev":
+		viper.SetDefault("gpio.chip", "/dev/gpiochip0")
+		return gpio.NewCdev(viper.GetString("gpio.chip"))
+	}
+
+	return nil, fmt.Errorf("GPIO driver does not exist: %s", viper.GetString("gpio.driver"))
+}
+
+// newRemote returns the coop managed by the agent with the ID, configured
+// under hub.agents. Its reported events are recorded in its own journal, next
+// to the configuration file by default.
+func newRemote(id string, cfg *viper.Viper, dir string) (*agent.Remote, error) {
+	if !namePattern.MatchString(id) {
+		return nil, fmt.Errorf("ID of the coop is incorrect: %s", id)
+	}
+	key := "hub.agents." + id
+
+	journalFile := fileOf(cfg, key+".journal_file", dir, "journal.jsonl", id)
+	logrus.WithFields(logrus.Fields{
+		"coop": id,
+		"file": journalFile,
+	}).Infoln("Using the journal file")
+	j, err := journal.NewFileJournal(journalFile)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the journal: %s", err)
+	}
+
+	return agent.NewRemote(id, cfg.GetString(key+".name"), j, cfg.GetDuration("hub.offline_after")), nil
+}
+
+// serveHub serves the reports of the agents over mutual TLS.
+func serveHub(remotes []*agent.Remote) {
+	tlsConfig, err := agent.NewServerTLSConfig(viper.GetString("hub.tls_cert"), viper.GetString("hub.tls_key"), viper.GetString("hub.ca_file"))
+	if err != nil {
+		logrus.WithError(err).Fatalln("Error while loading the TLS configuration of the hub")
+	}
+
+	viper.SetDefault("hub.listen", ":8443")
+	s := &http.Server{
+		Addr:           viper.GetString("hub.listen"),
+		TLSConfig:      tlsConfig,
+		Handler:        agent.NewHub(remotes...),
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+	logrus.WithFields(logrus.Fields{
+		"port": s.Addr,
+	}).Infoln("Starting the hub")
+	logrus.WithError(s.ListenAndServeTLS("", "")).Fatalln("Error while serving the hub")
+}
+
+// handleCoop registers the pages and the API of the coop under /coops/<id>.
+func handleCoop(router, api *mux.Router, authenticator *auth.BasicAuth, registry *services.Registry, s services.CoopService) {
+	p := "/coops/" + s.ID()
+	miscCtrl := routes.NewMiscController(s, registry, p, p)
+	apiCtrl := routes.NewAPIController(s)
+
+	router.HandleFunc(p, authenticator.Wrap(miscCtrl.Index))
+	router.HandleFunc(p+"/", authenticator.Wrap(miscCtrl.Index))
+	router.HandleFunc(p+"/configuration", authenticator.Wrap(miscCtrl.Configuration))
+	router.HandleFunc(p+"/history", authenticator.Wrap(miscCtrl.History))
+	router.HandleFunc(p+"/open", authenticator.Wrap(miscCtrl.OpenCoopDoorManually))
+	router.HandleFunc(p+"/close", authenticator.Wrap(miscCtrl.CloseCoopDoorManually))
+	router.HandleFunc(p+"/partial", authenticator.Wrap(miscCtrl.PartialCoopDoorManually))
+	router.HandleFunc(p+"/stop", authenticator.Wrap(miscCtrl.StopCoopDoorManually))
+	router.HandleFunc(p+"/temperature", authenticator.Wrap(miscCtrl.GetCoopTemperature))
+	router.HandleFunc(p+"/camera/still", authenticator.Wrap(miscCtrl.ProcessCaptureRequest))
+	router.HandleFunc(p+"/doors/{name}/open", authenticator.Wrap(miscCtrl.OpenDoorManually))
+	router.HandleFunc(p+"/doors/{name}/close", authenticator.Wrap(miscCtrl.CloseDoorManually))
+	router.HandleFunc(p+"/doors/{name}/stop", authenticator.Wrap(miscCtrl.StopDoorManually))
+
+	api.HandleFunc(p, authenticator.Wrap(apiCtrl.GetCoop)).Methods("GET")
+	api.HandleFunc(p, authenticator.Wrap(apiCtrl.UpdateCoop)).Methods("PUT")
+	api.HandleFunc(p+"/conditions", authenticator.Wrap(apiCtrl.GetConditions)).Methods("GET")
+	api.HandleFunc(p+"/schedule", authenticator.Wrap(apiCtrl.GetSchedule)).Methods("GET")
+	api.HandleFunc(p+"/open", authenticator.Wrap(apiCtrl.Open)).Methods("POST")
+	api.HandleFunc(p+"/close", authenticator.Wrap(apiCtrl.Close)).Methods("POST")
+	api.HandleFunc(p+"/partial", authenticator.Wrap(apiCtrl.Partial)).Methods("POST")
+	api.HandleFunc(p+"/stop", authenticator.Wrap(apiCtrl.Stop)).Methods("POST")
+	api.HandleFunc(p+"/calibrate", authenticator.Wrap(apiCtrl.Calibrate)).Methods("POST")
+	api.HandleFunc(p+"/history", authenticator.Wrap(apiCtrl.GetHistory)).Methods("GET")
+	api.HandleFunc(p+"/sensors", authenticator.Wrap(apiCtrl.GetSensors)).Methods("GET")
+	api.HandleFunc(p+"/doors", authenticator.Wrap(apiCtrl.GetDoors)).Methods("GET")
+	api.HandleFunc(p+"/doors/{name}", authenticator.Wrap(apiCtrl.GetDoor)).Methods("GET")
+	api.HandleFunc(p+"/doors/{name}", authenticator.Wrap(apiCtrl.UpdateDoor)).Methods("PUT")
+	api.HandleFunc(p+"/doors/{name}/open", authenticator.Wrap(apiCtrl.OpenDoor)).Methods("POST")
+	api.HandleFunc(p+"/doors/{name}/close", authenticator.Wrap(apiCtrl.CloseDoor)).Methods("POST")
+	api.HandleFunc(p+"/doors/{name}/stop", authenticator.Wrap(apiCtrl.StopDoor)).Methods("POST")
+	api.HandleFunc(p+"/doors/{name}/history", authenticator.Wrap(apiCtrl.GetDoorHistory)).Methods("GET")
+}
+
+// readSensors reads the sensors of the coops periodically, so that their metrics are up to date.
+func readSensors(registry *services.Registry, interval time.Duration) {
+	for range time.Tick(interval) {
+		for _, coopService := range registry.All() {
+			_, _, _, _, err := coopService.GetTemp()
+			if err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"coop": coopService.ID(),
+				}).Errorln("Error while reading the sensors")
+			}
+		}
+	}
+}
+
+// openAPIHandler serves the OpenAPI document of the API.
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := StaticFS.ReadFile("static/openapi.yaml")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(data)
+}
+
 // Secret holds the secret password.
 func Secret(user, realm string) string {
 	if user == viper.GetString("general.gui_username") {
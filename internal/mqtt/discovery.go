@@ -0,0 +1,118 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// entity is an entity of Home Assistant.
+type entity struct {
+	component string
+	objectID  string
+	config    map[string]interface{}
+}
+
+// entities returns the entities of the coop.
+func (b *Bridge) entities() []entity {
+	entities := []entity{
+		{
+			component: "cover",
+			objectID:  "door",
+			config: map[string]interface{}{
+				"name":          "Door",
+				"device_class":  "door",
+				"command_topic": b.topic("door/set"),
+				"state_topic":   b.topic("door/state"),
+				"payload_open":  "OPEN",
+				"payload_close": "CLOSE",
+				"payload_stop":  "STOP",
+				"state_open":    "open",
+				"state_opening": "opening",
+				"state_closed":  "closed",
+				"state_closing": "closing",
+			},
+		},
+		{
+			component: "switch",
+			objectID:  "automatic",
+			config: map[string]interface{}{
+				"name":          "Automatic mode",
+				"icon":          "mdi:calendar-clock",
+				"command_topic": b.topic("automatic/set"),
+				"state_topic":   b.topic("automatic/state"),
+			},
+		},
+		{
+			component: "fan",
+			objectID:  "fan",
+			config: map[string]interface{}{
+				"name":                      "Fan",
+				"command_topic":             b.topic("fan/set"),
+				"state_topic":               b.topic("fan/state"),
+				"preset_mode_command_topic": b.topic("fan/mode/set"),
+				"preset_mode_state_topic":   b.topic("fan/mode/state"),
+				"preset_modes":              []string{"auto", "manual"},
+			},
+		},
+	}
+
+	sensors := []struct {
+		objectID    string
+		name        string
+		deviceClass string
+		unit        string
+	}{
+		{"inside_temperature", "Inside temperature", "temperature", "°F"},
+		{"inside_humidity", "Inside humidity", "humidity", "%"},
+		{"outside_temperature", "Outside temperature", "temperature", "°F"},
+		{"outside_humidity", "Outside humidity", "humidity", "%"},
+	}
+	for _, sensor := range sensors {
+		entities = append(entities, entity{
+			component: "sensor",
+			objectID:  sensor.objectID,
+			config: map[string]interface{}{
+				"name":                sensor.name,
+				"device_class":        sensor.deviceClass,
+				"state_class":         "measurement",
+				"unit_of_measurement": sensor.unit,
+				"state_topic":         b.topic("sensors"),
+				"value_template":      fmt.Sprintf("{{ value_json.%s }}", sensor.objectID),
+			},
+		})
+	}
+
+	return entities
+}
+
+// discoveryMessages returns the discovery messages of the entities.
+func (b *Bridge) discoveryMessages() []message {
+	device := map[string]interface{}{
+		"identifiers":  []string{b.settings.ClientID},
+		"name":         "GoCoop",
+		"manufacturer": "GoCoop",
+	}
+
+	var messages []message
+	for _, e := range b.entities() {
+		e.config["unique_id"] = b.settings.ClientID + "_" + e.objectID
+		e.config["availability_topic"] = b.topic("availability")
+		e.config["device"] = device
+
+		payload, err := json.Marshal(e.config)
+		if err != nil {
+			logrus.WithError(err).Errorln("Error while marshalling the discovery payload")
+			continue
+		}
+
+		messages = append(messages, message{
+			topic:    fmt.Sprintf("%s/%s/%s/%s/config", b.settings.DiscoveryPrefix, e.component, b.settings.ClientID, e.objectID),
+			payload:  payload,
+			retained: true,
+		})
+	}
+
+	return messages
+}
@@ -0,0 +1,390 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+const (
+	// Online is the payload published on the availability topic when connected.
+	Online = "online"
+
+	// Offline is the payload published by the broker on behalf of the bridge when it disconnects.
+	Offline = "offline"
+
+	// By is the name recorded in the journal for the commands received over MQTT.
+	By = "mqtt"
+
+	// DefaultTopicPrefix is the default prefix of the topics.
+	DefaultTopicPrefix = "gocoop"
+
+	// DefaultDiscoveryPrefix is the default discovery prefix of Home Assistant.
+	DefaultDiscoveryPrefix = "homeassistant"
+
+	// DefaultClientID is the default client ID.
+	DefaultClientID = "gocoop"
+
+	// DefaultSensorsInterval is the default interval between two readings of the sensors.
+	DefaultSensorsInterval = 5 * time.Minute
+
+	qos          = 1
+	tokenTimeout = 10 * time.Second
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings are the settings of the bridge.
+type Settings struct {
+	Broker          string
+	ClientID        string
+	Username        string
+	Password        string
+	TopicPrefix     string
+	DiscoveryPrefix string
+	SensorsInterval time.Duration
+}
+
+// Bridge publishes the coop on an MQTT broker and handles the commands.
+type Bridge struct {
+	client      paho.Client
+	coopService services.CoopService
+	settings    Settings
+	done        chan struct{}
+}
+
+// message is a message to publish.
+type message struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewBridge returns a new Bridge.
+func NewBridge(coopService services.CoopService, settings Settings) *Bridge {
+	if settings.ClientID == "" {
+		settings.ClientID = DefaultClientID
+	}
+	if settings.TopicPrefix == "" {
+		settings.TopicPrefix = DefaultTopicPrefix
+	}
+	if settings.DiscoveryPrefix == "" {
+		settings.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if settings.SensorsInterval <= 0 {
+		settings.SensorsInterval = DefaultSensorsInterval
+	}
+
+	b := &Bridge{
+		coopService: coopService,
+		settings:    settings,
+		done:        make(chan struct{}),
+	}
+
+	opts := paho.NewClientOptions()
+	opts.AddBroker(settings.Broker)
+	opts.SetClientID(settings.ClientID)
+	opts.SetUsername(settings.Username)
+	opts.SetPassword(settings.Password)
+	opts.SetAutoReconnect(true)
+	opts.SetWill(b.topic("availability"), Offline, qos, true)
+	opts.SetOnConnectHandler(func(paho.Client) {
+		// Tokens must not be waited for in the handlers of the client
+		go b.setup()
+	})
+	opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		logrus.WithError(err).Warningln("Connection to the MQTT broker lost")
+	})
+	b.client = paho.NewClient(opts)
+
+	return b
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Start connects to the broker and starts publishing the coop.
+func (b *Bridge) Start() error {
+	logrus.WithFields(logrus.Fields{
+		"broker": b.settings.Broker,
+	}).Infoln("Connecting to the MQTT broker")
+
+	token := b.client.Connect()
+	if !token.WaitTimeout(tokenTimeout) {
+		return fmt.Errorf("timeout while connecting to the MQTT broker")
+	}
+	if token.Error() != nil {
+		return fmt.Errorf("error while connecting to the MQTT broker: %s", token.Error())
+	}
+
+	go b.watch(b.coopService.GetCoop().Subscribe())
+	go b.readSensors()
+
+	return nil
+}
+
+// Stop publishes the bridge as offline and disconnects from the broker.
+func (b *Bridge) Stop() {
+	close(b.done)
+
+	token := b.client.Publish(b.topic("availability"), qos, true, Offline)
+	token.WaitTimeout(tokenTimeout)
+
+	b.client.Disconnect(250)
+}
+
+// setup subscribes to the command topics and publishes the discovery payloads and the states.
+// It is called on every connection, so that everything is restored after the broker restarts.
+func (b *Bridge) setup() {
+	logrus.Infoln("Connected to the MQTT broker")
+
+	filters := map[string]byte{
+		b.topic("door/set"):      qos,
+		b.topic("automatic/set"): qos,
+		b.topic("fan/set"):       qos,
+		b.topic("fan/mode/set"):  qos,
+	}
+	token := b.client.SubscribeMultiple(filters, b.onCommand)
+	if token.WaitTimeout(tokenTimeout) && token.Error() != nil {
+		logrus.WithError(token.Error()).Errorln("Error while subscribing to the command topics")
+	}
+
+	for _, msg := range b.discoveryMessages() {
+		b.publish(msg)
+	}
+	b.publish(message{topic: b.topic("availability"), payload: []byte(Online), retained: true})
+	b.publishStates()
+}
+
+// watch publishes the states every time the coop changes.
+func (b *Bridge) watch(events <-chan journal.Event) {
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-events:
+			b.publishStates()
+		}
+	}
+}
+
+// readSensors publishes the readings of the sensors periodically.
+func (b *Bridge) readSensors() {
+	ticker := time.NewTicker(b.settings.SensorsInterval)
+	defer ticker.Stop()
+
+	for {
+		b.publishSensors()
+
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishStates publishes the states of the door, the automatic mode and the fan.
+func (b *Bridge) publishStates() {
+	for _, msg := range b.stateMessages() {
+		b.publish(msg)
+	}
+}
+
+// publishSensors publishes the readings of the sensors.
+func (b *Bridge) publishSensors() {
+	msg, err := b.sensorsMessage()
+	if err != nil {
+		logrus.WithError(err).Errorln("Error while reading the sensors")
+		return
+	}
+
+	b.publish(msg)
+	b.publishStates()
+}
+
+// publish publishes the message without waiting for the acknowledgement.
+func (b *Bridge) publish(msg message) {
+	token := b.client.Publish(msg.topic, qos, msg.retained, msg.payload)
+
+	go func() {
+		if token.WaitTimeout(tokenTimeout) && token.Error() != nil {
+			logrus.WithError(token.Error()).WithFields(logrus.Fields{
+				"topic": msg.topic,
+			}).Errorln("Error while publishing the message")
+		}
+	}()
+}
+
+// onCommand handles the messages received on the command topics.
+func (b *Bridge) onCommand(_ paho.Client, msg paho.Message) {
+	err := b.handle(msg.Topic(), string(msg.Payload()))
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"topic":   msg.Topic(),
+			"payload": string(msg.Payload()),
+		}).Errorln("Error while handling the command")
+	}
+
+	// Publish the states anyway, so that Home Assistant does not keep an optimistic state
+	go b.publishStates()
+}
+
+// handle executes the command received on the topic.
+func (b *Bridge) handle(topic, payload string) error {
+	payload = strings.ToUpper(strings.TrimSpace(payload))
+
+	switch topic {
+	case b.topic("door/set"):
+		switch payload {
+		case "OPEN":
+			return b.coopService.StartOpen(By)
+		case "CLOSE":
+			return b.coopService.StartClose(By)
+		case "STOP":
+			return b.coopService.Stop(By)
+		}
+	case b.topic("automatic/set"):
+		switch payload {
+		case "ON", "OFF":
+			return b.setAutomatic(payload == "ON")
+		}
+	case b.topic("fan/set"):
+		switch payload {
+		case "ON", "OFF":
+			b.coopService.SetFan(payload == "ON")
+			return nil
+		}
+	case b.topic("fan/mode/set"):
+		switch payload {
+		case "AUTO":
+			b.coopService.ResetFan()
+			return nil
+		case "MANUAL":
+			b.coopService.SetFan(b.coopService.IsFanOn())
+			return nil
+		}
+	default:
+		return fmt.Errorf("topic is not handled: %s", topic)
+	}
+
+	return fmt.Errorf("payload is incorrect: %s", payload)
+}
+
+// setAutomatic enables or disables the automatic mode, the rest is left unchanged.
+func (b *Bridge) setAutomatic(isAutomatic bool) error {
+	c := b.coopService.GetCoop()
+	oc := c.OpeningCondition()
+	cc := c.ClosingCondition()
+	var pc services.ConditionUpdateRequest
+	if partial := c.PartialCondition(); partial != nil {
+		pc.Mode = partial.Mode()
+		pc.Value = partial.Value()
+	}
+
+	return b.coopService.Update(services.CoopUpdateRequest{
+		Status:      c.Status(),
+		IsAutomatic: isAutomatic,
+		By:          By,
+		OpeningCondition: services.ConditionUpdateRequest{
+			Mode:  oc.Mode(),
+			Value: oc.Value(),
+		},
+		ClosingCondition: services.ConditionUpdateRequest{
+			Mode:  cc.Mode(),
+			Value: cc.Value(),
+		},
+		PartialCondition: pc,
+	})
+}
+
+// stateMessages returns the messages of the states of the door, the automatic mode, the fan and its mode.
+func (b *Bridge) stateMessages() []message {
+	c := b.coopService.GetCoop()
+
+	return []message{
+		{topic: b.topic("door/state"), payload: []byte(coverState(c.Status())), retained: true},
+		{topic: b.topic("automatic/state"), payload: []byte(onOff(c.IsAutomatic())), retained: true},
+		{topic: b.topic("fan/state"), payload: []byte(onOff(b.coopService.IsFanOn())), retained: true},
+		{topic: b.topic("fan/mode/state"), payload: []byte(fanMode(b.coopService.IsFanManual())), retained: true},
+	}
+}
+
+// sensorsMessage reads the sensors and returns the message of the readings.
+func (b *Bridge) sensorsMessage() (message, error) {
+	inTemp, inHumidity, outTemp, outHumidity, err := b.coopService.GetTemp()
+	if err != nil {
+		return message{}, err
+	}
+
+	payload, err := json.Marshal(map[string]float32{
+		"inside_temperature":  inTemp,
+		"inside_humidity":     inHumidity,
+		"outside_temperature": outTemp,
+		"outside_humidity":    outHumidity,
+	})
+	if err != nil {
+		return message{}, fmt.Errorf("error while marshalling the readings: %s", err)
+	}
+
+	return message{topic: b.topic("sensors"), payload: payload, retained: true}, nil
+}
+
+// topic returns the full topic.
+func (b *Bridge) topic(name string) string {
+	return b.settings.TopicPrefix + "/" + name
+}
+
+// coverState returns the state of the cover in Home Assistant.
+func coverState(status coop.Status) string {
+	switch status {
+	case coop.Opened, coop.Partial:
+		return "open"
+	case coop.Opening:
+		return "opening"
+	case coop.Closed:
+		return "closed"
+	case coop.Closing:
+		return "closing"
+	}
+
+	// Resets the cover to an unknown state
+	return "None"
+}
+
+// fanMode returns the preset mode of the fan.
+func fanMode(isManual bool) string {
+	if isManual {
+		return "manual"
+	}
+
+	return "auto"
+}
+
+// onOff returns the payload of a boolean.
+func onOff(b bool) string {
+	if b {
+		return "ON"
+	}
+
+	return "OFF"
+}
@@ -0,0 +1,172 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services/servicestest"
+	"github.com/fallais/gocoop/pkg/coop"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+func TestHandle(t *testing.T) {
+	s := servicestest.NewService("default", "", nil)
+	b := NewBridge(s, Settings{Broker: "tcp://localhost:1883"})
+
+	for _, payload := range []string{"OPEN", "close", " STOP "} {
+		err := b.handle("gocoop/door/set", payload)
+		if err != nil {
+			t.Fatalf("should not error: %s", err)
+		}
+	}
+	commands := s.Commands()
+	if len(commands) != 3 || commands[0] != "open by mqtt" || commands[1] != "close by mqtt" || commands[2] != "stop by mqtt" {
+		t.Fatalf("commands are incorrect: %v", commands)
+	}
+
+	err := b.handle("gocoop/fan/set", "ON")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if !s.IsFanOn() || !s.IsFanManual() {
+		t.Fatal("fan should be on manually")
+	}
+	err = b.handle("gocoop/fan/mode/set", "auto")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if s.IsFanManual() {
+		t.Fatal("fan should be driven by the temperature")
+	}
+
+	err = b.handle("gocoop/door/set", "UP")
+	if err == nil {
+		t.Fatal("should error for an incorrect payload")
+	}
+	err = b.handle("gocoop/light/set", "ON")
+	if err == nil {
+		t.Fatal("should error for an incorrect topic")
+	}
+}
+
+func TestDiscoveryMessages(t *testing.T) {
+	b := NewBridge(servicestest.NewService("default", "", nil), Settings{Broker: "tcp://localhost:1883", ClientID: "coop1", TopicPrefix: "farm"})
+
+	messages := b.discoveryMessages()
+	if len(messages) != 7 {
+		t.Fatalf("should have 7 entities, it has %d", len(messages))
+	}
+
+	cover := messages[0]
+	if cover.topic != "homeassistant/cover/coop1/door/config" || !cover.retained {
+		t.Fatalf("cover message is incorrect: %s", cover.topic)
+	}
+
+	var config map[string]interface{}
+	err := json.Unmarshal(cover.payload, &config)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if config["unique_id"] != "coop1_door" {
+		t.Errorf("unique_id is incorrect: %v", config["unique_id"])
+	}
+	if config["availability_topic"] != "farm/availability" {
+		t.Errorf("availability_topic is incorrect: %v", config["availability_topic"])
+	}
+	if config["command_topic"] != "farm/door/set" {
+		t.Errorf("command_topic is incorrect: %v", config["command_topic"])
+	}
+}
+
+func TestCoverState(t *testing.T) {
+	tests := map[coop.Status]string{
+		coop.Opened:  "open",
+		coop.Opening: "opening",
+		coop.Closed:  "closed",
+		coop.Closing: "closing",
+		coop.Partial: "open",
+		coop.Unknown: "None",
+	}
+	for status, expected := range tests {
+		if coverState(status) != expected {
+			t.Errorf("state of %s should be %s, it is %s", status, expected, coverState(status))
+		}
+	}
+}
+
+// TestBroker runs against a real broker, for example :
+// GOCOOP_MQTT_BROKER=tcp://localhost:1883 go test ./internal/mqtt
+func TestBroker(t *testing.T) {
+	broker := os.Getenv("GOCOOP_MQTT_BROKER")
+	if broker == "" {
+		t.Skip("GOCOOP_MQTT_BROKER is not set")
+	}
+
+	c := servicestest.NewCoop()
+	s := servicestest.NewService("default", "", c)
+	prefix := fmt.Sprintf("gocoop-test-%d", time.Now().UnixNano())
+	b := NewBridge(s, Settings{Broker: broker, ClientID: prefix, TopicPrefix: prefix})
+
+	// Listen to the availability and the states
+	received := make(chan string, 16)
+	opts := paho.NewClientOptions().AddBroker(broker).SetClientID(prefix + "-listener")
+	listener := paho.NewClient(opts)
+	token := listener.Connect()
+	if token.Wait() && token.Error() != nil {
+		t.Fatalf("should not error: %s", token.Error())
+	}
+	defer listener.Disconnect(250)
+	token = listener.Subscribe(prefix+"/#", qos, func(_ paho.Client, msg paho.Message) {
+		received <- msg.Topic() + " " + string(msg.Payload())
+	})
+	if token.Wait() && token.Error() != nil {
+		t.Fatalf("should not error: %s", token.Error())
+	}
+
+	err := b.Start()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer b.Stop()
+	waitFor(t, received, prefix+"/availability online")
+
+	// Send a command
+	token = listener.Publish(prefix+"/door/set", qos, false, "OPEN")
+	if token.Wait() && token.Error() != nil {
+		t.Fatalf("should not error: %s", token.Error())
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for len(s.Commands()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("should receive the command")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Change the state
+	err = c.Update("test", coop.Closed, false, c.OpeningCondition(), c.ClosingCondition(), nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	waitFor(t, received, prefix+"/door/state closed")
+}
+
+func waitFor(t *testing.T, received <-chan string, expected string) {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg := <-received:
+			if msg == expected {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("should receive %s", expected)
+		}
+	}
+}
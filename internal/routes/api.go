@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	auth "github.com/abbot/go-http-auth"
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/internal/utils"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/door"
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// APIController is the controller of the JSON API.
+type APIController struct {
+	coopService services.CoopService
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewAPIController returns a new APIController.
+func NewAPIController(coopService services.CoopService) *APIController {
+	return &APIController{
+		coopService: coopService,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Routes
+//------------------------------------------------------------------------------
+
+// GetCoop returns the coop.
+func (ctrl *APIController) GetCoop(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	writeJSON(w, http.StatusOK, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// UpdateCoop updates the coop. Missing fields are left unchanged.
+func (ctrl *APIController) UpdateCoop(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	c := ctrl.coopService.GetCoop()
+
+	// Start from the current values
+	oc := c.OpeningCondition()
+	cc := c.ClosingCondition()
+	pc := newPartialConditionResponse(c.PartialCondition())
+	input := CoopUpdateAPIRequest{
+		Status:      c.Status(),
+		IsAutomatic: c.IsAutomatic(),
+		OpeningCondition: ConditionAPIRequest{
+			Mode:  oc.Mode(),
+			Value: oc.Value(),
+		},
+		ClosingCondition: ConditionAPIRequest{
+			Mode:  cc.Mode(),
+			Value: cc.Value(),
+		},
+		PartialCondition: ConditionAPIRequest{
+			Mode:  pc.Mode,
+			Value: pc.Value,
+		},
+	}
+
+	// Parse the request
+	err := utils.ParseRequest(&r.Request, &input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Update the coop
+	err = ctrl.coopService.Update(services.CoopUpdateRequest{
+		Status:      input.Status,
+		IsAutomatic: input.IsAutomatic,
+		By:          r.Username,
+		OpeningCondition: services.ConditionUpdateRequest{
+			Mode:  input.OpeningCondition.Mode,
+			Value: input.OpeningCondition.Value,
+		},
+		ClosingCondition: services.ConditionUpdateRequest{
+			Mode:  input.ClosingCondition.Mode,
+			Value: input.ClosingCondition.Value,
+		},
+		PartialCondition: services.ConditionUpdateRequest{
+			Mode:  input.PartialCondition.Mode,
+			Value: input.PartialCondition.Value,
+		},
+	})
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCoopAPIResponse(c))
+}
+
+// GetConditions returns the conditions of the coop.
+func (ctrl *APIController) GetConditions(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	c := ctrl.coopService.GetCoop()
+	oc := c.OpeningCondition()
+	cc := c.ClosingCondition()
+
+	writeJSON(w, http.StatusOK, ConditionsAPIResponse{
+		Opening: ConditionAPIResponse{
+			Mode:     oc.Mode(),
+			Value:    oc.Value(),
+			Time:     oc.OpeningTime(),
+			NextTime: oc.NextOpeningTime(),
+		},
+		Closing: ConditionAPIResponse{
+			Mode:     cc.Mode(),
+			Value:    cc.Value(),
+			Time:     cc.ClosingTime(),
+			NextTime: cc.NextClosingTime(),
+		},
+		Partial: newPartialConditionAPIResponse(c.PartialCondition()),
+	})
+}
+
+// GetSchedule returns the next opening and closing times of the coop.
+func (ctrl *APIController) GetSchedule(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	c := ctrl.coopService.GetCoop()
+
+	writeJSON(w, http.StatusOK, ScheduleAPIResponse{
+		NextOpeningTime: c.NextOpeningTime(),
+		NextClosingTime: c.NextClosingTime(),
+	})
+}
+
+// Open starts opening the coop.
+func (ctrl *APIController) Open(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartOpen(r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// Close starts closing the coop.
+func (ctrl *APIController) Close(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartClose(r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// Partial starts moving the door of the coop to the partial position.
+func (ctrl *APIController) Partial(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartPartial(r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// Calibrate starts learning the travel of the encoder of the door.
+func (ctrl *APIController) Calibrate(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartCalibrate(r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// Stop stops the door of the coop.
+func (ctrl *APIController) Stop(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.Stop(r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCoopAPIResponse(ctrl.coopService.GetCoop()))
+}
+
+// GetSensors returns the readings of the sensors.
+func (ctrl *APIController) GetSensors(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	inTemp, inHumidity, outTemp, outHumidity, err := ctrl.coopService.GetTemp()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SensorsAPIResponse{
+		InsideTemperature:  inTemp,
+		InsideHumidity:     inHumidity,
+		OutsideTemperature: outTemp,
+		OutsideHumidity:    outHumidity,
+	})
+}
+
+// GetHistory returns the history of the coop.
+func (ctrl *APIController) GetHistory(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	response, err := getHistory(ctrl.coopService.GetHistory, &r.Request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// statusCode returns the HTTP status code matching the error.
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, coop.ErrAutomaticModeEnabled),
+		errors.Is(err, coop.ErrCoopAlreadyOpened),
+		errors.Is(err, coop.ErrCoopAlreadyOpening),
+		errors.Is(err, coop.ErrCoopAlreadyClosed),
+		errors.Is(err, coop.ErrCoopAlreadyClosing),
+		errors.Is(err, coop.ErrCoopAlreadyPartial),
+		errors.Is(err, coop.ErrStatusUnknown),
+		errors.Is(err, coop.ErrTransitionNotAllowed):
+		return http.StatusConflict
+	case errors.Is(err, coop.ErrIncorrectStatus),
+		errors.Is(err, services.ErrIncorrectCondition):
+		return http.StatusBadRequest
+	case errors.Is(err, door.ErrNoEncoder),
+		errors.Is(err, door.ErrNoSensor),
+		errors.Is(err, coop.ErrNoPartialPosition):
+		return http.StatusNotImplemented
+	case errors.Is(err, services.ErrCoopOffline):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrDoorNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes the value as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate, private")
+	w.WriteHeader(code)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		logrus.WithError(err).Errorln("Error while encoding the response")
+	}
+}
+
+// writeError writes the error as JSON with the given status code.
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ErrorAPIResponse{
+		Error: err.Error(),
+	})
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/door"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{coop.ErrAutomaticModeEnabled, http.StatusConflict},
+		{&coop.TransitionError{From: coop.Opened, To: coop.Opening, Err: coop.ErrCoopAlreadyOpened}, http.StatusConflict},
+		{&coop.TransitionError{From: coop.Closing, To: coop.Opened, Err: coop.ErrCoopAlreadyClosing}, http.StatusConflict},
+		{&coop.TransitionError{From: coop.Partial, To: coop.Partial, Err: coop.ErrCoopAlreadyPartial}, http.StatusConflict},
+		{coop.ErrIncorrectStatus, http.StatusBadRequest},
+		{fmt.Errorf("%w: mode does not exist", services.ErrIncorrectCondition), http.StatusBadRequest},
+		{door.ErrNoEncoder, http.StatusNotImplemented},
+		{coop.ErrNoPartialPosition, http.StatusNotImplemented},
+		{fmt.Errorf("%w: run_gate", services.ErrDoorNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: barn", services.ErrCoopOffline), http.StatusServiceUnavailable},
+		{fmt.Errorf("error while running the door"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if code := statusCode(test.err); code != test.code {
+			t.Errorf("should be %d for %q, it is %d", test.code, test.err, code)
+		}
+	}
+}
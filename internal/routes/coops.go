@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+
+	auth "github.com/abbot/go-http-auth"
+	"github.com/fallais/gocoop/internal/services"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// CoopsController is the controller of the coops managed by the instance.
+type CoopsController struct {
+	coops *services.Registry
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewCoopsController returns a new CoopsController.
+func NewCoopsController(coops *services.Registry) *CoopsController {
+	return &CoopsController{
+		coops: coops,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Routes
+//------------------------------------------------------------------------------
+
+// GetCoops returns the coops, the default coop first.
+func (ctrl *CoopsController) GetCoops(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	coops := []CoopsItemAPIResponse{}
+	for _, c := range ctrl.coops.All() {
+		coops = append(coops, CoopsItemAPIResponse{
+			ID:              c.ID(),
+			Name:            c.Name(),
+			CoopAPIResponse: newCoopAPIResponse(c.GetCoop()),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, coops)
+}
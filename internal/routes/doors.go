@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"net/http"
+
+	auth "github.com/abbot/go-http-auth"
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/internal/utils"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Routes
+//------------------------------------------------------------------------------
+
+// GetDoors returns the named doors of the coop.
+func (ctrl *APIController) GetDoors(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	doors := []DoorAPIResponse{}
+	for _, d := range ctrl.coopService.GetDoors() {
+		doors = append(doors, newDoorAPIResponse(d))
+	}
+
+	writeJSON(w, http.StatusOK, doors)
+}
+
+// GetDoor returns a named door of the coop.
+func (ctrl *APIController) GetDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	d, err := ctrl.coopService.GetDoor(doorName(r))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDoorAPIResponse(d))
+}
+
+// UpdateDoor updates a named door of the coop. Missing fields are left unchanged.
+func (ctrl *APIController) UpdateDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	d, err := ctrl.coopService.GetDoor(doorName(r))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	// Start from the current values
+	oc := d.OpeningCondition()
+	cc := d.ClosingCondition()
+	input := DoorUpdateAPIRequest{
+		Status:      d.Status(),
+		IsAutomatic: d.IsAutomatic(),
+		OpeningCondition: ConditionAPIRequest{
+			Mode:  oc.Mode(),
+			Value: oc.Value(),
+		},
+		ClosingCondition: ConditionAPIRequest{
+			Mode:  cc.Mode(),
+			Value: cc.Value(),
+		},
+	}
+
+	// Parse the request
+	err = utils.ParseRequest(&r.Request, &input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Update the door
+	err = ctrl.coopService.UpdateDoor(d.Name(), services.CoopUpdateRequest{
+		Status:      input.Status,
+		IsAutomatic: input.IsAutomatic,
+		By:          r.Username,
+		OpeningCondition: services.ConditionUpdateRequest{
+			Mode:  input.OpeningCondition.Mode,
+			Value: input.OpeningCondition.Value,
+		},
+		ClosingCondition: services.ConditionUpdateRequest{
+			Mode:  input.ClosingCondition.Mode,
+			Value: input.ClosingCondition.Value,
+		},
+	})
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDoorAPIResponse(d))
+}
+
+// OpenDoor starts opening a named door of the coop.
+func (ctrl *APIController) OpenDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	ctrl.runDoor(w, r, http.StatusAccepted, ctrl.coopService.StartOpenDoor)
+}
+
+// CloseDoor starts closing a named door of the coop.
+func (ctrl *APIController) CloseDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	ctrl.runDoor(w, r, http.StatusAccepted, ctrl.coopService.StartCloseDoor)
+}
+
+// StopDoor stops a named door of the coop.
+func (ctrl *APIController) StopDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	ctrl.runDoor(w, r, http.StatusOK, ctrl.coopService.StopDoor)
+}
+
+// GetDoorHistory returns the history of a named door of the coop.
+func (ctrl *APIController) GetDoorHistory(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	d, err := ctrl.coopService.GetDoor(doorName(r))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	response, err := getHistory(func(input services.HistoryRequest) ([]journal.Event, int, error) {
+		return ctrl.coopService.GetDoorHistory(d.Name(), input)
+	}, &r.Request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// OpenDoorManually starts opening a named door of the coop from the index page.
+func (ctrl *MiscController) OpenDoorManually(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartOpenDoor(doorName(r), r.Username)
+	if err != nil {
+		logrus.WithError(err).Errorln("Error in manually opening the door")
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+
+	ctrl.Index(w, r)
+}
+
+// CloseDoorManually starts closing a named door of the coop from the index page.
+func (ctrl *MiscController) CloseDoorManually(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StartCloseDoor(doorName(r), r.Username)
+	if err != nil {
+		logrus.WithError(err).Errorln("Error in manually closing the door")
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+
+	ctrl.Index(w, r)
+}
+
+// StopDoorManually stops a named door of the coop from the index page.
+func (ctrl *MiscController) StopDoorManually(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	err := ctrl.coopService.StopDoor(doorName(r), r.Username)
+	if err != nil {
+		logrus.WithError(err).Errorln("Error in manually stopping the door")
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+
+	ctrl.Index(w, r)
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// runDoor runs the action on the named door of the request, and returns the door with the code.
+func (ctrl *APIController) runDoor(w http.ResponseWriter, r *auth.AuthenticatedRequest, code int, action func(name, by string) error) {
+	name := doorName(r)
+
+	err := action(name, r.Username)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	d, err := ctrl.coopService.GetDoor(name)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	writeJSON(w, code, newDoorAPIResponse(d))
+}
+
+// doorName returns the name of the door in the path of the request.
+func doorName(r *auth.AuthenticatedRequest) string {
+	return mux.Vars(&r.Request)["name"]
+}
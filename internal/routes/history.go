@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	auth "github.com/abbot/go-http-auth"
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/sirupsen/logrus"
+)
+
+// dateFormat is the format of the dates used to filter the history.
+const dateFormat = "2006-01-02"
+
+//------------------------------------------------------------------------------
+// Routes
+//------------------------------------------------------------------------------
+
+// History is the history page.
+func (ctrl *MiscController) History(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	response, err := getHistory(ctrl.coopService.GetHistory, &r.Request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response.NavigationResponse = ctrl.navigation()
+
+	// Note the call to ParseFS instead of Parse
+	t, err := template.ParseFS(TemplatesFS, "templates/history.html.tmpl")
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+
+	// Header
+	w.Header().Add("Content-Type", "text/html")
+	w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate, private")
+	w.Header().Del("Content-Security-Policy")
+
+	// Execute
+	t.Execute(w, response)
+}
+
+// GetCoopHistory returns the history as JSON.
+func (ctrl *MiscController) GetCoopHistory(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	response, err := getHistory(ctrl.coopService.GetHistory, &r.Request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate, private")
+	w.Header().Del("Content-Security-Policy")
+
+	w.Write(jsonData)
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// getHistory reads the filters and the page from the query string and returns
+// the matching page of the history listed by the function. The "to" date is inclusive.
+func getHistory(list func(services.HistoryRequest) ([]journal.Event, int, error), r *http.Request) (*HistoryResponse, error) {
+	q := r.URL.Query()
+	input := services.HistoryRequest{}
+
+	if v := q.Get("from"); v != "" {
+		from, err := time.ParseInLocation(dateFormat, v, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		input.From = from
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, err := time.ParseInLocation(dateFormat, v, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		input.To = to.AddDate(0, 0, 1)
+	}
+
+	input.Page, _ = strconv.Atoi(q.Get("page"))
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	input.PerPage, _ = strconv.Atoi(q.Get("per_page"))
+	if input.PerPage < 1 {
+		input.PerPage = services.DefaultPerPage
+	}
+
+	events, total, err := list(input)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &HistoryResponse{
+		Events:  events,
+		Total:   total,
+		Page:    input.Page,
+		PerPage: input.PerPage,
+		From:    q.Get("from"),
+		To:      q.Get("to"),
+	}
+	if input.Page > 1 {
+		response.Previous = input.Page - 1
+	}
+	if input.Page*input.PerPage < total {
+		response.Next = input.Page + 1
+	}
+
+	return response, nil
+}
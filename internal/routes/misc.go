@@ -25,19 +25,26 @@ import (
 // TemplatesFS ...
 var TemplatesFS embed.FS
 
-// MiscController is the controller of Misc.
+// MiscController is the controller of Misc, for one of the coops.
 type MiscController struct {
 	coopService services.CoopService
+	coops       *services.Registry
+	prefix      string
+	actions     string
 }
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// NewMiscController returns a new MiscController.
-func NewMiscController(coopService services.CoopService) *MiscController {
+// NewMiscController returns a new MiscController for the coop of the registry.
+// Its pages are served under the prefix, and its actions under the actions path.
+func NewMiscController(coopService services.CoopService, coops *services.Registry, prefix, actions string) *MiscController {
 	return &MiscController{
 		coopService: coopService,
+		coops:       coops,
+		prefix:      prefix,
+		actions:     actions,
 	}
 }
 
@@ -49,24 +56,30 @@ func NewMiscController(coopService services.CoopService) *MiscController {
 func (ctrl *MiscController) Index(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
+	latitude, longitude := coop.Location()
 
 	// Prepare the response
 	response := CoopResponse{
 		OpeningCondition: ConditionResponse{
-			Mode:  coop.OpeningCondition.Mode(),
-			Value: coop.OpeningCondition.Value(),
+			Mode:  coop.OpeningCondition().Mode(),
+			Value: coop.OpeningCondition().Value(),
 		},
 		ClosingCondition: ConditionResponse{
-			Mode:  coop.ClosingCondition.Mode(),
-			Value: coop.ClosingCondition.Value(),
+			Mode:  coop.ClosingCondition().Mode(),
+			Value: coop.ClosingCondition().Value(),
 		},
+		PartialCondition: newPartialConditionResponse(coop.PartialCondition()),
+		PartialPosition:  coop.PartialPosition(),
 		NextOpeningTime: coop.NextOpeningTime(),
 		NextClosingTime: coop.NextClosingTime(),
-		Latitude:        coop.Latitude,
-		Longitude:       coop.Longitude,
-		Status:          string(coop.Status),
-		IsAutomatic:     coop.IsAutomatic,
+		Latitude:        latitude,
+		Longitude:       longitude,
+		Status:          string(coop.Status()),
+		IsAutomatic:     coop.IsAutomatic(),
+		Position:        position(coop),
 		Cameras:         viper.GetStringMapString("cameras"),
+		Doors:           newDoorResponses(ctrl.coopService.GetDoors()),
+		NavigationResponse: ctrl.navigation(),
 	}
 
 	// Note the call to ParseFS instead of Parse
@@ -104,24 +117,29 @@ func (ctrl *MiscController) Configuration(w http.ResponseWriter, r *auth.Authent
 func (ctrl *MiscController) getConfiguration(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
+	latitude, longitude := coop.Location()
 
 	// Prepare the response
 	response := CoopResponse{
 		OpeningCondition: ConditionResponse{
-			Mode:  coop.OpeningCondition.Mode(),
-			Value: coop.OpeningCondition.Value(),
+			Mode:  coop.OpeningCondition().Mode(),
+			Value: coop.OpeningCondition().Value(),
 		},
 		ClosingCondition: ConditionResponse{
-			Mode:  coop.ClosingCondition.Mode(),
-			Value: coop.ClosingCondition.Value(),
+			Mode:  coop.ClosingCondition().Mode(),
+			Value: coop.ClosingCondition().Value(),
 		},
+		PartialCondition: newPartialConditionResponse(coop.PartialCondition()),
+		PartialPosition:  coop.PartialPosition(),
 		NextOpeningTime: coop.NextOpeningTime(),
 		NextClosingTime: coop.NextClosingTime(),
-		Latitude:        coop.Latitude,
-		Longitude:       coop.Longitude,
-		Status:          string(coop.Status),
-		IsAutomatic:     coop.IsAutomatic,
+		Latitude:        latitude,
+		Longitude:       longitude,
+		Status:          string(coop.Status()),
+		IsAutomatic:     coop.IsAutomatic(),
 		Cameras:         viper.GetStringMapString("cameras"),
+		Doors:           newDoorResponses(ctrl.coopService.GetDoors()),
+		NavigationResponse: ctrl.navigation(),
 	}
 
 	// Note the call to ParseFS instead of Parse
@@ -161,6 +179,8 @@ func (ctrl *MiscController) updateConfiguration(w http.ResponseWriter, r *auth.A
 		status = coop.Opened
 	case "closed":
 		status = coop.Closed
+	case "partial":
+		status = coop.Partial
 	case "unknown":
 		status = coop.Unknown
 	default:
@@ -173,6 +193,7 @@ func (ctrl *MiscController) updateConfiguration(w http.ResponseWriter, r *auth.A
 		Latitude:    latitude,
 		Longitude:   longitude,
 		IsAutomatic: isAutomatic,
+		By:          r.Username,
 		OpeningCondition: services.ConditionUpdateRequest{
 			Mode:  r.FormValue("opening_mode"),
 			Value: r.FormValue("opening_value"),
@@ -181,10 +202,18 @@ func (ctrl *MiscController) updateConfiguration(w http.ResponseWriter, r *auth.A
 			Mode:  r.FormValue("closing_mode"),
 			Value: r.FormValue("closing_value"),
 		},
+		PartialCondition: services.ConditionUpdateRequest{
+			Mode:  r.FormValue("partial_mode"),
+			Value: r.FormValue("partial_value"),
+		},
 	}
 
-	// Update the coop
-	err = ctrl.coopService.Update(update)
+	// Update the coop, or one of its named doors
+	if door := r.FormValue("door"); door != "" {
+		err = ctrl.coopService.UpdateDoor(door, update)
+	} else {
+		err = ctrl.coopService.Update(update)
+	}
 	if err != nil {
 		logrus.WithError(err).Errorln("error while updating the coop")
 		return
@@ -192,24 +221,29 @@ func (ctrl *MiscController) updateConfiguration(w http.ResponseWriter, r *auth.A
 
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
+	latitude, longitude = coop.Location()
 
 	// Prepare the response
 	response := CoopResponse{
 		OpeningCondition: ConditionResponse{
-			Mode:  coop.OpeningCondition.Mode(),
-			Value: coop.OpeningCondition.Value(),
+			Mode:  coop.OpeningCondition().Mode(),
+			Value: coop.OpeningCondition().Value(),
 		},
 		ClosingCondition: ConditionResponse{
-			Mode:  coop.ClosingCondition.Mode(),
-			Value: coop.ClosingCondition.Value(),
+			Mode:  coop.ClosingCondition().Mode(),
+			Value: coop.ClosingCondition().Value(),
 		},
+		PartialCondition: newPartialConditionResponse(coop.PartialCondition()),
+		PartialPosition:  coop.PartialPosition(),
 		NextOpeningTime: coop.NextOpeningTime(),
 		NextClosingTime: coop.NextClosingTime(),
-		Latitude:        coop.Latitude,
-		Longitude:       coop.Longitude,
-		Status:          string(coop.Status),
-		IsAutomatic:     coop.IsAutomatic,
+		Latitude:        latitude,
+		Longitude:       longitude,
+		Status:          string(coop.Status()),
+		IsAutomatic:     coop.IsAutomatic(),
 		Cameras:         viper.GetStringMapString("cameras"),
+		Doors:           newDoorResponses(ctrl.coopService.GetDoors()),
+		NavigationResponse: ctrl.navigation(),
 	}
 
 	// Note the call to ParseFS instead of Parse
@@ -231,14 +265,16 @@ func (ctrl *MiscController) CloseCoopDoorManually(w http.ResponseWriter, r *auth
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
 
-	if(coop.IsAutomatic) {
+	if(coop.IsAutomatic()) {
 		logrus.Errorln("Coop is in Automatic Mode")
+		http.Error(w, "Coop is in Automatic Mode", http.StatusConflict)
 		return
 	}
 
-	err := ctrl.coopService.Close()
+	err := ctrl.coopService.StartClose(r.Username)
 	if(err != nil) {
 		logrus.WithError(err).Errorln("Error in manually closing Coop Door")
+		http.Error(w, err.Error(), statusCode(err))
 		return
 	}
 
@@ -249,14 +285,36 @@ func (ctrl *MiscController) OpenCoopDoorManually(w http.ResponseWriter, r *auth.
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
 
-	if(coop.IsAutomatic) {
+	if(coop.IsAutomatic()) {
 		logrus.Errorln("Coop is in Automatic Mode")
+		http.Error(w, "Coop is in Automatic Mode", http.StatusConflict)
 		return
 	}
 
-	err := ctrl.coopService.Open()
+	err := ctrl.coopService.StartOpen(r.Username)
 	if(err != nil) {
 		logrus.WithError(err).Errorln("Error in manually opeing Coop Door")
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+
+	ctrl.Index(w, r)
+}
+
+func (ctrl *MiscController) PartialCoopDoorManually(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+	// Get the coop
+	coop := ctrl.coopService.GetCoop()
+
+	if(coop.IsAutomatic()) {
+		logrus.Errorln("Coop is in Automatic Mode")
+		http.Error(w, "Coop is in Automatic Mode", http.StatusConflict)
+		return
+	}
+
+	err := ctrl.coopService.StartPartial(r.Username)
+	if(err != nil) {
+		logrus.WithError(err).Errorln("Error in manually partially opening Coop Door")
+		http.Error(w, err.Error(), statusCode(err))
 		return
 	}
 
@@ -267,21 +325,45 @@ func (ctrl *MiscController) StopCoopDoorManually(w http.ResponseWriter, r *auth.
 	// Get the coop
 	coop := ctrl.coopService.GetCoop()
 
-	if(coop.IsAutomatic) {
+	if(coop.IsAutomatic()) {
 		logrus.Errorln("Coop is in Automatic Mode")
+		http.Error(w, "Coop is in Automatic Mode", http.StatusConflict)
 		return
 	}
 
-	//TDB: need to expose
-	err := ctrl.coopService.Stop()
+	err := ctrl.coopService.Stop(r.Username)
 	if(err != nil) {
 		logrus.WithError(err).Errorln("Error in manually stopping Coop Door")
+		http.Error(w, err.Error(), statusCode(err))
 		return
 	}
 
 	ctrl.Index(w, r)
 }
 
+// navigation returns the navigation of the pages of the coop.
+func (ctrl *MiscController) navigation() NavigationResponse {
+	nav := NavigationResponse{
+		Prefix:  ctrl.prefix,
+		Actions: ctrl.actions,
+		Name:    ctrl.coopService.Name(),
+	}
+	for _, c := range ctrl.coops.All() {
+		link := CoopLinkResponse{
+			ID:     c.ID(),
+			Name:   c.Name(),
+			Prefix: "/coops/" + c.ID(),
+			Active: c.ID() == ctrl.coopService.ID(),
+		}
+		if c == ctrl.coops.Default() {
+			link.Prefix = ""
+		}
+		nav.Coops = append(nav.Coops, link)
+	}
+
+	return nav
+}
+
 func (ctrl *MiscController) GetCoopTemperature(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
 	inTemp,inHumidity,outTemp,outHumidity,err := ctrl.coopService.GetTemp()
 	if err != nil {
@@ -1,6 +1,13 @@
 package routes
 
-import "time"
+import (
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+)
 
 // ConditionResponse is the response for a condition.
 type ConditionResponse struct {
@@ -12,6 +19,8 @@ type ConditionResponse struct {
 type CoopResponse struct {
 	OpeningCondition ConditionResponse
 	ClosingCondition ConditionResponse
+	PartialCondition ConditionResponse
+	PartialPosition  float64
 	Latitude         float64
 	Longitude        float64
 	Status           string
@@ -22,5 +31,238 @@ type CoopResponse struct {
 	OutsideHumidity	 float32
 	InsideTemp       float32
 	InsideHumidity	 float32
+	Position         *float64
 	Cameras          map[string]string
+	Doors            []DoorResponse
+	NavigationResponse
+}
+
+// NavigationResponse is the navigation between the pages of the coops.
+type NavigationResponse struct {
+	Prefix  string
+	Actions string
+	Name    string
+	Coops   []CoopLinkResponse
+}
+
+// CoopLinkResponse is the link to the pages of a coop.
+type CoopLinkResponse struct {
+	ID     string
+	Name   string
+	Prefix string
+	Active bool
+}
+
+// DoorResponse is the response for a named door of the coop.
+type DoorResponse struct {
+	Name             string
+	OpeningCondition ConditionResponse
+	ClosingCondition ConditionResponse
+	Status           string
+	IsAutomatic      bool
+	NextOpeningTime  time.Time
+	NextClosingTime  time.Time
+	Position         *float64
+}
+
+// HistoryResponse is the response for the history.
+type HistoryResponse struct {
+	Events   []journal.Event `json:"events"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PerPage  int             `json:"per_page"`
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	Previous int             `json:"-"`
+	Next     int             `json:"-"`
+
+	NavigationResponse `json:"-"`
+}
+
+// ConditionAPIResponse is the API response for a condition.
+type ConditionAPIResponse struct {
+	Mode     string    `json:"mode"`
+	Value    string    `json:"value"`
+	Time     time.Time `json:"time"`
+	NextTime time.Time `json:"next_time"`
+}
+
+// ConditionsAPIResponse is the API response for the conditions.
+type ConditionsAPIResponse struct {
+	Opening ConditionAPIResponse  `json:"opening"`
+	Closing ConditionAPIResponse  `json:"closing"`
+	Partial *ConditionAPIResponse `json:"partial,omitempty"`
+}
+
+// ScheduleAPIResponse is the API response for the schedule.
+type ScheduleAPIResponse struct {
+	NextOpeningTime time.Time `json:"next_opening_time"`
+	NextClosingTime time.Time `json:"next_closing_time"`
+}
+
+// CoopAPIResponse is the API response for the coop.
+type CoopAPIResponse struct {
+	Status           coop.Status           `json:"status"`
+	IsAutomatic      bool                  `json:"is_automatic"`
+	LastTransition   time.Time             `json:"last_transition"`
+	Latitude         float64               `json:"latitude"`
+	Longitude        float64               `json:"longitude"`
+	OpeningCondition ConditionAPIResponse  `json:"opening_condition"`
+	ClosingCondition ConditionAPIResponse  `json:"closing_condition"`
+	NextOpeningTime  time.Time             `json:"next_opening_time"`
+	NextClosingTime  time.Time             `json:"next_closing_time"`
+	Position         *float64              `json:"position,omitempty"`
+	PartialCondition *ConditionAPIResponse `json:"partial_condition,omitempty"`
+	PartialPosition  float64               `json:"partial_position,omitempty"`
+}
+
+// DoorAPIResponse is the API response for a named door of the coop.
+type DoorAPIResponse struct {
+	Name string `json:"name"`
+	CoopAPIResponse
+}
+
+// CoopsItemAPIResponse is the API response for a coop of the list of the coops.
+type CoopsItemAPIResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	CoopAPIResponse
+}
+
+// ConditionAPIRequest is the API request for a condition.
+type ConditionAPIRequest struct {
+	Mode  string `json:"mode"`
+	Value string `json:"value"`
+}
+
+// CoopUpdateAPIRequest is the API request to update the coop.
+type CoopUpdateAPIRequest struct {
+	Status           coop.Status         `json:"status"`
+	IsAutomatic      bool                `json:"is_automatic"`
+	OpeningCondition ConditionAPIRequest `json:"opening_condition"`
+	ClosingCondition ConditionAPIRequest `json:"closing_condition"`
+	PartialCondition ConditionAPIRequest `json:"partial_condition"`
+}
+
+// DoorUpdateAPIRequest is the API request to update a named door of the coop.
+type DoorUpdateAPIRequest struct {
+	Status           coop.Status         `json:"status"`
+	IsAutomatic      bool                `json:"is_automatic"`
+	OpeningCondition ConditionAPIRequest `json:"opening_condition"`
+	ClosingCondition ConditionAPIRequest `json:"closing_condition"`
+}
+
+// SensorsAPIResponse is the API response for the sensors.
+type SensorsAPIResponse struct {
+	InsideTemperature  float32 `json:"inside_temperature"`
+	InsideHumidity     float32 `json:"inside_humidity"`
+	OutsideTemperature float32 `json:"outside_temperature"`
+	OutsideHumidity    float32 `json:"outside_humidity"`
+}
+
+// ErrorAPIResponse is the API response for an error.
+type ErrorAPIResponse struct {
+	Error string `json:"error"`
+}
+
+// newCoopAPIResponse returns the API response for the coop.
+func newCoopAPIResponse(c services.Coop) CoopAPIResponse {
+	latitude, longitude := c.Location()
+	oc := c.OpeningCondition()
+	cc := c.ClosingCondition()
+
+	return CoopAPIResponse{
+		Status:         c.Status(),
+		IsAutomatic:    c.IsAutomatic(),
+		LastTransition: c.LastTransition(),
+		Latitude:       latitude,
+		Longitude:      longitude,
+		OpeningCondition: ConditionAPIResponse{
+			Mode:     oc.Mode(),
+			Value:    oc.Value(),
+			Time:     oc.OpeningTime(),
+			NextTime: oc.NextOpeningTime(),
+		},
+		ClosingCondition: ConditionAPIResponse{
+			Mode:     cc.Mode(),
+			Value:    cc.Value(),
+			Time:     cc.ClosingTime(),
+			NextTime: cc.NextClosingTime(),
+		},
+		NextOpeningTime:  oc.NextOpeningTime(),
+		NextClosingTime:  cc.NextClosingTime(),
+		Position:         position(c),
+		PartialCondition: newPartialConditionAPIResponse(c.PartialCondition()),
+		PartialPosition:  c.PartialPosition(),
+	}
+}
+
+// newDoorAPIResponse returns the API response for a named door of the coop.
+func newDoorAPIResponse(d services.Coop) DoorAPIResponse {
+	return DoorAPIResponse{
+		Name:            d.Name(),
+		CoopAPIResponse: newCoopAPIResponse(d),
+	}
+}
+
+// newDoorResponses returns the responses for the named doors of the coop.
+func newDoorResponses(doors []services.Coop) []DoorResponse {
+	responses := []DoorResponse{}
+	for _, d := range doors {
+		responses = append(responses, DoorResponse{
+			Name: d.Name(),
+			OpeningCondition: ConditionResponse{
+				Mode:  d.OpeningCondition().Mode(),
+				Value: d.OpeningCondition().Value(),
+			},
+			ClosingCondition: ConditionResponse{
+				Mode:  d.ClosingCondition().Mode(),
+				Value: d.ClosingCondition().Value(),
+			},
+			Status:          string(d.Status()),
+			IsAutomatic:     d.IsAutomatic(),
+			NextOpeningTime: d.NextOpeningTime(),
+			NextClosingTime: d.NextClosingTime(),
+			Position:        position(d),
+		})
+	}
+
+	return responses
+}
+
+// newPartialConditionAPIResponse returns the API response for the partial
+// condition, nil if the partial position is not scheduled.
+func newPartialConditionAPIResponse(pc conditions.Condition) *ConditionAPIResponse {
+	if pc == nil {
+		return nil
+	}
+
+	return &ConditionAPIResponse{
+		Mode:     pc.Mode(),
+		Value:    pc.Value(),
+		Time:     pc.ClosingTime(),
+		NextTime: pc.NextClosingTime(),
+	}
+}
+
+// newPartialConditionResponse returns the response for the partial condition, empty if it is not scheduled.
+func newPartialConditionResponse(pc conditions.Condition) ConditionResponse {
+	if pc == nil {
+		return ConditionResponse{}
+	}
+
+	return ConditionResponse{
+		Mode:  pc.Mode(),
+		Value: pc.Value(),
+	}
+}
+
+// position returns the position of the door, nil if it is not measured.
+func position(c services.Coop) *float64 {
+	p, err := c.Position()
+	if err != nil {
+		return nil
+	}
+
+	return &p
 }
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"time"
+
 	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/journal"
 )
 
 // ConditionUpdateRequest ...
@@ -14,8 +17,35 @@ type ConditionUpdateRequest struct {
 type CoopUpdateRequest struct {
 	OpeningCondition ConditionUpdateRequest
 	ClosingCondition ConditionUpdateRequest
+	PartialCondition ConditionUpdateRequest
 	Latitude         float64
 	Longitude        float64
 	Status           coop.Status
 	IsAutomatic      bool
+	By               string
+}
+
+// HistoryRequest ...
+type HistoryRequest struct {
+	From    time.Time
+	To      time.Time
+	Page    int
+	PerPage int
+}
+
+// Query returns the query of the journal for the page of the request.
+func (input HistoryRequest) Query() journal.Query {
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	if input.PerPage < 1 {
+		input.PerPage = DefaultPerPage
+	}
+
+	return journal.Query{
+		From:   input.From,
+		To:     input.To,
+		Offset: (input.Page - 1) * input.PerPage,
+		Limit:  input.PerPage,
+	}
 }
@@ -1,53 +1,78 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/fallais/gocoop/pkg/coop"
-	"github.com/fallais/gocoop/pkg/coop/conditions"
-	"github.com/fallais/gocoop/pkg/coop/conditions/sunbased"
-	"github.com/fallais/gocoop/pkg/coop/conditions/timebased"
+	"github.com/fallais/gocoop/pkg/coop/journal"
 	"github.com/fallais/gocoop/pkg/temperature"
 	"github.com/fallais/gocoop/pkg/fan"
-	"github.com/spf13/viper"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
 )
 
 //------------------------------------------------------------------------------
 // Constants
 //------------------------------------------------------------------------------
 
-// ErrCoopOpened ...
-const ErrCoopOpened = "The coop is already opened"
+// DefaultPerPage is the default number of events per page of history.
+const DefaultPerPage = 50
 
-// ErrCoopOpening ...
-const ErrCoopOpening = "The coop is opening"
+// ErrIncorrectCondition is raised when a condition cannot be created.
+var ErrIncorrectCondition = errors.New("condition is incorrect")
 
-// ErrCoopClosed ...
-const ErrCoopClosed = "The coop is already closed"
+// ErrDoorNotFound is raised when the coop has no door with the name.
+var ErrDoorNotFound = errors.New("door does not exist")
 
-// ErrCoopClosing ...
-const ErrCoopClosing = "The coop is closing"
+var (
+	temperatureGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gocoop_temperature_fahrenheit",
+		Help: "Last reading of the temperature.",
+	}, []string{"location", "coop"})
+	humidityGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gocoop_humidity_percent",
+		Help: "Last reading of the humidity.",
+	}, []string{"location", "coop"})
+)
 
 //------------------------------------------------------------------------------
 // Structure
 //------------------------------------------------------------------------------
 
 type coopService struct {
+	id string
+	name string
 	coop *coop.Coop
+	doors []*coop.Coop
 	InTempSensor temperature.Temperature
 	OutTempSensor temperature.Temperature
+	fan *fan.Fan
+	fanTempLimit int
+
+	// The fan is driven by the temperature unless it has been turned on or off manually
+	fanMu sync.Mutex
+	isFanManual bool
 }
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// NewCoopService returns a new CoopService.
-func NewCoopService(coop *coop.Coop, indoorTemp temperature.Temperature, outsideTemp temperature.Temperature) CoopService {
+// NewCoopService returns a new CoopService for the coop with the ID and the name. The doors are
+// the named doors of the coop, next to its main door. The fan is turned on above the temperature limit.
+func NewCoopService(id, name string, coop *coop.Coop, doors []*coop.Coop, indoorTemp temperature.Temperature, outsideTemp temperature.Temperature, f *fan.Fan, fanTempLimit int) CoopService {
 	return &coopService {
+		id: id,
+		name: name,
 		coop: coop,
+		doors: doors,
 		InTempSensor: indoorTemp,
 		OutTempSensor: outsideTemp,
+		fan: f,
+		fanTempLimit: fanTempLimit,
 	}
 }
 
@@ -55,113 +80,293 @@ func NewCoopService(coop *coop.Coop, indoorTemp temperature.Temperature, outside
 // Services
 //------------------------------------------------------------------------------
 
+// ID returns the ID of the coop, used in the URLs.
+func (service *coopService) ID() string {
+	return service.id
+}
+
+// Name returns the name of the coop.
+func (service *coopService) Name() string {
+	return service.name
+}
+
 // Get returns the the coop.
-func (service *coopService) GetCoop() *coop.Coop {
+func (service *coopService) GetCoop() Coop {
 	return service.coop
 }
 
+// GetDoors returns the named doors of the coop.
+func (service *coopService) GetDoors() []Coop {
+	doors := []Coop{}
+	for _, d := range service.doors {
+		doors = append(doors, d)
+	}
+
+	return doors
+}
+
+// GetDoor returns the named door of the coop.
+func (service *coopService) GetDoor(name string) (Coop, error) {
+	d, err := service.door(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// door returns the named door of the coop.
+func (service *coopService) door(name string) (*coop.Coop, error) {
+	for _, d := range service.doors {
+		if d.Name() == name {
+			return d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrDoorNotFound, name)
+}
+
 // Update updates the coop.
 func (service *coopService) Update(input CoopUpdateRequest) error {
-	// Create the opening condition
-	var openingCondition conditions.Condition
-	switch input.OpeningCondition.Mode {
-	case "time_based":
-		oc, err := timebased.NewTimeBasedCondition(input.OpeningCondition.Value)
-		if err != nil {
-			return fmt.Errorf("Error while creating the opening condition: %s", err)
-		}
+	return update(service.coop, input)
+}
 
-		openingCondition = oc
-	case "sun_based":
-		oc, err := sunbased.NewSunBasedCondition(input.OpeningCondition.Value, viper.GetFloat64("coop.latitude"), viper.GetFloat64("coop.longitude"))
-		if err != nil {
-			return fmt.Errorf("Error while creating the opening condition")
-		}
+// UpdateDoor updates the named door of the coop.
+func (service *coopService) UpdateDoor(name string, input CoopUpdateRequest) error {
+	d, err := service.door(name)
+	if err != nil {
+		return err
+	}
+
+	return update(d, input)
+}
 
-		openingCondition = oc
-	default:
-		return fmt.Errorf("opening mode is incorrect: %s", input.OpeningCondition.Mode)
+// update updates the status, the automatic mode and the conditions of the door.
+func update(c *coop.Coop, input CoopUpdateRequest) error {
+	// Create the opening condition
+	openingCondition, err := coop.NewCondition(input.OpeningCondition.Mode, input.OpeningCondition.Value, c.Latitude, c.Longitude, c.Light)
+	if err != nil {
+		return fmt.Errorf("%w: error while creating the opening condition: %s", ErrIncorrectCondition, err)
 	}
 
 	// Create the closing condition
-	var closingCondition conditions.Condition
-	switch input.ClosingCondition.Mode {
-	case "time_based":
-		cc, err := timebased.NewTimeBasedCondition(input.ClosingCondition.Value)
-		if err != nil {
-			return fmt.Errorf("Error when creating the closing condition")
-		}
-
-		closingCondition = cc
-	case "sun_based":
-		cc, err := sunbased.NewSunBasedCondition(input.ClosingCondition.Value, viper.GetFloat64("coop.latitude"), viper.GetFloat64("coop.longitude"))
-		if err != nil {
-			return fmt.Errorf("Error when creating the closing condition")
-		}
+	closingCondition, err := coop.NewCondition(input.ClosingCondition.Mode, input.ClosingCondition.Value, c.Latitude, c.Longitude, c.Light)
+	if err != nil {
+		return fmt.Errorf("%w: error while creating the closing condition: %s", ErrIncorrectCondition, err)
+	}
 
-		closingCondition = cc
-	default:
-		return fmt.Errorf("closing mode is incorrect: %s", input.ClosingCondition.Mode)
+	// Create the partial condition, it is disabled without mode
+	partialCondition, err := coop.NewPartialCondition(input.PartialCondition.Mode, input.PartialCondition.Value, c.Latitude, c.Longitude, c.Light)
+	if err != nil {
+		return fmt.Errorf("%w: error while creating the partial condition: %s", ErrIncorrectCondition, err)
 	}
 
 	// Update the coop
-	service.coop.Status = input.Status
-	service.coop.IsAutomatic = input.IsAutomatic
-	service.coop.OpeningCondition = openingCondition
-	service.coop.ClosingCondition = closingCondition
+	return c.Update(input.By, input.Status, input.IsAutomatic, openingCondition, closingCondition, partialCondition)
+}
+
+// Open the Coop
+func (service *coopService) Open(by string) error {
+	return service.coop.Open(by)
+}
+
+// Close the Coop
+func (service *coopService) Close(by string) error {
+	return service.coop.Close(by)
+}
+
+// StartOpen starts opening the Coop and returns as soon as the door is moving.
+func (service *coopService) StartOpen(by string) error {
+	done, err := service.coop.OpenAsync(by)
+	if err != nil {
+		return err
+	}
+
+	go logResult("opening", done)
 
 	return nil
 }
 
-// Open the Coop
-func (service *coopService) Open() error {
-	// Get the status of the coop
-	status := service.coop.Status
+// StartOpenDoor starts opening the named door of the Coop and returns as soon as the door is moving.
+func (service *coopService) StartOpenDoor(name, by string) error {
+	d, err := service.door(name)
+	if err != nil {
+		return err
+	}
 
-	// Check if coop is opened
-	if status == coop.Opened {
-		return fmt.Errorf(ErrCoopOpened)
+	done, err := d.OpenAsync(by)
+	if err != nil {
+		return err
 	}
 
-	// Check if coop is opening
-	if status == coop.Opening {
-		return fmt.Errorf(ErrCoopOpening)
+	go logDoorResult(name, "opening", done)
+
+	return nil
+}
+
+// StartClose starts closing the Coop and returns as soon as the door is moving.
+func (service *coopService) StartClose(by string) error {
+	done, err := service.coop.CloseAsync(by)
+	if err != nil {
+		return err
 	}
 
-	return service.coop.Open()
+	go logResult("closing", done)
+
+	return nil
 }
 
-// Close the Coop
-func (service *coopService) Close() error {
-	// Get the status of the coop
-	status := service.coop.Status
+// StartCloseDoor starts closing the named door of the Coop and returns as soon as the door is moving.
+func (service *coopService) StartCloseDoor(name, by string) error {
+	d, err := service.door(name)
+	if err != nil {
+		return err
+	}
 
-	// Check if coop is closed
-	if status == coop.Closed {
-		return fmt.Errorf(ErrCoopClosed)
+	done, err := d.CloseAsync(by)
+	if err != nil {
+		return err
 	}
 
-	// Check if coop is closing
-	if status == coop.Closing {
-		return fmt.Errorf(ErrCoopClosing)
+	go logDoorResult(name, "closing", done)
+
+	return nil
+}
+
+// StartPartial starts moving the door of the Coop to the partial position and returns as soon as the door is moving.
+func (service *coopService) StartPartial(by string) error {
+	done, err := service.coop.OpenPartiallyAsync(by)
+	if err != nil {
+		return err
 	}
 
-	return service.coop.Close()
+	go logResult("partially opening", done)
+
+	return nil
 }
 
-// Close the Coop
-func (service *coopService) Stop() error {
-	return service.coop.Stop()
+// StartCalibrate starts calibrating the door of the Coop and returns as soon as the door is moving.
+func (service *coopService) StartCalibrate(by string) error {
+	done, err := service.coop.CalibrateAsync(by)
+	if err != nil {
+		return err
+	}
+
+	go logResult("calibrating", done)
+
+	return nil
+}
+
+// logResult logs the result of a movement running in the background.
+func logResult(action string, done <-chan error) {
+	err := <-done
+	if err != nil {
+		logrus.WithError(err).Errorf("Error while %s the coop", action)
+		return
+	}
+
+	logrus.Infof("The coop has finished %s", action)
+}
+
+// logDoorResult logs the result of a movement of a named door running in the background.
+func logDoorResult(name, action string, done <-chan error) {
+	entry := logrus.WithFields(logrus.Fields{
+		"door": name,
+	})
+
+	err := <-done
+	if err != nil {
+		entry.WithError(err).Errorf("Error while %s the door", action)
+		return
+	}
+
+	entry.Infof("The door has finished %s", action)
+}
+
+// Stop the Coop
+func (service *coopService) Stop(by string) error {
+	return service.coop.Stop(by)
+}
+
+// StopDoor stops the named door of the Coop.
+func (service *coopService) StopDoor(name, by string) error {
+	d, err := service.door(name)
+	if err != nil {
+		return err
+	}
+
+	return d.Stop(by)
+}
+
+// GetDoorHistory returns a page of the events of the named door of the coop.
+func (service *coopService) GetDoorHistory(name string, input HistoryRequest) ([]journal.Event, int, error) {
+	d, err := service.door(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return history(d, input)
+}
+
+// GetHistory returns a page of the events of the coop.
+func (service *coopService) GetHistory(input HistoryRequest) ([]journal.Event, int, error) {
+	return history(service.coop, input)
+}
+
+// history returns a page of the events of the door.
+func history(c *coop.Coop, input HistoryRequest) ([]journal.Event, int, error) {
+	return c.Events(input.Query())
+}
+
+// SetFan turns the fan on or off manually, the temperature does not drive it
+// anymore until ResetFan is called.
+func (service *coopService) SetFan(on bool) {
+	service.fanMu.Lock()
+	defer service.fanMu.Unlock()
+
+	service.isFanManual = true
+	setFan(service.fan, on)
 }
 
-func coopTempFanHandler(tempInsideCoop float32) {
-	coopfan := fan.NewFan(uint8(viper.GetInt("fan.pin")))
-	insideTempLimit := viper.GetInt("fan.temp_limit")
+// ResetFan gives the control of the fan back to the temperature, it is driven
+// again at the next reading of the sensors.
+func (service *coopService) ResetFan() {
+	service.fanMu.Lock()
+	defer service.fanMu.Unlock()
 
-	if tempInsideCoop > float32(insideTempLimit) {
-		coopfan.On()
+	service.isFanManual = false
+}
+
+// IsFanOn returns true if the fan is turned on.
+func (service *coopService) IsFanOn() bool {
+	return service.fan.IsOn()
+}
+
+// IsFanManual returns true if the fan has been turned on or off manually.
+func (service *coopService) IsFanManual() bool {
+	service.fanMu.Lock()
+	defer service.fanMu.Unlock()
+
+	return service.isFanManual
+}
+
+// coopTempFanHandler drives the fan with the temperature, unless it is manual.
+func (service *coopService) coopTempFanHandler(tempInsideCoop float32) {
+	service.fanMu.Lock()
+	defer service.fanMu.Unlock()
+
+	if service.isFanManual {
+		return
+	}
+	setFan(service.fan, tempInsideCoop > float32(service.fanTempLimit))
+}
+
+// setFan turns the fan on or off.
+func setFan(f *fan.Fan, on bool) {
+	if on {
+		f.On()
 	} else {
-		coopfan.Off()
+		f.Off()
 	}
 }
 
@@ -171,12 +376,17 @@ func (service *coopService) GetTemp() (float32, float32, float32, float32, error
         return -1,-1,-1,-1,fmt.Errorf("Error reading temperature: %s\n", err.Error())
     }
 
-	go coopTempFanHandler(InsideTemp)
+	service.coopTempFanHandler(InsideTemp)
 
 	OutsideTemp, OutsideHumidity, err := service.OutTempSensor.ReadTemp()
     if err != nil {
         return -1,-1,-1,-1,fmt.Errorf("Error reading temperature: %s\n", err.Error())
     }
 
+	temperatureGauge.WithLabelValues("inside", service.id).Set(float64(InsideTemp))
+	humidityGauge.WithLabelValues("inside", service.id).Set(float64(InsideHumidity))
+	temperatureGauge.WithLabelValues("outside", service.id).Set(float64(OutsideTemp))
+	humidityGauge.WithLabelValues("outside", service.id).Set(float64(OutsideHumidity))
+
 	return InsideTemp, InsideHumidity, OutsideTemp, OutsideHumidity, nil
 }
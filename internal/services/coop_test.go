@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/fallais/gocoop/pkg/fan"
+	"github.com/fallais/gocoop/pkg/gpio"
+)
+
+type fakeSensor float32
+
+func (s fakeSensor) ReadTemp() (float32, float32, error) { return float32(s), 50, nil }
+
+func TestFanOverride(t *testing.T) {
+	chip := gpio.NewFake()
+	service := NewCoopService("default", "The coop", nil, nil, fakeSensor(90), fakeSensor(60), fan.NewFan(chip.Pin(4)), 80)
+
+	// The temperature drives the fan
+	_, _, _, _, err := service.GetTemp()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if !service.IsFanOn() || service.IsFanManual() {
+		t.Fatal("fan should be turned on by the temperature")
+	}
+
+	// The manual command survives the readings
+	service.SetFan(false)
+	service.GetTemp()
+	if service.IsFanOn() || !service.IsFanManual() {
+		t.Fatal("fan should stay turned off manually")
+	}
+
+	// Until the temperature drives it again
+	service.ResetFan()
+	service.GetTemp()
+	if !service.IsFanOn() || service.IsFanManual() {
+		t.Fatal("fan should be turned on by the temperature again")
+	}
+}
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"time"
+
 	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+	"github.com/fallais/gocoop/pkg/coop/journal"
 )
 
 //------------------------------------------------------------------------------
@@ -10,10 +14,46 @@ import (
 
 // CoopService is the interface
 type CoopService interface {
-	GetCoop() *coop.Coop
+	ID() string
+	Name() string
+	GetCoop() Coop
+	GetDoors() []Coop
+	GetDoor(name string) (Coop, error)
 	Update(CoopUpdateRequest) error
-	Open() error
-	Close() error
-	Stop() error
+	UpdateDoor(name string, input CoopUpdateRequest) error
+	Open(by string) error
+	Close(by string) error
+	StartOpen(by string) error
+	StartClose(by string) error
+	StartPartial(by string) error
+	StartCalibrate(by string) error
+	Stop(by string) error
+	StartOpenDoor(name, by string) error
+	StartCloseDoor(name, by string) error
+	StopDoor(name, by string) error
 	GetTemp() (float32, float32, float32, float32, error)
+	SetFan(on bool)
+	ResetFan()
+	IsFanOn() bool
+	IsFanManual() bool
+	GetHistory(HistoryRequest) ([]journal.Event, int, error)
+	GetDoorHistory(name string, input HistoryRequest) ([]journal.Event, int, error)
+}
+
+// Coop is the view of a door of a coop, the main door included. It is
+// implemented by *coop.Coop.
+type Coop interface {
+	Name() string
+	Location() (float64, float64)
+	Status() coop.Status
+	IsAutomatic() bool
+	LastTransition() time.Time
+	OpeningCondition() conditions.Condition
+	ClosingCondition() conditions.Condition
+	PartialCondition() conditions.Condition
+	PartialPosition() float64
+	Position() (float64, error)
+	NextOpeningTime() time.Time
+	NextClosingTime() time.Time
+	Subscribe() <-chan journal.Event
 }
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultCoopID is the ID of the coop configured at the root of the configuration.
+const DefaultCoopID = "default"
+
+// ErrCoopNotFound is raised when the registry has no coop with the ID.
+var ErrCoopNotFound = errors.New("coop does not exist")
+
+// ErrCoopOffline is raised when the coop is managed by an agent that does not report anymore.
+var ErrCoopOffline = errors.New("coop is offline")
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Registry is the registry of the coops managed by the instance.
+type Registry struct {
+	coops []CoopService
+	byID  map[string]CoopService
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewRegistry returns a new Registry of the coops, in order. The first coop is
+// the default coop. The IDs of the coops must be unique.
+func NewRegistry(coops ...CoopService) (*Registry, error) {
+	if len(coops) == 0 {
+		return nil, errors.New("the registry needs at least one coop")
+	}
+
+	r := &Registry{
+		byID: make(map[string]CoopService),
+	}
+	for _, c := range coops {
+		if _, ok := r.byID[c.ID()]; ok {
+			return nil, fmt.Errorf("coop is declared twice: %s", c.ID())
+		}
+		r.coops = append(r.coops, c)
+		r.byID[c.ID()] = c
+	}
+
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Default returns the default coop.
+func (r *Registry) Default() CoopService {
+	return r.coops[0]
+}
+
+// Get returns the coop with the ID.
+func (r *Registry) Get(id string) (CoopService, error) {
+	c, ok := r.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCoopNotFound, id)
+	}
+
+	return c, nil
+}
+
+// All returns the coops, the default coop first.
+func (r *Registry) All() []CoopService {
+	return r.coops
+}
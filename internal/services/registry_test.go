@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeService struct {
+	CoopService
+	id string
+}
+
+func (s *fakeService) ID() string { return s.id }
+
+func TestRegistry(t *testing.T) {
+	home := &fakeService{id: "default"}
+	barn := &fakeService{id: "barn"}
+
+	r, err := NewRegistry(home, barn)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	if r.Default() != home {
+		t.Fatalf("the first coop should be the default coop")
+	}
+	if c, err := r.Get("barn"); err != nil || c != barn {
+		t.Fatalf("should get the barn: %v", err)
+	}
+	if _, err := r.Get("shed"); !errors.Is(err, ErrCoopNotFound) {
+		t.Fatalf("should be not found, it is %v", err)
+	}
+	if len(r.All()) != 2 {
+		t.Fatalf("should be 2 coops, it is %d", len(r.All()))
+	}
+
+	// The IDs are unique
+	_, err = NewRegistry(home, &fakeService{id: "default"})
+	if err == nil {
+		t.Fatalf("should error with a duplicate coop")
+	}
+
+	// The registry is never empty
+	_, err = NewRegistry()
+	if err == nil {
+		t.Fatalf("should error without coop")
+	}
+}
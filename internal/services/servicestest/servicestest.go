@@ -0,0 +1,189 @@
+package servicestest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+	"github.com/fallais/gocoop/pkg/door"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Door is a door that reaches its limit switches at once. It cannot sense its
+// position and it has no encoder.
+type Door struct{}
+
+// Service is a fake coop service around a real coop. The commands are recorded
+// instead of being run, and the methods that are not faked panic.
+type Service struct {
+	services.CoopService
+
+	id    string
+	name  string
+	coop  *coop.Coop
+	doors []services.Coop
+
+	mu       sync.Mutex
+	commands []string
+	fan      bool
+	manual   bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewCoop returns a new coop in manual mode with a Door, opened at 08h00 and
+// closed at 20h00. It panics if the coop cannot be created.
+func NewCoop() *coop.Coop {
+	c, err := coop.New(43.388352, 1.277914, nil, Door{}, "time_based", "08h00", "time_based", "20h00", "", "", nil, nil, nil, false, false)
+	if err != nil {
+		panic(fmt.Sprintf("servicestest: error while creating the coop: %s", err))
+	}
+
+	return c
+}
+
+// NewService returns a new Service of the coop with the ID and the name, and
+// the named doors.
+func NewService(id, name string, c *coop.Coop, doors ...services.Coop) *Service {
+	return &Service{
+		id:    id,
+		name:  name,
+		coop:  c,
+		doors: doors,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Open returns at once at the limit switch.
+func (Door) Open() (door.Movement, error) {
+	return door.Movement{EndReason: door.LimitSwitch}, nil
+}
+
+// Close returns at once at the limit switch.
+func (Door) Close() (door.Movement, error) {
+	return door.Movement{EndReason: door.LimitSwitch}, nil
+}
+
+// MoveTo returns at once when the duration has elapsed.
+func (Door) MoveTo(from, to float64) (door.Movement, error) {
+	return door.Movement{EndReason: door.DurationElapsed}, nil
+}
+
+// Stop does nothing.
+func (Door) Stop() error {
+	return nil
+}
+
+// Sense returns door.ErrNoSensor.
+func (Door) Sense() (door.State, error) {
+	return door.StateUnknown, door.ErrNoSensor
+}
+
+// Position returns door.ErrNoEncoder.
+func (Door) Position() (float64, error) {
+	return 0, door.ErrNoEncoder
+}
+
+// Calibrate returns door.ErrNoEncoder.
+func (Door) Calibrate() error {
+	return door.ErrNoEncoder
+}
+
+// ID returns the ID of the coop.
+func (s *Service) ID() string {
+	return s.id
+}
+
+// Name returns the name of the coop.
+func (s *Service) Name() string {
+	return s.name
+}
+
+// GetCoop returns the coop.
+func (s *Service) GetCoop() services.Coop {
+	return s.coop
+}
+
+// GetDoors returns the named doors.
+func (s *Service) GetDoors() []services.Coop {
+	return s.doors
+}
+
+// StartOpen records the command.
+func (s *Service) StartOpen(by string) error {
+	return s.command("open by " + by)
+}
+
+// StartClose records the command.
+func (s *Service) StartClose(by string) error {
+	return s.command("close by " + by)
+}
+
+// Stop records the command.
+func (s *Service) Stop(by string) error {
+	return s.command("stop by " + by)
+}
+
+// SetFan turns the fan on or off manually.
+func (s *Service) SetFan(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fan, s.manual = on, true
+}
+
+// ResetFan gives the fan back to the temperature.
+func (s *Service) ResetFan() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.manual = false
+}
+
+// IsFanOn returns true if the fan is turned on.
+func (s *Service) IsFanOn() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fan
+}
+
+// IsFanManual returns true if the fan has been turned on or off manually.
+func (s *Service) IsFanManual() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.manual
+}
+
+// GetTemp returns fixed readings of the sensors.
+func (s *Service) GetTemp() (float32, float32, float32, float32, error) {
+	return 68, 50, 59, 70, nil
+}
+
+// Commands returns the recorded commands, in order.
+func (s *Service) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+// command records the command.
+func (s *Service) command(cmd string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, cmd)
+
+	return nil
+}
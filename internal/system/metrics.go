@@ -0,0 +1,87 @@
+package system
+
+import (
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/pkg/coop"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reporter is a coop managed by an agent of the hub.
+type reporter interface {
+	LastSeen() time.Time
+}
+
+var statuses = []coop.Status{coop.Opened, coop.Closed, coop.Opening, coop.Closing, coop.Partial, coop.Unknown}
+
+var (
+	statusDesc        = prometheus.NewDesc("gocoop_door_status", "Status of the door, 1 for the current status.", []string{"status", "coop"}, nil)
+	automaticDesc     = prometheus.NewDesc("gocoop_automatic_mode", "1 if the automatic mode is enabled.", []string{"coop"}, nil)
+	nextOpeningDesc   = prometheus.NewDesc("gocoop_next_opening_seconds", "Seconds until the next opening.", []string{"coop"}, nil)
+	nextClosingDesc   = prometheus.NewDesc("gocoop_next_closing_seconds", "Seconds until the next closing.", []string{"coop"}, nil)
+	fanDesc           = prometheus.NewDesc("gocoop_fan_on", "1 if the fan is turned on.", []string{"coop"}, nil)
+	doorStatusDesc    = prometheus.NewDesc("gocoop_named_door_status", "Status of the named doors, 1 for the current status.", []string{"door", "status", "coop"}, nil)
+	doorAutomaticDesc = prometheus.NewDesc("gocoop_named_door_automatic_mode", "1 if the automatic mode of the named door is enabled.", []string{"door", "coop"}, nil)
+	lastReportDesc    = prometheus.NewDesc("gocoop_agent_last_report_timestamp_seconds", "Time of the last report of the agent of the coop.", []string{"coop"}, nil)
+)
+
+// coopsCollector collects the metrics of the coops at every scrape.
+type coopsCollector struct {
+	coops *services.Registry
+}
+
+// SetupMetrics registers the metrics of the coops, they are read at every collection.
+func SetupMetrics(coops *services.Registry) {
+	prometheus.MustRegister(&coopsCollector{coops: coops})
+}
+
+// Describe sends the descriptions of the metrics of the coops.
+func (c *coopsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{statusDesc, automaticDesc, nextOpeningDesc, nextClosingDesc, fanDesc, doorStatusDesc, doorAutomaticDesc, lastReportDesc} {
+		ch <- desc
+	}
+}
+
+// Collect sends the metrics of the coops.
+func (c *coopsCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+	}
+
+	for _, coopService := range c.coops.All() {
+		id := coopService.ID()
+		cp := coopService.GetCoop()
+
+		current := cp.Status()
+		for _, s := range statuses {
+			gauge(statusDesc, boolValue(s == current), string(s), id)
+		}
+		gauge(automaticDesc, boolValue(cp.IsAutomatic()), id)
+		gauge(nextOpeningDesc, time.Until(cp.NextOpeningTime()).Seconds(), id)
+		gauge(nextClosingDesc, time.Until(cp.NextClosingTime()).Seconds(), id)
+		gauge(fanDesc, boolValue(coopService.IsFanOn()), id)
+
+		for _, d := range coopService.GetDoors() {
+			current := d.Status()
+			for _, s := range statuses {
+				gauge(doorStatusDesc, boolValue(s == current), d.Name(), string(s), id)
+			}
+			gauge(doorAutomaticDesc, boolValue(d.IsAutomatic()), d.Name(), id)
+		}
+
+		if r, ok := coopService.(reporter); ok && !r.LastSeen().IsZero() {
+			gauge(lastReportDesc, float64(r.LastSeen().Unix()), id)
+		}
+	}
+}
+
+// boolValue returns 1 if b is true, 0 otherwise.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
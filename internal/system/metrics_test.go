@@ -0,0 +1,106 @@
+package system
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/internal/services"
+	"github.com/fallais/gocoop/internal/services/servicestest"
+	"github.com/fallais/gocoop/pkg/coop"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// agentService is a coop managed by an agent.
+type agentService struct {
+	*servicestest.Service
+	lastSeen time.Time
+}
+
+func (s *agentService) LastSeen() time.Time { return s.lastSeen }
+
+func TestCoopsCollector(t *testing.T) {
+	// The default coop is closed in automatic mode, with a named door
+	home := servicestest.NewCoop()
+	err := home.Update("test", coop.Closed, true, home.OpeningCondition(), home.ClosingCondition(), nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	gate := servicestest.NewCoop()
+	gate.SetName("run_gate")
+	err = gate.Update("test", coop.Opened, false, gate.OpeningCondition(), gate.ClosingCondition(), nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	homeService := servicestest.NewService("default", "Home", home, gate)
+	homeService.SetFan(true)
+
+	// The barn is managed by an agent
+	barnService := &agentService{
+		Service:  servicestest.NewService("barn", "The barn", servicestest.NewCoop()),
+		lastSeen: time.Unix(1700000000, 0),
+	}
+
+	coops, err := services.NewRegistry(homeService, barnService)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(&coopsCollector{coops: coops})
+
+	expected := `
+# HELP gocoop_agent_last_report_timestamp_seconds Time of the last report of the agent of the coop.
+# TYPE gocoop_agent_last_report_timestamp_seconds gauge
+gocoop_agent_last_report_timestamp_seconds{coop="barn"} 1.7e+09
+# HELP gocoop_automatic_mode 1 if the automatic mode is enabled.
+# TYPE gocoop_automatic_mode gauge
+gocoop_automatic_mode{coop="barn"} 0
+gocoop_automatic_mode{coop="default"} 1
+# HELP gocoop_door_status Status of the door, 1 for the current status.
+# TYPE gocoop_door_status gauge
+gocoop_door_status{coop="barn",status="closed"} 0
+gocoop_door_status{coop="barn",status="closing"} 0
+gocoop_door_status{coop="barn",status="opened"} 0
+gocoop_door_status{coop="barn",status="opening"} 0
+gocoop_door_status{coop="barn",status="partial"} 0
+gocoop_door_status{coop="barn",status="unknown"} 1
+gocoop_door_status{coop="default",status="closed"} 1
+gocoop_door_status{coop="default",status="closing"} 0
+gocoop_door_status{coop="default",status="opened"} 0
+gocoop_door_status{coop="default",status="opening"} 0
+gocoop_door_status{coop="default",status="partial"} 0
+gocoop_door_status{coop="default",status="unknown"} 0
+# HELP gocoop_fan_on 1 if the fan is turned on.
+# TYPE gocoop_fan_on gauge
+gocoop_fan_on{coop="barn"} 0
+gocoop_fan_on{coop="default"} 1
+# HELP gocoop_named_door_automatic_mode 1 if the automatic mode of the named door is enabled.
+# TYPE gocoop_named_door_automatic_mode gauge
+gocoop_named_door_automatic_mode{coop="default",door="run_gate"} 0
+# HELP gocoop_named_door_status Status of the named doors, 1 for the current status.
+# TYPE gocoop_named_door_status gauge
+gocoop_named_door_status{coop="default",door="run_gate",status="closed"} 0
+gocoop_named_door_status{coop="default",door="run_gate",status="closing"} 0
+gocoop_named_door_status{coop="default",door="run_gate",status="opened"} 1
+gocoop_named_door_status{coop="default",door="run_gate",status="opening"} 0
+gocoop_named_door_status{coop="default",door="run_gate",status="partial"} 0
+gocoop_named_door_status{coop="default",door="run_gate",status="unknown"} 0
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"gocoop_agent_last_report_timestamp_seconds", "gocoop_automatic_mode", "gocoop_door_status", "gocoop_fan_on",
+		"gocoop_named_door_automatic_mode", "gocoop_named_door_status")
+	if err != nil {
+		t.Fatalf("metrics are incorrect: %s", err)
+	}
+
+	// The schedule is given for every coop
+	count, err := testutil.GatherAndCount(registry, "gocoop_next_opening_seconds", "gocoop_next_closing_seconds")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if count != 4 {
+		t.Fatalf("should be 4 metrics of the schedule, it is %d", count)
+	}
+}
@@ -0,0 +1,24 @@
+package adc
+
+import (
+	"errors"
+)
+
+// ErrChannel is raised when the channel does not exist on the converter.
+var ErrChannel = errors.New("channel does not exist")
+
+//------------------------------------------------------------------------------
+// Interfaces
+//------------------------------------------------------------------------------
+
+// ADC is an analog to digital converter.
+type ADC interface {
+	// Read returns the voltage on the channel, in volts.
+	Read(channel int) (float64, error)
+}
+
+// Conn is a connection to a device on a bus. On a SPI bus, w is written
+// while r is read. On an I2C bus, w is written then r is read.
+type Conn interface {
+	Tx(w, r []byte) error
+}
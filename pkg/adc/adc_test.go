@@ -0,0 +1,71 @@
+package adc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// fakeConn records the writes and answers with the replies, in order.
+type fakeConn struct {
+	writes  [][]byte
+	replies [][]byte
+}
+
+func (c *fakeConn) Tx(w, r []byte) error {
+	c.writes = append(c.writes, append([]byte(nil), w...))
+	if len(r) > 0 {
+		copy(r, c.replies[0])
+		c.replies = c.replies[1:]
+	}
+	return nil
+}
+
+func TestMCP3008(t *testing.T) {
+	conn := &fakeConn{replies: [][]byte{{0x00, 0x02, 0x00}}}
+	a := NewMCP3008(conn, 3.3)
+
+	volts, err := a.Read(5)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if !bytes.Equal(conn.writes[0], []byte{0x01, 0xD0, 0x00}) {
+		t.Fatalf("should select the channel 5 in single-ended mode, it wrote %x", conn.writes[0])
+	}
+	if math.Abs(volts-512*3.3/1023) > 1e-9 {
+		t.Fatalf("should be half of the reference, it is %v", volts)
+	}
+
+	_, err = a.Read(8)
+	if err != ErrChannel {
+		t.Fatalf("should not read the channel 8: %v", err)
+	}
+}
+
+func TestADS1115(t *testing.T) {
+	conn := &fakeConn{replies: [][]byte{
+		{0x43, 0x83}, // conversion in progress
+		{0xC3, 0x83}, // conversion done
+		{0x40, 0x00}, // half of the full-scale range
+	}}
+	a, err := NewADS1115(conn, 4.096)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	volts, err := a.Read(1)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if !bytes.Equal(conn.writes[0], []byte{0x01, 0xD3, 0x83}) {
+		t.Fatalf("should start a single-shot conversion of AIN1, it wrote %x", conn.writes[0])
+	}
+	if volts != 2.048 {
+		t.Fatalf("should be 2.048V, it is %v", volts)
+	}
+
+	_, err = NewADS1115(conn, 5)
+	if err != ErrFullScale {
+		t.Fatalf("should not support the range: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package adctest
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoReply is raised when the connection has no reply left.
+var ErrNoReply = errors.New("no reply left")
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Converter is an in-memory converter. It returns the voltage that is set on
+// every channel.
+type Converter struct {
+	mu    sync.Mutex
+	volts float64
+}
+
+// Conn is an in-memory connection. It records the writes and answers the
+// reads with the replies, in order.
+type Conn struct {
+	mu      sync.Mutex
+	writes  [][]byte
+	replies [][]byte
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewConverter returns a new Converter with the voltage.
+func NewConverter(volts float64) *Converter {
+	return &Converter{
+		volts: volts,
+	}
+}
+
+// NewConn returns a new Conn answering with the replies.
+func NewConn(replies ...[]byte) *Conn {
+	return &Conn{
+		replies: replies,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read returns the voltage, whatever the channel.
+func (f *Converter) Read(channel int) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.volts, nil
+}
+
+// Set sets the voltage.
+func (f *Converter) Set(volts float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.volts = volts
+}
+
+// Tx records w if it is not empty, and fills r with the next reply.
+func (c *Conn) Tx(w, r []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(w) > 0 {
+		c.writes = append(c.writes, append([]byte(nil), w...))
+	}
+	if len(r) > 0 {
+		if len(c.replies) == 0 {
+			return ErrNoReply
+		}
+		copy(r, c.replies[0])
+		c.replies = c.replies[1:]
+	}
+
+	return nil
+}
+
+// Writes returns the recorded writes, in order.
+func (c *Conn) Writes() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([][]byte(nil), c.writes...)
+}
@@ -0,0 +1,106 @@
+package adc
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Registers of the ADS1115.
+const (
+	ads1115Conversion = 0x00
+	ads1115Config     = 0x01
+)
+
+// ads1115Ranges are the full-scale ranges of the programmable gain amplifier, in volts.
+var ads1115Ranges = []float64{6.144, 4.096, 2.048, 1.024, 0.512, 0.256}
+
+// ads1115Timeout is the maximum duration of a conversion at 128 samples per second.
+const ads1115Timeout = 50 * time.Millisecond
+
+// ErrFullScale is raised when the full-scale range is not supported by the ADS1115.
+var ErrFullScale = errors.New("full-scale range is not supported")
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// ads1115 is a 16-bit converter with 4 channels, on an I2C bus.
+type ads1115 struct {
+	conn      Conn
+	pga       int
+	fullScale float64
+
+	mu sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewADS1115 returns a new ADS1115 with the full-scale range, in volts: 6.144,
+// 4.096, 2.048, 1.024, 0.512 or 0.256.
+func NewADS1115(conn Conn, fullScale float64) (ADC, error) {
+	for pga, r := range ads1115Ranges {
+		if r == fullScale {
+			return &ads1115{
+				conn:      conn,
+				pga:       pga,
+				fullScale: fullScale,
+			}, nil
+		}
+	}
+
+	return nil, ErrFullScale
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read starts a single-shot conversion of the channel against the ground and returns the voltage.
+func (a *ads1115) Read(channel int) (float64, error) {
+	if channel < 0 || channel > 3 {
+		return 0, ErrChannel
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Start a single-shot conversion at 128 samples per second, comparator disabled
+	config := uint16(0x8000) | uint16(0x4+channel)<<12 | uint16(a.pga)<<9 | 0x0100 | 0x0080 | 0x0003
+	err := a.conn.Tx([]byte{ads1115Config, byte(config >> 8), byte(config)}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while starting the conversion of the ADS1115: %s", err)
+	}
+
+	// Wait for the end of the conversion
+	deadline := time.Now().Add(ads1115Timeout)
+	r := make([]byte, 2)
+	for {
+		err = a.conn.Tx([]byte{ads1115Config}, r)
+		if err != nil {
+			return 0, fmt.Errorf("error while reading the ADS1115: %s", err)
+		}
+		if r[0]&0x80 != 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("conversion of the ADS1115 has timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	err = a.conn.Tx([]byte{ads1115Conversion}, r)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the ADS1115: %s", err)
+	}
+	raw := int16(uint16(r[0])<<8 | uint16(r[1]))
+
+	return float64(raw) * a.fullScale / 32768, nil
+}
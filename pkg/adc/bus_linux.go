@@ -0,0 +1,139 @@
+//go:build linux
+// +build linux
+
+package adc
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Requests of the spidev and i2c-dev drivers, see linux/spi/spidev.h and linux/i2c-dev.h.
+const (
+	spiIocMessage1 = 1<<30 | uintptr(unsafe.Sizeof(spiTransfer{}))<<16 | 'k'<<8
+	i2cSlave       = 0x0703
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// spiTransfer is struct spi_ioc_transfer.
+type spiTransfer struct {
+	TxBuf       uint64
+	RxBuf       uint64
+	Len         uint32
+	SpeedHz     uint32
+	DelayUsecs  uint16
+	BitsPerWord uint8
+	CsChange    uint8
+	TxNbits     uint8
+	RxNbits     uint8
+	WordDelay   uint8
+	Pad         uint8
+}
+
+// spiConn is a device on a SPI bus, such as /dev/spidev0.0.
+type spiConn struct {
+	f       *os.File
+	speedHz uint32
+}
+
+// i2cConn is a device on an I2C bus, such as /dev/i2c-1.
+type i2cConn struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// OpenSPI opens the SPI device with the clock speed in Hertz.
+func OpenSPI(path string, speedHz uint32) (Conn, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the SPI device: %s", err)
+	}
+
+	return &spiConn{
+		f:       f,
+		speedHz: speedHz,
+	}, nil
+}
+
+// OpenI2C opens the I2C bus and selects the device at the address.
+func OpenI2C(path string, address int) (Conn, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the I2C bus: %s", err)
+	}
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), i2cSlave, uintptr(address))
+	if errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("error while selecting the I2C device 0x%02x: %s", address, errno)
+	}
+
+	return &i2cConn{
+		f: f,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Tx writes w while reading r, both must have the same length.
+func (c *spiConn) Tx(w, r []byte) error {
+	if len(w) != len(r) || len(w) == 0 {
+		return fmt.Errorf("SPI buffers must have the same length")
+	}
+
+	tr := spiTransfer{
+		TxBuf:       uint64(uintptr(unsafe.Pointer(&w[0]))),
+		RxBuf:       uint64(uintptr(unsafe.Pointer(&r[0]))),
+		Len:         uint32(len(w)),
+		SpeedHz:     c.speedHz,
+		BitsPerWord: 8,
+	}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, c.f.Fd(), spiIocMessage1, uintptr(unsafe.Pointer(&tr)))
+	runtime.KeepAlive(w)
+	runtime.KeepAlive(r)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// Tx writes w then reads r.
+func (c *i2cConn) Tx(w, r []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(w) > 0 {
+		_, err := c.f.Write(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(r) > 0 {
+		_, err := c.f.Read(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
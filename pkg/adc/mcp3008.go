@@ -0,0 +1,56 @@
+package adc
+
+import (
+	"fmt"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// mcp3008 is a 10-bit converter with 8 channels, on a SPI bus.
+type mcp3008 struct {
+	conn Conn
+	vref float64
+
+	mu sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewMCP3008 returns a new MCP3008 with the reference voltage on its VREF pin.
+func NewMCP3008(conn Conn, vref float64) ADC {
+	return &mcp3008{
+		conn: conn,
+		vref: vref,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read returns the voltage on the single-ended channel.
+func (a *mcp3008) Read(channel int) (float64, error) {
+	if channel < 0 || channel > 7 {
+		return 0, ErrChannel
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Start bit, single-ended mode and channel, then 10 bits are clocked out
+	w := []byte{0x01, byte(0x08|channel) << 4, 0x00}
+	r := make([]byte, len(w))
+	err := a.conn.Tx(w, r)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the MCP3008: %s", err)
+	}
+
+	raw := int(r[1]&0x03)<<8 | int(r[2])
+
+	return float64(raw) * a.vref / 1023, nil
+}
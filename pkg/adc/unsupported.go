@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package adc
+
+import "errors"
+
+// ErrUnsupported is raised when the buses are not supported by the operating system.
+var ErrUnsupported = errors.New("SPI and I2C are only supported on Linux")
+
+// OpenSPI is not supported outside of Linux.
+func OpenSPI(path string, speedHz uint32) (Conn, error) {
+	return nil, ErrUnsupported
+}
+
+// OpenI2C is not supported outside of Linux.
+func OpenI2C(path string, address int) (Conn, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,46 @@
+package coop
+
+import (
+	"fmt"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+	"github.com/fallais/gocoop/pkg/coop/conditions/calendar"
+	"github.com/fallais/gocoop/pkg/coop/conditions/composite"
+	"github.com/fallais/gocoop/pkg/coop/conditions/lux"
+	"github.com/fallais/gocoop/pkg/coop/conditions/solar"
+	"github.com/fallais/gocoop/pkg/coop/conditions/sunbased"
+	"github.com/fallais/gocoop/pkg/coop/conditions/timebased"
+)
+
+// NewCondition returns a new Condition with given mode and value. The light
+// monitor is the one of the coop, it is nil if the coop has no light sensor.
+func NewCondition(mode, value string, latitude, longitude float64, light *lux.Monitor) (conditions.Condition, error) {
+	switch mode {
+	case "time_based":
+		return timebased.NewTimeBasedCondition(value)
+	case "sun_based":
+		return sunbased.NewSunBasedCondition(value, latitude, longitude)
+	case "calendar":
+		return calendar.NewCalendarCondition(value)
+	case "lux":
+		return lux.NewLuxCondition(value, light)
+	case solar.CivilTwilight, solar.NauticalTwilight, solar.AstronomicalTwilight, solar.Elevation:
+		return solar.NewSolarCondition(mode, value, latitude, longitude)
+	case composite.Max, composite.Min, composite.Clamp:
+		return composite.NewCompositeCondition(mode, value, func(mode, value string) (conditions.Condition, error) {
+			return NewCondition(mode, value, latitude, longitude, light)
+		})
+	default:
+		return nil, fmt.Errorf("mode does not exist: %s", mode)
+	}
+}
+
+// NewPartialCondition returns a new Condition with given mode and value for
+// the partial position, or nil if the mode is empty since it is optional.
+func NewPartialCondition(mode, value string, latitude, longitude float64, light *lux.Monitor) (conditions.Condition, error) {
+	if mode == "" {
+		return nil, nil
+	}
+
+	return NewCondition(mode, value, latitude, longitude, light)
+}
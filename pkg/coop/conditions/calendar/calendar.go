@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// A calendar condition is a fixed time that depends on the day. It is a list
+// of rules separated by semicolons or new lines, such as
+// "08h00; sat,sun 09h00; nov-feb 09h30; 12-25 10h00". A rule is a time
+// preceded by selectors of the days, which must all match. The rule without
+// selector is the default one, and the last matching rule wins.
+type calendarCondition struct {
+	rules []rule
+}
+
+// rule is a time for the days matching all its selectors.
+type rule struct {
+	selectors []selector
+	hours     int
+	minutes   int
+	text      string
+}
+
+// selector matches days.
+type selector func(day time.Time) bool
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewCalendarCondition returns a new Condition.
+func NewCalendarCondition(value string) (conditions.Condition, error) {
+	c := &calendarCondition{}
+	hasDefault := false
+
+	for _, text := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(strings.ToLower(text))
+		if len(fields) == 0 {
+			continue
+		}
+
+		// The time is the last field
+		h, m, err := parseTime(fields[len(fields)-1])
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the rule %q: %s", strings.TrimSpace(text), err)
+		}
+		r := rule{
+			hours:   h,
+			minutes: m,
+			text:    strings.Join(fields, " "),
+		}
+
+		// The selectors are before
+		for _, field := range fields[:len(fields)-1] {
+			s, err := parseSelector(field)
+			if err != nil {
+				return nil, fmt.Errorf("error while parsing the rule %q: %s", strings.TrimSpace(text), err)
+			}
+			r.selectors = append(r.selectors, s)
+		}
+		if len(r.selectors) == 0 {
+			hasDefault = true
+		}
+
+		c.rules = append(c.rules, r)
+	}
+
+	if !hasDefault {
+		return nil, fmt.Errorf("calendar has no default time: %s", value)
+	}
+
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// OpeningTime returns the time of today.
+func (c *calendarCondition) OpeningTime() time.Time {
+	return c.at(time.Now())
+}
+
+// ClosingTime returns the time of today.
+func (c *calendarCondition) ClosingTime() time.Time {
+	return c.at(time.Now())
+}
+
+// NextOpeningTime returns the time of today, or of tomorrow if it is passed.
+func (c *calendarCondition) NextOpeningTime() time.Time {
+	return c.next(time.Now())
+}
+
+// NextClosingTime returns the time of today, or of tomorrow if it is passed.
+func (c *calendarCondition) NextClosingTime() time.Time {
+	return c.next(time.Now())
+}
+
+// Mode returns the mode of the condition.
+func (c *calendarCondition) Mode() string {
+	return "calendar"
+}
+
+// Value returns the rules of the condition.
+func (c *calendarCondition) Value() string {
+	var texts []string
+	for _, r := range c.rules {
+		texts = append(texts, r.text)
+	}
+
+	return strings.Join(texts, "; ")
+}
+
+// at returns the time of the day, given by the last matching rule.
+func (c *calendarCondition) at(day time.Time) time.Time {
+	var current rule
+	for _, r := range c.rules {
+		if r.matches(day) {
+			current = r
+		}
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), current.hours, current.minutes, 0, 0, time.Local)
+}
+
+// next returns the time of the day, or of the next day if it is passed.
+func (c *calendarCondition) next(now time.Time) time.Time {
+	t := c.at(now)
+	if now.After(t) {
+		return c.at(now.AddDate(0, 0, 1))
+	}
+
+	return t
+}
+
+// matches returns true if all the selectors of the rule match the day.
+func (r rule) matches(day time.Time) bool {
+	for _, s := range r.selectors {
+		if !s(day) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarCondition(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"sat 09h00",
+		"08h00; sat 9h00",
+		"08h00; funday 09h00",
+		"08h00; mon-feb 09h00",
+		"08h00; 02-30 09h00",
+		"08h00; 12-20..13-05 09h00",
+		"08h00; 2024-02-30 09h00",
+	} {
+		_, err := NewCalendarCondition(value)
+		if err == nil {
+			t.Fatalf("should error for %q", value)
+		}
+	}
+
+	c, err := NewCalendarCondition(" 08h00 ;\nSat,Sun  09h00;")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Mode() != "calendar" {
+		t.Fatalf("should be calendar, it is %s", c.Mode())
+	}
+	if c.Value() != "08h00; sat,sun 09h00" {
+		t.Fatalf("value is incorrect: %s", c.Value())
+	}
+}
+
+func TestAt(t *testing.T) {
+	c, err := NewCalendarCondition("07h30; sat,sun 08h30; nov-feb 09h00; nov-feb sat-sun 09h30; 12-24..12-26 10h00; 2025-06-02 06h00")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	cc := c.(*calendarCondition)
+
+	tests := []struct {
+		day      time.Time
+		expected string
+	}{
+		{time.Date(2025, 6, 4, 0, 0, 0, 0, time.Local), "07h30"},   // Wednesday
+		{time.Date(2025, 6, 7, 0, 0, 0, 0, time.Local), "08h30"},   // Saturday
+		{time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local), "09h00"},  // Wednesday in winter
+		{time.Date(2025, 11, 1, 0, 0, 0, 0, time.Local), "09h30"},  // Saturday in winter
+		{time.Date(2025, 12, 25, 0, 0, 0, 0, time.Local), "10h00"}, // Christmas
+		{time.Date(2025, 12, 27, 0, 0, 0, 0, time.Local), "09h30"}, // Saturday after Christmas
+		{time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local), "06h00"},   // Date
+		{time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local), "07h30"},   // Not the date, another year
+	}
+	for _, test := range tests {
+		got := cc.at(test.day).Format("15h04")
+		if got != test.expected {
+			t.Fatalf("time of %s should be %s, it is %s", test.day.Format("Mon 2006-01-02"), test.expected, got)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	c, err := NewCalendarCondition("07h30; sat,sun 08h30")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	cc := c.(*calendarCondition)
+
+	// Friday before the time
+	friday := time.Date(2025, 6, 6, 6, 0, 0, 0, time.Local)
+	if next := cc.next(friday); next != time.Date(2025, 6, 6, 7, 30, 0, 0, time.Local) {
+		t.Fatalf("next time is incorrect: %s", next)
+	}
+
+	// Friday after the time, it is the time of Saturday
+	friday = time.Date(2025, 6, 6, 12, 0, 0, 0, time.Local)
+	if next := cc.next(friday); next != time.Date(2025, 6, 7, 8, 30, 0, 0, time.Local) {
+		t.Fatalf("next time is incorrect: %s", next)
+	}
+
+	if c.NextOpeningTime().Before(time.Now()) {
+		t.Fatalf("next opening time should be in the future: %s", c.NextOpeningTime())
+	}
+}
@@ -0,0 +1,167 @@
+package calendar
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timePattern = regexp.MustCompile(`^(\d{2})h(\d{2})$`)
+	dayPattern  = regexp.MustCompile(`^(\d{2})-(\d{2})$`)
+	datePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+
+	weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+	months   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+)
+
+// parseTime returns the hours and minutes with given string.
+func parseTime(t string) (int, int, error) {
+	if !timePattern.MatchString(t) {
+		return 0, 0, fmt.Errorf("time format is incorrect: %s", t)
+	}
+	hoursAndMinutes := timePattern.FindStringSubmatch(t)
+
+	hours, _ := strconv.Atoi(hoursAndMinutes[1])
+	if hours > 23 {
+		return 0, 0, fmt.Errorf("incorrect hours: %d", hours)
+	}
+	minutes, _ := strconv.Atoi(hoursAndMinutes[2])
+	if minutes > 59 {
+		return 0, 0, fmt.Errorf("incorrect minutes: %d", minutes)
+	}
+
+	return hours, minutes, nil
+}
+
+// parseSelector returns the selector of a comma separated list of weekdays,
+// months, days of the year (12-25), dates (2024-12-25) or ranges of them
+// (mon-fri, nov-feb, 12-20..01-05). It matches if any of them matches.
+func parseSelector(s string) (selector, error) {
+	var items []selector
+	for _, item := range strings.Split(s, ",") {
+		sel, err := parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, sel)
+	}
+
+	return func(day time.Time) bool {
+		for _, sel := range items {
+			if sel(day) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parseItem returns the selector of a weekday, a month, a day of the year, a
+// date or a range of them.
+func parseItem(item string) (selector, error) {
+	// Range of days of the year
+	if parts := strings.Split(item, ".."); len(parts) == 2 {
+		from, err := parseDay(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseDay(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return func(day time.Time) bool {
+			return between(int(day.Month())*100+day.Day(), from, to)
+		}, nil
+	}
+
+	// Date
+	if datePattern.MatchString(item) {
+		date, err := time.ParseInLocation("2006-01-02", item, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("date is incorrect: %s", item)
+		}
+		return func(day time.Time) bool {
+			return day.Year() == date.Year() && day.YearDay() == date.YearDay()
+		}, nil
+	}
+
+	// Day of the year
+	if dayPattern.MatchString(item) {
+		d, err := parseDay(item)
+		if err != nil {
+			return nil, err
+		}
+		return func(day time.Time) bool {
+			return int(day.Month())*100+day.Day() == d
+		}, nil
+	}
+
+	// Weekdays and months, or ranges of them
+	bounds := strings.Split(item, "-")
+	if len(bounds) > 2 {
+		return nil, fmt.Errorf("selector is incorrect: %s", item)
+	}
+	if from, ok := index(weekdays, bounds[0]); ok {
+		to, ok := index(weekdays, bounds[len(bounds)-1])
+		if !ok {
+			return nil, fmt.Errorf("weekday is incorrect: %s", bounds[len(bounds)-1])
+		}
+		return func(day time.Time) bool {
+			// Monday is the first day of the week
+			return between((int(day.Weekday())+6)%7, from, to)
+		}, nil
+	}
+	if from, ok := index(months, bounds[0]); ok {
+		to, ok := index(months, bounds[len(bounds)-1])
+		if !ok {
+			return nil, fmt.Errorf("month is incorrect: %s", bounds[len(bounds)-1])
+		}
+		return func(day time.Time) bool {
+			return between(int(day.Month())-1, from, to)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("selector is incorrect: %s", item)
+}
+
+// parseDay returns the day of the year with given string MM-DD, as MMDD.
+func parseDay(s string) (int, error) {
+	if !dayPattern.MatchString(s) {
+		return 0, fmt.Errorf("day format is incorrect: %s", s)
+	}
+	monthAndDay := dayPattern.FindStringSubmatch(s)
+	month, _ := strconv.Atoi(monthAndDay[1])
+	day, _ := strconv.Atoi(monthAndDay[2])
+
+	// A leap year accepts the 29th of February
+	date := time.Date(2024, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || int(date.Month()) != month || date.Day() != day {
+		return 0, fmt.Errorf("day is incorrect: %s", s)
+	}
+
+	return month*100 + day, nil
+}
+
+// between returns true if v is between from and to included, the range wraps
+// around if from is after to.
+func between(v, from, to int) bool {
+	if from <= to {
+		return v >= from && v <= to
+	}
+
+	return v >= from || v <= to
+}
+
+// index returns the index of s in the list.
+func index(list []string, s string) (int, bool) {
+	for i, item := range list {
+		if item == s {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
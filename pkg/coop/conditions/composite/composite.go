@@ -0,0 +1,249 @@
+package composite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+const (
+	// Max is the latest of the times of the children.
+	Max = "max"
+
+	// Min is the earliest of the times of the children.
+	Min = "min"
+
+	// Clamp is the time of the first child, but never before the time of the
+	// second child and never after the time of the third child.
+	Clamp = "clamp"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// A composite condition combines the times of its children. Its value is the
+// list of the children such as "sun_based(30m), time_based(07h00)".
+type compositeCondition struct {
+	mode     string
+	children []conditions.Condition
+}
+
+// Child is the mode and the value of a child condition.
+type Child struct {
+	Mode  string
+	Value string
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewCompositeCondition returns a new Condition with the mode and the value,
+// the children are created with newCondition.
+func NewCompositeCondition(mode, value string, newCondition func(mode, value string) (conditions.Condition, error)) (conditions.Condition, error) {
+	children, err := Split(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case Max, Min:
+		if len(children) < 2 {
+			return nil, fmt.Errorf("%s needs at least 2 conditions: %s", mode, value)
+		}
+	case Clamp:
+		if len(children) != 3 {
+			return nil, fmt.Errorf("clamp needs 3 conditions, the time and its bounds: %s", value)
+		}
+	default:
+		return nil, fmt.Errorf("mode does not exist: %s", mode)
+	}
+
+	c := &compositeCondition{
+		mode: mode,
+	}
+	for _, child := range children {
+		condition, err := newCondition(child.Mode, child.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating the condition %s(%s): %s", child.Mode, child.Value, err)
+		}
+		c.children = append(c.children, condition)
+	}
+
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// OpeningTime returns the combined opening time of today.
+func (c *compositeCondition) OpeningTime() time.Time {
+	return c.combine(conditions.Condition.OpeningTime)
+}
+
+// ClosingTime returns the combined closing time of today.
+func (c *compositeCondition) ClosingTime() time.Time {
+	return c.combine(conditions.Condition.ClosingTime)
+}
+
+// NextOpeningTime returns the combined opening time of today, or of tomorrow if it is passed.
+func (c *compositeCondition) NextOpeningTime() time.Time {
+	return c.next(time.Now(), conditions.Condition.OpeningTime, conditions.Condition.NextOpeningTime)
+}
+
+// NextClosingTime returns the combined closing time of today, or of tomorrow if it is passed.
+func (c *compositeCondition) NextClosingTime() time.Time {
+	return c.next(time.Now(), conditions.Condition.ClosingTime, conditions.Condition.NextClosingTime)
+}
+
+// Mode returns the mode of the condition.
+func (c *compositeCondition) Mode() string {
+	return c.mode
+}
+
+// Value returns the children of the condition.
+func (c *compositeCondition) Value() string {
+	var children []Child
+	for _, child := range c.children {
+		children = append(children, Child{Mode: child.Mode(), Value: child.Value()})
+	}
+
+	return Join(children)
+}
+
+// combine returns the combination of the times of the children.
+func (c *compositeCondition) combine(at func(conditions.Condition) time.Time) time.Time {
+	var times []time.Time
+	for _, child := range c.children {
+		times = append(times, at(child))
+	}
+
+	return c.combineTimes(times)
+}
+
+// combineTimes returns the combination of the times, in the order of the children.
+func (c *compositeCondition) combineTimes(times []time.Time) time.Time {
+	switch c.mode {
+	case Clamp:
+		t := times[0]
+		if t.Before(times[1]) {
+			t = times[1]
+		}
+		if t.After(times[2]) {
+			t = times[2]
+		}
+		return t
+	case Min:
+		t := times[0]
+		for _, other := range times[1:] {
+			if other.Before(t) {
+				t = other
+			}
+		}
+		return t
+	default:
+		t := times[0]
+		for _, other := range times[1:] {
+			if other.After(t) {
+				t = other
+			}
+		}
+		return t
+	}
+}
+
+// next returns the combined time of today, or the one of tomorrow if it is
+// passed. The time of tomorrow of a child is its next time, or its time of
+// today one day later if its next time is still today.
+func (c *compositeCondition) next(now time.Time, today, next func(conditions.Condition) time.Time) time.Time {
+	t := c.combine(today)
+	if !now.After(t) {
+		return t
+	}
+
+	var times []time.Time
+	for _, child := range c.children {
+		n := next(child)
+		if sameDay(n, now) {
+			n = today(child).AddDate(0, 0, 1)
+		}
+		times = append(times, n)
+	}
+
+	return c.combineTimes(times)
+}
+
+// Split returns the children of the value of a composite condition. The
+// values of the children can contain commas and parentheses.
+func Split(value string) ([]Child, error) {
+	var children []Child
+	depth := 0
+	start := 0
+	for i, r := range value + "," {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("parentheses are incorrect: %s", value)
+			}
+		case r == ',' && depth == 0:
+			child, err := parseChild(value[start:i])
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+			start = i + 1
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("parentheses are incorrect: %s", value)
+	}
+
+	return children, nil
+}
+
+// Join returns the value of a composite condition with the children.
+func Join(children []Child) string {
+	var texts []string
+	for _, child := range children {
+		texts = append(texts, fmt.Sprintf("%s(%s)", child.Mode, child.Value))
+	}
+
+	return strings.Join(texts, ", ")
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// parseChild returns the child with given string mode(value).
+func parseChild(s string) (Child, error) {
+	s = strings.TrimSpace(s)
+	open := strings.Index(s, "(")
+	if open <= 0 || !strings.HasSuffix(s, ")") {
+		return Child{}, fmt.Errorf("condition format is incorrect, it must be mode(value): %s", s)
+	}
+
+	return Child{
+		Mode:  strings.TrimSpace(s[:open]),
+		Value: strings.TrimSpace(s[open+1 : len(s)-1]),
+	}, nil
+}
+
+// sameDay returns true if the times are on the same day, in the location of b.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.In(b.Location()).Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,145 @@
+package composite
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+)
+
+// fixedCondition is a condition at a fixed time, whose next time is the same
+// time of the next day.
+type fixedCondition struct {
+	mode  string
+	value string
+	at    time.Time
+	now   time.Time
+}
+
+func (c fixedCondition) OpeningTime() time.Time { return c.at }
+func (c fixedCondition) ClosingTime() time.Time { return c.at }
+func (c fixedCondition) NextOpeningTime() time.Time {
+	if c.now.After(c.at) {
+		return c.at.AddDate(0, 0, 1)
+	}
+	return c.at
+}
+func (c fixedCondition) NextClosingTime() time.Time { return c.NextOpeningTime() }
+func (c fixedCondition) Mode() string               { return c.mode }
+func (c fixedCondition) Value() string              { return c.value }
+
+var now = time.Date(2025, 6, 2, 12, 0, 0, 0, time.Local)
+
+// newFixed returns a condition at the given time of the day of now, its value is HHhMM.
+func newFixed(mode, value string) (conditions.Condition, error) {
+	t, err := time.ParseInLocation("15h04", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	return fixedCondition{
+		mode:  mode,
+		value: value,
+		at:    time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local),
+		now:   now,
+	}, nil
+}
+
+func TestSplit(t *testing.T) {
+	children, err := Split(" sun_based(30m), calendar(08h00; sat,sun 09h00) ,max(time_based(07h00), time_based(08h00))")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	expected := []Child{
+		{Mode: "sun_based", Value: "30m"},
+		{Mode: "calendar", Value: "08h00; sat,sun 09h00"},
+		{Mode: "max", Value: "time_based(07h00), time_based(08h00)"},
+	}
+	if fmt.Sprint(children) != fmt.Sprint(expected) {
+		t.Fatalf("children are incorrect: %v", children)
+	}
+	if Join(children) != "sun_based(30m), calendar(08h00; sat,sun 09h00), max(time_based(07h00), time_based(08h00))" {
+		t.Fatalf("value is incorrect: %s", Join(children))
+	}
+
+	for _, value := range []string{"sun_based(30m", "sun_based 30m", "(30m)", "sun_based(30m))"} {
+		_, err := Split(value)
+		if err == nil {
+			t.Fatalf("should error for %q", value)
+		}
+	}
+}
+
+func TestCompositeCondition(t *testing.T) {
+	for _, test := range []struct {
+		mode  string
+		value string
+	}{
+		{"max", "time_based(07h00)"},
+		{"clamp", "time_based(07h00), time_based(08h00)"},
+		{"average", "time_based(07h00), time_based(08h00)"},
+		{"min", "time_based(07h00), time_based(25h00)"},
+	} {
+		_, err := NewCompositeCondition(test.mode, test.value, newFixed)
+		if err == nil {
+			t.Fatalf("should error for %s(%s)", test.mode, test.value)
+		}
+	}
+
+	for _, test := range []struct {
+		mode     string
+		value    string
+		expected string
+	}{
+		// Open at sunrise+30m but never before 07h00
+		{"max", "sun_based(06h20), time_based(07h00)", "07h00"},
+		{"max", "sun_based(07h40), time_based(07h00)", "07h40"},
+		// Close at sunset+20m but no later than 21h30
+		{"min", "sun_based(21h50), time_based(21h30)", "21h30"},
+		{"min", "sun_based(20h10), time_based(21h30)", "20h10"},
+		// Between 18h00 and 21h30
+		{"clamp", "sun_based(17h20), time_based(18h00), time_based(21h30)", "18h00"},
+		{"clamp", "sun_based(19h40), time_based(18h00), time_based(21h30)", "19h40"},
+		{"clamp", "sun_based(22h10), time_based(18h00), time_based(21h30)", "21h30"},
+	} {
+		c, err := NewCompositeCondition(test.mode, test.value, newFixed)
+		if err != nil {
+			t.Fatalf("should not error: %s", err)
+		}
+		if c.Mode() != test.mode || c.Value() != test.value {
+			t.Fatalf("mode and value are incorrect: %s(%s)", c.Mode(), c.Value())
+		}
+		if got := c.OpeningTime().Format("15h04"); got != test.expected {
+			t.Fatalf("time of %s(%s) should be %s, it is %s", test.mode, test.value, test.expected, got)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// At noon, the sun based child is still today, the time based one is tomorrow
+	c, err := NewCompositeCondition("min", "sun_based(21h50), time_based(11h30)", newFixed)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	cc := c.(*compositeCondition)
+
+	next := cc.next(now, conditions.Condition.ClosingTime, conditions.Condition.NextClosingTime)
+	expected := time.Date(2025, 6, 3, 11, 30, 0, 0, time.Local)
+	if next != expected {
+		t.Fatalf("next time should be %s, it is %s", expected, next)
+	}
+
+	// Not passed yet
+	c, err = NewCompositeCondition("max", "sun_based(06h20), time_based(13h00)", newFixed)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	cc = c.(*compositeCondition)
+
+	next = cc.next(now, conditions.Condition.OpeningTime, conditions.Condition.NextOpeningTime)
+	expected = time.Date(2025, 6, 2, 13, 0, 0, 0, time.Local)
+	if next != expected {
+		t.Fatalf("next time should be %s, it is %s", expected, next)
+	}
+}
@@ -0,0 +1,214 @@
+package lux
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultDwell is the default time the light must stay beyond the threshold.
+const DefaultDwell = 5 * time.Minute
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// A lux condition follows the light measured by a sensor. It is dark when the
+// light stays below the threshold during the dwell time, and light when it
+// stays above the threshold plus the hysteresis. Its value is the threshold
+// followed by the optional settings, such as "30 hysteresis=10 dwell=10m".
+type luxCondition struct {
+	threshold  float64
+	hysteresis float64
+	dwell      time.Duration
+	monitor    *Monitor
+}
+
+// change is a change between the dark and the light.
+type change struct {
+	at    time.Time
+	light bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewLuxCondition returns a new Condition following the light read by the monitor
+// of the coop, it fails if the coop has no light sensor.
+func NewLuxCondition(value string, monitor *Monitor) (conditions.Condition, error) {
+	if monitor == nil {
+		return nil, fmt.Errorf("no light sensor is configured for the coop")
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("threshold is missing")
+	}
+
+	threshold, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(fields[0]), "lux"), 64)
+	if err != nil || threshold < 0 {
+		return nil, fmt.Errorf("threshold is incorrect: %s", fields[0])
+	}
+	c := &luxCondition{
+		threshold: threshold,
+		dwell:     DefaultDwell,
+		monitor:   monitor,
+	}
+
+	for _, field := range fields[1:] {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("setting format is incorrect, it must be key=value: %s", field)
+		}
+
+		switch parts[0] {
+		case "hysteresis":
+			c.hysteresis, err = strconv.ParseFloat(parts[1], 64)
+			if err != nil || c.hysteresis < 0 {
+				return nil, fmt.Errorf("hysteresis is incorrect: %s", parts[1])
+			}
+		case "dwell":
+			c.dwell, err = time.ParseDuration(parts[1])
+			if err != nil || c.dwell < 0 {
+				return nil, fmt.Errorf("dwell time is incorrect: %s", parts[1])
+			}
+		default:
+			return nil, fmt.Errorf("setting does not exist: %s", parts[0])
+		}
+	}
+
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// OpeningTime returns the time of today when it became light.
+func (c *luxCondition) OpeningTime() time.Time {
+	return c.at(c.monitor.Samples(), time.Now(), true)
+}
+
+// ClosingTime returns the time of today when it became dark.
+func (c *luxCondition) ClosingTime() time.Time {
+	return c.at(c.monitor.Samples(), time.Now(), false)
+}
+
+// NextOpeningTime returns the opening time of today, or an estimation for tomorrow if it is passed.
+func (c *luxCondition) NextOpeningTime() time.Time {
+	return next(time.Now(), c.OpeningTime())
+}
+
+// NextClosingTime returns the closing time of today, or an estimation for tomorrow if it is passed.
+func (c *luxCondition) NextClosingTime() time.Time {
+	return next(time.Now(), c.ClosingTime())
+}
+
+// Mode returns the mode of the condition.
+func (c *luxCondition) Mode() string {
+	return "lux"
+}
+
+// Value returns the threshold and the settings of the condition.
+func (c *luxCondition) Value() string {
+	return fmt.Sprintf("%s hysteresis=%s dwell=%s", format(c.threshold), format(c.hysteresis), c.dwell)
+}
+
+// at returns the time of the day of now when it became light, or dark. Until
+// it does, the time is the one of the previous day if it is still to come, so
+// that the schedule can be displayed, or the end of the day.
+func (c *luxCondition) at(samples []Sample, now time.Time, light bool) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOfDay := midnight.AddDate(0, 0, 1).Add(-time.Second)
+
+	// The changes until now, those of today start at the index today
+	changes := c.changes(samples)
+	changes = changes[:sort.Search(len(changes), func(i int) bool { return changes[i].at.After(now) })]
+	today := sort.Search(len(changes), func(i int) bool { return !changes[i].at.Before(midnight) })
+
+	if light {
+		// It is light since the night
+		if today > 0 && changes[today-1].light {
+			return midnight
+		}
+
+		// The first light of the day opens
+		for _, ch := range changes[today:] {
+			if ch.light {
+				return ch.at
+			}
+		}
+	} else if today < len(changes) && !changes[len(changes)-1].light {
+		// The last dark of the day closes, unless it is light again
+		return changes[len(changes)-1].at
+	}
+
+	// The first light or the last dark of the previous day is an estimation
+	var estimation time.Time
+	for _, ch := range changes[:today] {
+		if ch.light == light && !ch.at.Before(midnight.AddDate(0, 0, -1)) && (!light || estimation.IsZero()) {
+			estimation = ch.at.AddDate(0, 0, 1)
+		}
+	}
+	if estimation.After(now) {
+		return estimation
+	}
+
+	return endOfDay
+}
+
+// changes returns the changes between the dark and the light in the samples.
+// The state of the first sample is the one on its side of the threshold.
+func (c *luxCondition) changes(samples []Sample) []change {
+	var changes []change
+	var since time.Time
+	for _, s := range samples {
+		if len(changes) == 0 {
+			changes = append(changes, change{at: s.At, light: s.Lux >= c.threshold})
+			continue
+		}
+
+		light := changes[len(changes)-1].light
+		if light && s.Lux >= c.threshold || !light && s.Lux < c.threshold+c.hysteresis {
+			since = time.Time{}
+			continue
+		}
+
+		if since.IsZero() {
+			since = s.At
+		}
+		if s.At.Sub(since) >= c.dwell {
+			changes = append(changes, change{at: s.At, light: !light})
+			since = time.Time{}
+		}
+	}
+
+	return changes
+}
+
+//------------------------------------------------------------------------------
+// Helpers
+//------------------------------------------------------------------------------
+
+// next returns the time, or the time of the next day if it is passed.
+func next(now, t time.Time) time.Time {
+	if now.After(t) {
+		return t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// format returns the number without useless decimals.
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,173 @@
+package lux
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeSensor returns the illuminance, or the error.
+type fakeSensor struct {
+	lux float64
+	err error
+}
+
+func (s *fakeSensor) Read() (float64, error) {
+	return s.lux, s.err
+}
+
+// day returns the samples of every minute from the previous day until now,
+// with the illuminance given for each time.
+func day(now time.Time, lux func(t time.Time) float64) []Sample {
+	var samples []Sample
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for t := midnight.AddDate(0, 0, -1); !t.After(now); t = t.Add(time.Minute) {
+		samples = append(samples, Sample{At: t, Lux: lux(t)})
+	}
+	return samples
+}
+
+// clock returns the time of the day of now.
+func clock(now time.Time, hours, minutes int) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), hours, minutes, 0, 0, now.Location())
+}
+
+// sunny is light from 07h30 until 18h45, with a cloud from 12h00 until 12h30.
+func sunny(t time.Time) float64 {
+	minutes := t.Hour()*60 + t.Minute()
+	switch {
+	case minutes >= 12*60 && minutes < 12*60+30:
+		return 5
+	case minutes >= 7*60+30 && minutes < 18*60+45:
+		return 500
+	}
+	return 0
+}
+
+func TestLuxCondition(t *testing.T) {
+	_, err := NewLuxCondition("30", nil)
+	if err == nil {
+		t.Fatalf("should error without a light sensor")
+	}
+
+	m := NewMonitor("barn", &fakeSensor{lux: 100}, time.Minute)
+
+	for _, value := range []string{"", "dark", "-5", "30 hysteresis", "30 hysteresis=-1", "30 dwell=soon", "30 color=red", "30 sensor=barn"} {
+		_, err := NewLuxCondition(value, m)
+		if err == nil {
+			t.Fatalf("should error for %q", value)
+		}
+	}
+
+	c, err := NewLuxCondition("30lux dwell=10m hysteresis=7.5", m)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Mode() != "lux" || c.Value() != "30 hysteresis=7.5 dwell=10m0s" {
+		t.Fatalf("mode and value are incorrect: %s(%s)", c.Mode(), c.Value())
+	}
+}
+
+func TestChanges(t *testing.T) {
+	c := &luxCondition{threshold: 30, hysteresis: 20, dwell: 2 * time.Minute}
+	start := time.Date(2025, 6, 2, 18, 0, 0, 0, time.Local)
+
+	var samples []Sample
+	for i, lux := range []float64{
+		100, 20, 20, 100, // Too short to be dark
+		20, 20, 20, // Dark after the dwell time
+		40, 40, 40, 40, // Not light, within the hysteresis
+		60, 60, 60, // Light
+	} {
+		samples = append(samples, Sample{At: start.Add(time.Duration(i) * time.Minute), Lux: lux})
+	}
+
+	changes := c.changes(samples)
+	expected := []change{
+		{at: start, light: true},
+		{at: start.Add(6 * time.Minute), light: false},
+		{at: start.Add(13 * time.Minute), light: true},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("changes are incorrect: %v", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("changes are incorrect: %v", changes)
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	c := &luxCondition{threshold: 30, dwell: 5 * time.Minute}
+	today := time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		now     time.Time
+		opening time.Time
+		closing time.Time
+	}{
+		// Night, the times of yesterday are estimations
+		{clock(today, 5, 0), clock(today, 7, 35), clock(today, 18, 50)},
+		// Morning, it became light
+		{clock(today, 9, 0), clock(today, 7, 35), clock(today, 18, 50)},
+		// Cloud, it became dark
+		{clock(today, 12, 20), clock(today, 7, 35), clock(today, 12, 5)},
+		// After the cloud, it is light again
+		{clock(today, 14, 0), clock(today, 7, 35), clock(today, 18, 50)},
+		// Evening, it became dark
+		{clock(today, 22, 0), clock(today, 7, 35), clock(today, 18, 50)},
+	}
+	for _, test := range tests {
+		samples := day(test.now, sunny)
+		if opening := c.at(samples, test.now, true); opening != test.opening {
+			t.Fatalf("at %s the opening time should be %s, it is %s", test.now, test.opening, opening)
+		}
+		if closing := c.at(samples, test.now, false); closing != test.closing {
+			t.Fatalf("at %s the closing time should be %s, it is %s", test.now, test.closing, closing)
+		}
+	}
+
+	// Without readings, the times are at the end of the day
+	now := clock(today, 9, 0)
+	endOfDay := clock(today, 23, 59).Add(59 * time.Second)
+	if opening := c.at(nil, now, true); opening != endOfDay {
+		t.Fatalf("opening time should be at the end of the day, it is %s", opening)
+	}
+
+	// Overcast, yesterday's time is passed and it is not light yet
+	now = clock(today, 8, 0)
+	samples := day(now, func(t time.Time) float64 {
+		if t.Day() == today.Day() {
+			return 10
+		}
+		return sunny(t)
+	})
+	if opening := c.at(samples, now, true); opening != endOfDay {
+		t.Fatalf("opening time should be at the end of the day, it is %s", opening)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	sensor := &fakeSensor{lux: 100}
+	m := NewMonitor("coop", sensor, time.Minute)
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.Local)
+
+	m.read(now.Add(-History - time.Minute))
+	m.read(now.Add(-time.Hour))
+	sensor.err = errors.New("no answer")
+	m.read(now.Add(-time.Minute))
+	sensor.err = nil
+	sensor.lux = 250
+	m.read(now)
+
+	samples := m.Samples()
+	if len(samples) != 2 || samples[0].At != now.Add(-time.Hour) || samples[1].At != now {
+		t.Fatalf("samples are incorrect: %v", samples)
+	}
+	if got := testutil.ToFloat64(illuminance.WithLabelValues("coop")); got != 250 {
+		t.Fatalf("should expose the last reading, it is %v", got)
+	}
+}
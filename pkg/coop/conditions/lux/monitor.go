@@ -0,0 +1,150 @@
+package lux
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/lightsensor"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultInterval is the default interval between two readings of the light.
+const DefaultInterval = time.Minute
+
+// History is how long the readings are kept, the conditions replay them from
+// the beginning of the previous day.
+const History = 48 * time.Hour
+
+var illuminance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "gocoop_light_lux",
+	Help: "Illuminance measured by the light sensor, in lux.",
+}, []string{"sensor"})
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Sample is a reading of the light.
+type Sample struct {
+	At  time.Time
+	Lux float64
+}
+
+// Monitor reads a light sensor at every interval and keeps the readings.
+type Monitor struct {
+	name     string
+	sensor   lightsensor.Sensor
+	interval time.Duration
+	done     chan struct{}
+
+	mu      sync.Mutex
+	samples []Sample
+	failing bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewMonitor returns a new Monitor of the sensor with the name.
+func NewMonitor(name string, sensor lightsensor.Sensor, interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Monitor{
+		name:     name,
+		sensor:   sensor,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Start reads the sensor a first time, then at every interval.
+func (m *Monitor) Start() {
+	logrus.WithFields(logrus.Fields{
+		"sensor":   m.name,
+		"interval": m.interval,
+	}).Infoln("Monitoring the light")
+
+	m.read(time.Now())
+	go m.run()
+}
+
+// Stop stops reading the sensor.
+func (m *Monitor) Stop() {
+	close(m.done)
+}
+
+// Samples returns the readings, from the oldest to the latest.
+func (m *Monitor) Samples() []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Sample(nil), m.samples...)
+}
+
+// run reads the sensor at every interval.
+func (m *Monitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.read(now)
+		}
+	}
+}
+
+// read reads the sensor and keeps the reading. The errors are logged when
+// the sensor starts failing, and when it recovers.
+func (m *Monitor) read(now time.Time) {
+	lux, err := m.sensor.Read()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		if !m.failing {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"sensor": m.name,
+			}).Warningln("Cannot read the light sensor")
+		}
+		m.failing = true
+		return
+	}
+	if m.failing {
+		logrus.WithFields(logrus.Fields{
+			"sensor": m.name,
+		}).Infoln("The light sensor has recovered")
+	}
+	m.failing = false
+
+	illuminance.WithLabelValues(m.name).Set(lux)
+	m.add(Sample{At: now, Lux: lux})
+}
+
+// add keeps the sample, and drops the samples older than the history.
+func (m *Monitor) add(s Sample) {
+	m.samples = append(m.samples, s)
+
+	i := sort.Search(len(m.samples), func(i int) bool {
+		return !m.samples[i].At.Before(s.At.Add(-History))
+	})
+	m.samples = m.samples[i:]
+}
@@ -0,0 +1,104 @@
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+// The sun position is computed with the algorithms of the NOAA solar
+// calculator, which are accurate to about one minute between the polar circles.
+
+// crossings returns the times of the day when the center of the sun crosses
+// the elevation in the morning and in the evening. If the sun does not reach
+// the elevation that day, both are the solar noon. If it stays above, they are
+// the solar midnights before and after the solar noon.
+func crossings(day time.Time, latitude, longitude, elevation float64) (time.Time, time.Time) {
+	// Midnight UTC of the day, the date is the one of the location of day
+	y, m, d := day.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	// The hour angle is estimated at the solar noon, then refined with the
+	// position of the sun at each crossing
+	noon := solarNoon(midnight, longitude)
+	h := hourAngle(noon, latitude, elevation)
+	morning := noon.Add(-minutes(4 * hourAngle(noon.Add(-minutes(4*h)), latitude, elevation)))
+	evening := noon.Add(minutes(4 * hourAngle(noon.Add(minutes(4*h)), latitude, elevation)))
+
+	return morning.In(day.Location()), evening.In(day.Location())
+}
+
+// solarNoon returns the solar noon of the day starting at midnight UTC.
+func solarNoon(midnight time.Time, longitude float64) time.Time {
+	_, equationOfTime := sunPosition(midnight.Add(minutes(720 - 4*longitude)))
+
+	return midnight.Add(minutes(720 - 4*longitude - equationOfTime))
+}
+
+// hourAngle returns the hour angle in degrees of the sun at the elevation,
+// with the declination of the sun at t.
+func hourAngle(t time.Time, latitude, elevation float64) float64 {
+	declination, _ := sunPosition(t)
+	cos := (math.Sin(rad(elevation)) - math.Sin(rad(latitude))*math.Sin(rad(declination))) /
+		(math.Cos(rad(latitude)) * math.Cos(rad(declination)))
+
+	// The sun does not cross the elevation
+	cos = math.Max(-1, math.Min(1, cos))
+
+	return deg(math.Acos(cos))
+}
+
+// elevationAt returns the elevation of the center of the sun in degrees at t,
+// without the atmospheric refraction.
+func elevationAt(t time.Time, latitude, longitude float64) float64 {
+	declination, equationOfTime := sunPosition(t)
+	u := t.UTC()
+	trueSolarTime := float64(u.Hour()*60+u.Minute()) + float64(u.Second())/60 + equationOfTime + 4*longitude
+	h := trueSolarTime/4 - 180
+
+	cos := math.Sin(rad(latitude))*math.Sin(rad(declination)) + math.Cos(rad(latitude))*math.Cos(rad(declination))*math.Cos(rad(h))
+
+	return deg(math.Asin(cos))
+}
+
+// sunPosition returns the declination of the sun in degrees and the equation
+// of time in minutes at t.
+func sunPosition(t time.Time) (float64, float64) {
+	// Julian century
+	c := (float64(t.Unix())/86400 + 2440587.5 - 2451545) / 36525
+
+	meanLongitude := math.Mod(280.46646+c*(36000.76983+c*0.0003032), 360)
+	meanAnomaly := 357.52911 + c*(35999.05029-0.0001537*c)
+	eccentricity := 0.016708634 - c*(0.000042037+0.0000001267*c)
+	center := math.Sin(rad(meanAnomaly))*(1.914602-c*(0.004817+0.000014*c)) +
+		math.Sin(rad(2*meanAnomaly))*(0.019993-0.000101*c) +
+		math.Sin(rad(3*meanAnomaly))*0.000289
+	omega := 125.04 - 1934.136*c
+	apparentLongitude := meanLongitude + center - 0.00569 - 0.00478*math.Sin(rad(omega))
+	obliquity := 23 + (26+(21.448-c*(46.815+c*(0.00059-c*0.001813)))/60)/60 + 0.00256*math.Cos(rad(omega))
+
+	declination := deg(math.Asin(math.Sin(rad(obliquity)) * math.Sin(rad(apparentLongitude))))
+
+	y := math.Pow(math.Tan(rad(obliquity/2)), 2)
+	equationOfTime := 4 * deg(y*math.Sin(2*rad(meanLongitude))-
+		2*eccentricity*math.Sin(rad(meanAnomaly))+
+		4*eccentricity*y*math.Sin(rad(meanAnomaly))*math.Cos(2*rad(meanLongitude))-
+		0.5*y*y*math.Sin(4*rad(meanLongitude))-
+		1.25*eccentricity*eccentricity*math.Sin(2*rad(meanAnomaly)))
+
+	return declination, equationOfTime
+}
+
+// minutes returns the duration of the minutes.
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
+
+// rad returns the degrees in radians.
+func rad(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+// deg returns the radians in degrees.
+func deg(r float64) float64 {
+	return r * 180 / math.Pi
+}
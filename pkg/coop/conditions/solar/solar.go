@@ -0,0 +1,149 @@
+package solar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+const (
+	// CivilTwilight is the civil dawn and dusk, the sun is 6° below the horizon.
+	CivilTwilight = "civil_twilight"
+
+	// NauticalTwilight is the nautical dawn and dusk, the sun is 12° below the horizon.
+	NauticalTwilight = "nautical_twilight"
+
+	// AstronomicalTwilight is the astronomical dawn and dusk, the sun is 18° below the horizon.
+	AstronomicalTwilight = "astronomical_twilight"
+
+	// Elevation is when the sun crosses a given elevation.
+	Elevation = "solar_elevation"
+)
+
+// elevations are the elevations of the sun of the twilights, in degrees.
+var elevations = map[string]float64{
+	CivilTwilight:        -6,
+	NauticalTwilight:     -12,
+	AstronomicalTwilight: -18,
+}
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// A solar condition is when the sun crosses an elevation, in the morning for
+// the opening and in the evening for the closing. The value of a twilight is
+// an offset such as "15m", the value of a solar elevation is the elevation in
+// degrees such as "-4".
+type solarCondition struct {
+	mode      string
+	elevation float64
+	offset    time.Duration
+	latitude  float64
+	longitude float64
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewSolarCondition returns a new Condition with the mode and the value.
+func NewSolarCondition(mode, value string, latitude, longitude float64) (conditions.Condition, error) {
+	c := &solarCondition{
+		mode:      mode,
+		latitude:  latitude,
+		longitude: longitude,
+	}
+
+	switch mode {
+	case CivilTwilight, NauticalTwilight, AstronomicalTwilight:
+		offset, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the offset: %s", err)
+		}
+		c.elevation = elevations[mode]
+		c.offset = offset
+	case Elevation:
+		elevation, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "°"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing the elevation: %s", err)
+		}
+		if elevation < -90 || elevation > 90 {
+			return nil, fmt.Errorf("elevation must be between -90 and 90 degrees: %s", value)
+		}
+		c.elevation = elevation
+	default:
+		return nil, fmt.Errorf("mode does not exist: %s", mode)
+	}
+
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// OpeningTime returns the time of today when the sun rises above the elevation.
+func (c *solarCondition) OpeningTime() time.Time {
+	return c.morning(time.Now())
+}
+
+// ClosingTime returns the time of today when the sun sets below the elevation.
+func (c *solarCondition) ClosingTime() time.Time {
+	return c.evening(time.Now())
+}
+
+// NextOpeningTime returns the opening time of today, or of tomorrow if it is passed.
+func (c *solarCondition) NextOpeningTime() time.Time {
+	return next(time.Now(), c.morning)
+}
+
+// NextClosingTime returns the closing time of today, or of tomorrow if it is passed.
+func (c *solarCondition) NextClosingTime() time.Time {
+	return next(time.Now(), c.evening)
+}
+
+// Mode returns the mode of the condition.
+func (c *solarCondition) Mode() string {
+	return c.mode
+}
+
+// Value returns the value of the condition.
+func (c *solarCondition) Value() string {
+	if c.mode == Elevation {
+		return strconv.FormatFloat(c.elevation, 'f', -1, 64)
+	}
+
+	return c.offset.String()
+}
+
+// morning returns the time of the day when the sun rises above the elevation.
+func (c *solarCondition) morning(day time.Time) time.Time {
+	morning, _ := crossings(day, c.latitude, c.longitude, c.elevation)
+
+	return morning.Add(c.offset)
+}
+
+// evening returns the time of the day when the sun sets below the elevation.
+func (c *solarCondition) evening(day time.Time) time.Time {
+	_, evening := crossings(day, c.latitude, c.longitude, c.elevation)
+
+	return evening.Add(c.offset)
+}
+
+// next returns the time of the day of now, or of the next day if it is passed.
+func next(now time.Time, at func(day time.Time) time.Time) time.Time {
+	t := at(now)
+	if now.After(t) {
+		return at(now.AddDate(0, 0, 1))
+	}
+
+	return t
+}
@@ -0,0 +1,102 @@
+package solar
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Paris
+const latitude = 48.8566
+const longitude = 2.3522
+
+func TestSolarCondition(t *testing.T) {
+	for _, test := range []struct {
+		mode  string
+		value string
+	}{
+		{"civil_twilight", "faya"},
+		{"solar_elevation", "faya"},
+		{"solar_elevation", "-95"},
+		{"golden_hour", "10m"},
+	} {
+		_, err := NewSolarCondition(test.mode, test.value, latitude, longitude)
+		if err == nil {
+			t.Fatalf("should error for %s(%s)", test.mode, test.value)
+		}
+	}
+
+	c, err := NewSolarCondition("civil_twilight", "15m", latitude, longitude)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Mode() != "civil_twilight" || c.Value() != "15m0s" {
+		t.Fatalf("mode and value are incorrect: %s(%s)", c.Mode(), c.Value())
+	}
+
+	c, err = NewSolarCondition("solar_elevation", " -4.5° ", latitude, longitude)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Mode() != "solar_elevation" || c.Value() != "-4.5" {
+		t.Fatalf("mode and value are incorrect: %s(%s)", c.Mode(), c.Value())
+	}
+
+	if c.NextClosingTime().Before(time.Now()) {
+		t.Fatalf("next closing time should be in the future: %s", c.NextClosingTime())
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	day := time.Date(2025, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	// Times of the NOAA solar calculator, in UTC
+	tests := []struct {
+		elevation float64
+		morning   string
+		evening   string
+	}{
+		{-0.833, "03h47", "19h58"}, // Sunrise and sunset
+		{-6, "03h02", "20h42"},     // Civil twilight
+		{-12, "02h03", "21h41"},    // Nautical twilight
+	}
+	for _, test := range tests {
+		morning, evening := crossings(day, latitude, longitude, test.elevation)
+		if !near(morning, test.morning) || !near(evening, test.evening) {
+			t.Fatalf("crossings of %v° should be %s and %s, they are %s and %s", test.elevation, test.morning, test.evening, morning.Format("15h04"), evening.Format("15h04"))
+		}
+
+		// The sun is at the elevation, without the refraction of the sunrise
+		for _, crossing := range []time.Time{morning, evening} {
+			if e := elevationAt(crossing, latitude, longitude); test.elevation != -0.833 && math.Abs(e-test.elevation) > 0.1 {
+				t.Fatalf("elevation at %s should be %v°, it is %v°", crossing, test.elevation, e)
+			}
+		}
+	}
+
+	// The astronomical night does not come in June in Paris, the crossings are the solar midnights
+	morning, evening := crossings(day, latitude, longitude, -18)
+	if !near(morning, "23h51") || !near(evening, "23h52") {
+		t.Fatalf("crossings should be the solar midnights, they are %s and %s", morning, evening)
+	}
+
+	// The sun does not rise in December in Tromsø, the crossings are the solar noon
+	morning, evening = crossings(time.Date(2025, 12, 21, 12, 0, 0, 0, time.UTC), 69.6492, 18.9553, -0.833)
+	if morning != evening || !near(morning, "10h42") {
+		t.Fatalf("crossings should be the solar noon, they are %s and %s", morning, evening)
+	}
+}
+
+// near returns true if the time is within 2 minutes of the time HHhMM of its day.
+func near(t time.Time, expected string) bool {
+	e, _ := time.Parse("15h04", expected)
+	minutes := t.Hour()*60 + t.Minute() - (e.Hour()*60 + e.Minute())
+	if minutes > 720 {
+		minutes -= 1440
+	}
+	if minutes < -720 {
+		minutes += 1440
+	}
+
+	return minutes >= -2 && minutes <= 2
+}
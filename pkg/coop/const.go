@@ -6,7 +6,7 @@ import (
 )
 
 // ErrAutomaticModeEnabled is raised when the automatic mode is enabled.
-var ErrAutomaticModeEnabled = errors.New("cannot close the coop because automatic mode is enabled")
+var ErrAutomaticModeEnabled = errors.New("cannot use the coop because automatic mode is enabled")
 
 // ErrCoopAlreadyOpening is raised when the coop is already opening.
 var ErrCoopAlreadyOpening = errors.New("coop is already opening")
@@ -23,11 +23,47 @@ const CheckFrequency = 10 * time.Second
 // NotificationMessage is the notification message.
 const NotificationMessage = "The status of the coop is unknown."
 
+// ObstructedMessage is the notification message when the door cannot be closed because of an obstacle.
+const ObstructedMessage = "The door of the coop cannot be closed, something is blocking it."
+
+// TimeoutMessage is the notification message when the door has not reached the end of its travel in time.
+const TimeoutMessage = "The door of the coop has not been %s in time, its position is unknown."
+
+// MismatchMessage is the notification message when the position of the door does not match the status of the coop.
+const MismatchMessage = "The door of the coop is %s while the coop is %s."
+
 // ErrIncorrectStatus is raised when status of the coop is incorrect.
 var ErrIncorrectStatus = errors.New("status is incorrect")
 
 // ErrCoopAlreadyOpened is raised when the coop is already opened.
 var ErrCoopAlreadyOpened = errors.New("coop is already opened")
 
+// ErrCoopAlreadyClosed is raised when the coop is already closed.
+var ErrCoopAlreadyClosed = errors.New("coop is already closed")
+
+// ErrCoopAlreadyPartial is raised when the door of the coop is already at the partial position.
+var ErrCoopAlreadyPartial = errors.New("coop is already partially opened")
+
+// ErrNoPartialPosition is raised when the partial position of the door is not configured.
+var ErrNoPartialPosition = errors.New("partial position is not configured")
+
+// ErrStatusUnknown is raised when the coop cannot be used because its status is unknown.
+var ErrStatusUnknown = errors.New("status of the coop is unknown")
+
+// ErrTransitionNotAllowed is raised when a transition is not in the transition table.
+var ErrTransitionNotAllowed = errors.New("transition is not allowed")
+
+// SubscriberBufferSize is the number of events buffered for each subscriber.
+const SubscriberBufferSize = 32
+
+// Scheduler is the name recorded for the actions triggered by the automatic mode.
+const Scheduler = "scheduler"
+
+// positions are the positions of the door in percent for the statuses at the limit switches.
+var positions = map[Status]float64{
+	Closed: 0,
+	Opened: 100,
+}
+
 // DefaultStatus is the default status
 const DefaultStatus = Unknown
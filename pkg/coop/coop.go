@@ -2,16 +2,19 @@ package coop
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/fallais/gocoop/pkg/coop/conditions"
-	"github.com/fallais/gocoop/pkg/coop/conditions/sunbased"
-	"github.com/fallais/gocoop/pkg/coop/conditions/timebased"
+	"github.com/fallais/gocoop/pkg/coop/conditions/lux"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/fallais/gocoop/pkg/coop/state"
 	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/motor"
 	"github.com/fallais/gocoop/pkg/notifiers"
-	"github.com/stianeikeland/go-rpio/v4"
-	"github.com/spf13/viper"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,17 +23,37 @@ import (
 //------------------------------------------------------------------------------
 
 // Coop represents a chicken coop.
+//
+// The status of the coop is a state machine guarded by a mutex: every change
+// goes through the transition table, so that concurrent checks and manual
+// actions cannot drive the motor twice.
 type Coop struct {
 	door      door.Door
 	ticker    *time.Ticker
 	notifiers []notifiers.Notifier
-
-	OpeningCondition conditions.Condition
-	ClosingCondition conditions.Condition
-	Status           Status
-	Latitude         float64
-	Longitude        float64
-	IsAutomatic      bool
+	store     state.Store
+	journal   journal.Journal
+
+	subMu       sync.Mutex
+	subscribers []chan journal.Event
+	name        string
+
+	mu               sync.Mutex
+	openingCondition conditions.Condition
+	closingCondition conditions.Condition
+	partialCondition conditions.Condition
+	partialPosition  float64
+	status           Status
+	isAutomatic      bool
+	lastTransition   time.Time
+	stopRequested    bool
+	redrive          bool
+	mismatch         bool
+	heldOpen         bool
+
+	Latitude  float64
+	Longitude float64
+	Light     *lux.Monitor
 }
 
 //------------------------------------------------------------------------------
@@ -38,65 +61,54 @@ type Coop struct {
 //------------------------------------------------------------------------------
 
 // New returns a new Coop with given latitude and longitude, a door, and options.
-func New(latitude, longitude float64, door door.Door, openingConditionMode, openingConditionValue, closingConditionMode, closingConditionValue string, 
-		 notifiers []notifiers.Notifier, isAutomatic, notifyAtStartup bool) (*Coop, error) {
+// The light monitor is used by the lux conditions, it is nil without light sensor.
+// The partial condition is optional, its mode is then empty.
+// When a store is given, the state saved during the previous run is restored.
+// When a journal is given, every transition and error is recorded into it.
+func New(latitude, longitude float64, light *lux.Monitor, door door.Door, openingConditionMode, openingConditionValue, closingConditionMode, closingConditionValue string, 
+		 partialConditionMode, partialConditionValue string, notifiers []notifiers.Notifier, store state.Store, journal journal.Journal, isAutomatic, notifyAtStartup bool) (*Coop, error) {
 	// Check latitude and longtitude
 	if latitude == 0 && longitude == 0 {
 		return nil, ErrIncorrectPosition
 	}
 
 	// Create the opening condition
-	var openingCondition conditions.Condition
-	switch openingConditionMode {
-	case "time_based":
-		oc, err := timebased.NewTimeBasedCondition(openingConditionValue)
-		if err != nil {
-			return nil, fmt.Errorf("error while creating the opening condition: %s", err)
-		}
-
-		openingCondition = oc
-	case "sun_based":
-		oc, err := sunbased.NewSunBasedCondition(openingConditionValue, latitude, longitude)
-		if err != nil {
-			return nil, fmt.Errorf("error while creating the opening condition: %s", err)
-		}
-
-		openingCondition = oc
-	default:
-		return nil, fmt.Errorf("error with the opening mode: %s", viper.GetString("coop.opening.mode"))
+	openingCondition, err := NewCondition(openingConditionMode, openingConditionValue, latitude, longitude, light)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating the opening condition: %s", err)
 	}
 
 	// Create the closing condition
-	var closingCondition conditions.Condition
-	switch closingConditionMode {
-	case "time_based":
-		cc, err := timebased.NewTimeBasedCondition(closingConditionValue)
-		if err != nil {
-			return nil, fmt.Errorf("error while creating the closing condition: %s", err)
-		}
-
-		closingCondition = cc
-	case "sun_based":
-		cc, err := sunbased.NewSunBasedCondition(closingConditionValue, latitude, longitude)
-		if err != nil {
-			return nil, fmt.Errorf("error while creating the closing condition: %s", err)
-		}
+	closingCondition, err := NewCondition(closingConditionMode, closingConditionValue, latitude, longitude, light)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating the closing condition: %s", err)
+	}
 
-		closingCondition = cc
-	default:
-		return nil, fmt.Errorf("error with the closing mode: %s", viper.GetString("coop.closing.mode"))
+	// Create the partial condition
+	partialCondition, err := NewPartialCondition(partialConditionMode, partialConditionValue, latitude, longitude, light)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating the partial condition: %s", err)
 	}
 
 	c := &Coop{
 		door:             door,
 		notifiers:        notifiers,
 		ticker:           time.NewTicker(CheckFrequency),
-		OpeningCondition: openingCondition,
-		ClosingCondition: closingCondition,
+		openingCondition: openingCondition,
+		closingCondition: closingCondition,
+		partialCondition: partialCondition,
 		Latitude:         latitude,
 		Longitude:        longitude,
-		Status:           DefaultStatus,
-		IsAutomatic:      isAutomatic,
+		Light:            light,
+		status:           DefaultStatus,
+		isAutomatic:      isAutomatic,
+		store:            store,
+		journal:          journal,
+	}
+
+	// Restore the previous state
+	if store != nil {
+		c.restore()
 	}
 
 	// Watch the clock
@@ -104,7 +116,7 @@ func New(latitude, longitude float64, door door.Door, openingConditionMode, open
 
 	// Notify that the status is unknown
 	if notifyAtStartup {
-		go c.notify()
+		go c.notify(NotificationMessage)
 	}
 
 	return c, nil
@@ -121,201 +133,691 @@ func (coop *Coop) watch() {
 	}
 }
 
-func (coop *Coop) notify() {
+// notifierFailures counts the notifications that have failed.
+var notifierFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gocoop_notifier_failures_total",
+	Help: "Number of notifications that have failed.",
+}, []string{"vendor"})
+
+func (coop *Coop) notify(message string) {
+	if name := coop.Name(); name != "" {
+		message = fmt.Sprintf("%s: %s", name, message)
+	}
+
 	logrus.Infoln("Notifying")
 	for _, notifier := range coop.notifiers {
-		err := notifier.Notify(NotificationMessage)
+		err := notifier.Notify(message)
 		if err != nil {
 			logrus.Errorf("error while notifying: %s", err)
+			notifierFailures.WithLabelValues(notifier.Vendor()).Inc()
+			coop.record(journal.Event{
+				Type:    journal.Error,
+				By:      Scheduler,
+				Message: fmt.Sprintf("error while notifying with %s: %s", notifier.Vendor(), err),
+			})
 		}
 	}
 }
 
+// Status returns the status of the chicken coop.
+func (coop *Coop) Status() Status {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.status
+}
+
+// Location returns the latitude and the longitude of the chicken coop.
+func (coop *Coop) Location() (float64, float64) {
+	return coop.Latitude, coop.Longitude
+}
+
+// Name returns the name of the door of the chicken coop, empty for the main door.
+func (coop *Coop) Name() string {
+	coop.subMu.Lock()
+	defer coop.subMu.Unlock()
+
+	return coop.name
+}
+
+// SetName sets the name of the door of the chicken coop. The events of a
+// named door are recorded with its name, and its notifications start with it.
+func (coop *Coop) SetName(name string) {
+	coop.subMu.Lock()
+	defer coop.subMu.Unlock()
+
+	coop.name = name
+}
+
+// IsAutomatic returns true if the automatic mode is enabled.
+func (coop *Coop) IsAutomatic() bool {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.isAutomatic
+}
+
+// SetRedrive enables or disables the re-drive of the door to the position
+// expected by the status when a mismatch is detected in automatic mode.
+func (coop *Coop) SetRedrive(redrive bool) {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	coop.redrive = redrive
+}
+
+// OpeningCondition returns the opening condition of the chicken coop.
+func (coop *Coop) OpeningCondition() conditions.Condition {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.openingCondition
+}
+
+// ClosingCondition returns the closing condition of the chicken coop.
+func (coop *Coop) ClosingCondition() conditions.Condition {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.closingCondition
+}
+
+// PartialCondition returns the condition of the partial position of the
+// chicken coop, nil if the partial position is not scheduled.
+func (coop *Coop) PartialCondition() conditions.Condition {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.partialCondition
+}
+
+// SetPartialPosition sets the partial position of the door, in percent from
+// 0 when it is closed to 100 when it is opened. 0 disables it.
+func (coop *Coop) SetPartialPosition(position float64) {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	coop.partialPosition = position
+}
+
+// PartialPosition returns the partial position of the door, 0 if it is disabled.
+func (coop *Coop) PartialPosition() float64 {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.partialPosition
+}
+
+// LastTransition returns the time of the last change of status.
+func (coop *Coop) LastTransition() time.Time {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	return coop.lastTransition
+}
+
 // NextOpeningTime returns the next opening time of the chicken coop.
 func (coop *Coop) NextOpeningTime() time.Time {
-	return coop.OpeningCondition.NextOpeningTime()
+	return coop.OpeningCondition().NextOpeningTime()
 }
 
 // NextClosingTime returns the next closing time of the chicken coop.
 func (coop *Coop) NextClosingTime() time.Time {
-	return coop.ClosingCondition.NextClosingTime()
+	return coop.ClosingCondition().NextClosingTime()
 }
 
-// Open opens the chicken coop.
-func (coop *Coop) Open() error {
+// Update updates the status, the automatic mode and the conditions of the
+// chicken coop on behalf of the given user. It is rejected while the door is moving.
+// The partial condition can be nil.
+func (coop *Coop) Update(by string, status Status, isAutomatic bool, openingCondition, closingCondition, partialCondition conditions.Condition) error {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	// Check the status
+	switch status {
+	case Opened, Closed, Partial, Unknown:
+	default:
+		return ErrIncorrectStatus
+	}
+
+	// Check the transition
+	if coop.status == Opening || coop.status == Closing {
+		return &TransitionError{
+			From: coop.status,
+			To:   status,
+			Err:  rejection(coop.status, status),
+		}
+	}
+	if coop.status != status && !isAllowed(coop.status, status) {
+		return &TransitionError{
+			From: coop.status,
+			To:   status,
+			Err:  rejection(coop.status, status),
+		}
+	}
+
+	// Update the coop
+	if coop.status != status {
+		coop.lastTransition = time.Now()
+		coop.record(journal.Event{
+			Type: journal.Transition,
+			From: string(coop.status),
+			To:   string(status),
+			By:   by,
+		})
+	}
+	coop.status = status
+	coop.isAutomatic = isAutomatic
+	coop.heldOpen = false
+	coop.openingCondition = openingCondition
+	coop.closingCondition = closingCondition
+	coop.partialCondition = partialCondition
+	message := fmt.Sprintf("automatic=%t opening=%s(%s) closing=%s(%s)", isAutomatic,
+		openingCondition.Mode(), openingCondition.Value(), closingCondition.Mode(), closingCondition.Value())
+	if partialCondition != nil {
+		message += fmt.Sprintf(" partial=%s(%s)", partialCondition.Mode(), partialCondition.Value())
+	}
+	coop.record(journal.Event{
+		Type:    journal.Configuration,
+		By:      by,
+		Message: message,
+	})
+
+	return coop.save()
+}
+
+// Subscribe returns a channel that receives every event of the coop, such as
+// the transitions and the configuration changes. Events are dropped if the
+// subscriber is too slow.
+func (coop *Coop) Subscribe() <-chan journal.Event {
+	coop.subMu.Lock()
+	defer coop.subMu.Unlock()
+
+	ch := make(chan journal.Event, SubscriberBufferSize)
+	coop.subscribers = append(coop.subscribers, ch)
+
+	return ch
+}
+
+// Events returns the events of the journal matching the query. The events of
+// a named door are the ones recorded with its name.
+func (coop *Coop) Events(q journal.Query) ([]journal.Event, int, error) {
+	if coop.journal == nil {
+		return []journal.Event{}, 0, nil
+	}
+	if name := coop.Name(); name != "" {
+		q.Door = name
+	}
+
+	return coop.journal.List(q)
+}
+
+// Open opens the chicken coop on behalf of the given user.
+func (coop *Coop) Open(by string) error {
 	// Check the automatic mode
-	if coop.IsAutomatic {
+	if coop.IsAutomatic() {
 		return ErrAutomaticModeEnabled
 	}
 
-	return coop.open()
+	return coop.open(by)
 }
 
-func (coop *Coop) open() error {
-	// Check the incompatible status
-	switch coop.Status {
-	case Unknown:
-		return fmt.Errorf("cannot open the coop because the status unknown")
-	case Opened:
-		return ErrCoopAlreadyOpened
-	case Opening:
-		return ErrCoopAlreadyOpening
-	case Closing:
-		return ErrCoopAlreadyClosing
+func (coop *Coop) open(by string) error {
+	return coop.move(by, Opening, Opened)
+}
+
+// Close closes the chicken coop on behalf of the given user.
+func (coop *Coop) Close(by string) error {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return ErrAutomaticModeEnabled
 	}
 
-	// Update the status of the coop
-	coop.Status = Opening
+	return coop.close(by)
+}
+
+func (coop *Coop) close(by string) error {
+	return coop.move(by, Closing, Closed)
+}
 
-	// Open the door
-	err := coop.door.Open()
+// OpenPartially moves the door of the chicken coop to the partial position on behalf of the given user.
+func (coop *Coop) OpenPartially(by string) error {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return ErrAutomaticModeEnabled
+	}
+
+	return coop.openPartially(by)
+}
+
+func (coop *Coop) openPartially(by string) error {
+	moving, err := coop.partialDirection()
 	if err != nil {
-		// Update the status of the coop
-		coop.Status = Unknown
+		return err
+	}
 
-		return fmt.Errorf("error while opening the door: %s", err)
+	return coop.move(by, moving, Partial)
+}
+
+// OpenAsync starts opening the chicken coop on behalf of the given user and
+// returns as soon as the door is moving. The result of the movement is sent
+// on the returned channel.
+func (coop *Coop) OpenAsync(by string) (<-chan error, error) {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return nil, ErrAutomaticModeEnabled
 	}
 
-	// Update the status of the coop
-	coop.Status = Opened
+	return coop.moveAsync(by, Opening, Opened)
+}
 
-	return nil
+// CloseAsync starts closing the chicken coop on behalf of the given user and
+// returns as soon as the door is moving. The result of the movement is sent
+// on the returned channel.
+func (coop *Coop) CloseAsync(by string) (<-chan error, error) {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return nil, ErrAutomaticModeEnabled
+	}
+
+	return coop.moveAsync(by, Closing, Closed)
 }
 
-// Close closes the chicken coop.
-func (coop *Coop) Close() error {
+// OpenPartiallyAsync starts moving the door of the chicken coop to the
+// partial position on behalf of the given user and returns as soon as the
+// door is moving. The result of the movement is sent on the returned channel.
+func (coop *Coop) OpenPartiallyAsync(by string) (<-chan error, error) {
 	// Check the automatic mode
-	if coop.IsAutomatic {
-		return fmt.Errorf("cannot close the coop because automatic mode is set")
+	if coop.IsAutomatic() {
+		return nil, ErrAutomaticModeEnabled
+	}
+
+	moving, err := coop.partialDirection()
+	if err != nil {
+		return nil, err
 	}
 
-	return coop.close()
+	return coop.moveAsync(by, moving, Partial)
 }
 
-func (coop *Coop) close() error {
-	// Check the incompatible statuses
-	switch coop.Status {
-	case Unknown:
-		return fmt.Errorf("cannot open the coop because the status unknown")
+// partialDirection returns the moving status that leads to the partial position from the current status.
+func (coop *Coop) partialDirection() (Status, error) {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	if coop.partialPosition <= 0 || coop.partialPosition >= 100 {
+		return "", ErrNoPartialPosition
+	}
+
+	switch coop.status {
 	case Closed:
-		return fmt.Errorf("coop is already closed")
-	case Opening:
-		return fmt.Errorf("coop is already opening")
-	case Closing:
-		return fmt.Errorf("coop is already closing")
+		return Opening, nil
+	case Opened:
+		return Closing, nil
 	}
 
-	// Update the status of the coop
-	coop.Status = Closing
+	return "", &TransitionError{
+		From: coop.status,
+		To:   Partial,
+		Err:  rejection(coop.status, Partial),
+	}
+}
+
+// Position returns the position of the door measured by its encoder, as a
+// percentage from 0 when it is closed to 100 when it is opened.
+func (coop *Coop) Position() (float64, error) {
+	return coop.door.Position()
+}
+
+// Calibrate learns the travel of the encoder of the door on behalf of the
+// given user: the door is closed down to its limit switch, then fully opened.
+func (coop *Coop) Calibrate(by string) error {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return ErrAutomaticModeEnabled
+	}
+
+	err := coop.door.Calibrate()
+	if err != nil {
+		return err
+	}
+
+	return coop.calibrate(by)
+}
+
+// CalibrateAsync is like Calibrate but it runs the door in the background.
+// The result of the calibration is sent on the returned channel.
+func (coop *Coop) CalibrateAsync(by string) (<-chan error, error) {
+	// Check the automatic mode
+	if coop.IsAutomatic() {
+		return nil, ErrAutomaticModeEnabled
+	}
+
+	err := coop.door.Calibrate()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- coop.calibrate(by)
+	}()
+
+	return done, nil
+}
+
+// calibrate closes then opens the door, the travel is learnt by the door on the way.
+func (coop *Coop) calibrate(by string) error {
+	logrus.WithFields(logrus.Fields{
+		"by": by,
+	}).Infoln("Calibrating the door")
+
+	// Start from the closed limit switch
+	if coop.Status() != Closed {
+		err := coop.close(by)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := coop.open(by)
+	if err != nil {
+		return err
+	}
+
+	_, err = coop.door.Position()
+	if err != nil {
+		return fmt.Errorf("error while calibrating the door: %s", err)
+	}
 
-	// Close the door
-	err := coop.door.Close()
+	logrus.Infoln("The door has been calibrated")
+
+	return nil
+}
+
+// move runs the door from the moving status to the final status.
+func (coop *Coop) move(by string, moving, final Status) error {
+	run, err := coop.begin(by, moving, final)
+	if err != nil {
+		return err
+	}
+
+	return coop.finish(by, final, run)
+}
+
+// moveAsync is like move but it runs the door in the background.
+func (coop *Coop) moveAsync(by string, moving, final Status) (<-chan error, error) {
+	run, err := coop.begin(by, moving, final)
 	if err != nil {
-		// Update the status of the coop
-		coop.Status = Unknown
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- coop.finish(by, final, run)
+	}()
+
+	return done, nil
+}
+
+// begin moves the coop to the moving status, and returns the movement of the
+// door from the previous status to the final status.
+func (coop *Coop) begin(by string, moving, final Status) (func() (door.Movement, error), error) {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
 
-		return fmt.Errorf("error while opening the door: %s", err)
+	from := coop.status
+	err := coop.transition(moving, journal.Event{By: by})
+	if err != nil {
+		return nil, err
+	}
+	coop.stopRequested = false
+	coop.heldOpen = false
+
+	return coop.runner(from, final), nil
+}
+
+// runner returns the movement of the door from a status to another. The
+// partial position is reached with a movement to a position, the limit
+// switches are used otherwise, even when leaving the partial position. The
+// lock must be held by the caller.
+func (coop *Coop) runner(from, to Status) func() (door.Movement, error) {
+	partial := coop.partialPosition
+
+	switch {
+	case to == Partial:
+		position := positions[from]
+		return func() (door.Movement, error) {
+			return coop.door.MoveTo(position, partial)
+		}
+	case from == Partial:
+		return func() (door.Movement, error) {
+			return coop.door.MoveTo(partial, positions[to])
+		}
+	case to == Opened:
+		return coop.door.Open
+	}
+
+	return coop.door.Close
+}
+
+// finish runs the door and moves the coop to the final status.
+func (coop *Coop) finish(by string, final Status, run func() (door.Movement, error)) error {
+	// Run the door
+	m, err := run()
+
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	ev := journal.Event{
+		By:          by,
+		Duration:    m.Duration,
+		EndReason:   string(m.EndReason),
+		PeakCurrent: m.PeakCurrent,
+	}
+
+	// The door has been blocked on its way, or it has not reached its end
+	if err == nil {
+		switch m.EndReason {
+		case door.Stall:
+			err = motor.ErrStall
+		case door.Obstructed:
+			err = door.ErrObstructed
+			go coop.notify(ObstructedMessage)
+		case door.Timeout:
+			err = motor.ErrTimeout
+			go coop.notify(fmt.Sprintf(TimeoutMessage, final))
+		case door.Failure:
+			err = door.ErrFailure
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("error while running the door to %s: %s", final, err)
+
+		// Update the status of the coop, the door has been left fully opened
+		// when it cannot be closed: it is held opened until the next closing
+		ev.Message = err.Error()
+		if m.Reversed {
+			coop.heldOpen = true
+			coop.transition(Opened, ev)
+		} else {
+			coop.transition(Unknown, ev)
+		}
+		coop.record(journal.Event{
+			Type:    journal.Error,
+			By:      by,
+			Message: err.Error(),
+		})
+
+		return err
+	}
+
+	// The door has been stopped on its way
+	if coop.stopRequested {
+		coop.transition(Unknown, ev)
+
+		return nil
 	}
 
 	// Update the status of the coop
-	coop.Status = Closed
+	coop.transition(final, ev)
 
 	return nil
 }
 
-// Close closes the chicken coop.
-func (coop *Coop) Stop() error {
-	switch coop.Status {
-	case Opening:
-	case Closing:
-			return coop.door.Stop()
+// Stop stops the door of the chicken coop if it is moving, on behalf of the
+// given user. The status of the coop becomes unknown since the door is
+// stopped in the middle.
+func (coop *Coop) Stop(by string) error {
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	switch coop.status {
+	case Opening, Closing:
+		logrus.WithFields(logrus.Fields{
+			"by": by,
+		}).Infoln("Stopping the door")
+		coop.stopRequested = true
+		return coop.door.Stop()
 	default:
 		logrus.Infoln("Nothing to be done for request: Stop Door")
 		return nil
 	}
-
-	return nil
 }
 
 // Check performs a check of the door of the chicken coop.
 func (coop *Coop) Check() {
+	// Get a consistent view of the coop
+	coop.mu.Lock()
+	status := coop.status
+	isAutomatic := coop.isAutomatic
+	openingCondition := coop.openingCondition
+	closingCondition := coop.closingCondition
+	partialCondition := coop.partialCondition
+	if coop.partialPosition <= 0 {
+		partialCondition = nil
+	}
+	heldOpen := coop.heldOpen
+	coop.mu.Unlock()
+
+	// Compare the status with the position of the door
+	if coop.reconcile(status, isAutomatic) {
+		return
+	}
+
 	// Check the automatic mode
-	if !coop.IsAutomatic {
+	if !isAutomatic {
 		logrus.WithFields(logrus.Fields{
-			"status": coop.Status,
+			"status": status,
 		}).Warningln("Automatic mode is disabled")
 		return
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"status":       coop.Status,
-		"opening_time": coop.OpeningCondition.OpeningTime(),
-		"closing_time": coop.ClosingCondition.ClosingTime(),
+		"status":       status,
+		"opening_time": openingCondition.OpeningTime(),
+		"closing_time": closingCondition.ClosingTime(),
 	}).Debugln("Checking the coop")
 
 	// Process the status
-	switch coop.Status {
+	switch status {
 	case Unknown:
 		logrus.Warningln("The status is unknown")
-		if coop.IsAutomatic {
-			logrus.Infoln("Since it's Automatic Mode, will try to mitigate unknown state...")
-			openDoorLimitPin := viper.GetInt("door.stoplimit.open_pin")
-			openlimitPin := rpio.Pin(openDoorLimitPin)
-			openlimitPin.Input()
-
-			closeDoorLimitPin := viper.GetInt("door.stoplimit.close_pin")
-			closelimitPin := rpio.Pin(closeDoorLimitPin)
-			closelimitPin.Input()
-
-			if openlimitPin.Read() == rpio.Low {
-				logrus.Infoln("Hit the Door Top Limit switch, so the Coop is in Opened State")
-				coop.Status = Opened
-			} else if closelimitPin.Read() == rpio.Low {
-				logrus.Infoln("Hit the Door Top Limit switch, so the Coop is in Closed State")
-				coop.Status = Closed
-			} else {
-				// If we get here then it means the door is somewhere stuck in the middle
-				// so let's close it to get to a good known state.
-				err := coop.close()
-				if err != nil {
-					logrus.Errorf("Error when closing the coop: %s", err)
-					return
-				}
-
-				logrus.Infoln("The Coop is in Closed State")
+		logrus.Infoln("Since it's Automatic Mode, will try to mitigate unknown state...")
+		state, err := coop.door.Sense()
+		if err != nil {
+			logrus.WithError(err).Warningln("Cannot sense the position of the door")
+		}
+
+		if state == door.StateOpened {
+			logrus.Infoln("Hit the Door Top Limit switch, so the Coop is in Opened State")
+			coop.mu.Lock()
+			coop.transition(Opened, journal.Event{By: Scheduler, EndReason: string(door.LimitSwitch)})
+			coop.mu.Unlock()
+		} else if state == door.StateClosed {
+			logrus.Infoln("Hit the Door Bottom Limit switch, so the Coop is in Closed State")
+			coop.mu.Lock()
+			coop.transition(Closed, journal.Event{By: Scheduler, EndReason: string(door.LimitSwitch)})
+			coop.mu.Unlock()
+		} else {
+			// If we get here then it means the door is somewhere stuck in the middle
+			// so let's close it to get to a good known state.
+			err := coop.close(Scheduler)
+			if err != nil {
+				logrus.Errorf("Error when closing the coop: %s", err)
+				return
 			}
+
+			logrus.Infoln("The Coop is in Closed State")
 		}
 	case Opening:
 		logrus.Infoln("The coop is opening")
 	case Closing:
 		logrus.Infoln("The coop is closing")
 	case Closed:
-		if coop.shouldBeOpened(time.Now()) {
+		if shouldBeOpened(time.Now(), openingCondition, closingCondition) {
 			logrus.WithFields(logrus.Fields{
-				"status":       coop.Status,
-				"opening_time": coop.OpeningCondition.OpeningTime(),
-				"closing_time": coop.ClosingCondition.ClosingTime(),
+				"status":       status,
+				"opening_time": openingCondition.OpeningTime(),
+				"closing_time": closingCondition.ClosingTime(),
 			}).Warnln("The coop should be opened")
 
 			// Open the coop
-			err := coop.open()
+			err := coop.open(Scheduler)
 			if err != nil {
 				logrus.Errorf("error while opening the coop: %s", err)
 				return
 			}
 
 			logrus.Infoln("The coop has been opened")
+		} else if shouldBePartial(time.Now(), openingCondition, closingCondition, partialCondition) {
+			coop.checkPartial(status, partialCondition)
+		}
+	case Partial:
+		if shouldBeOpened(time.Now(), openingCondition, closingCondition) {
+			logrus.WithFields(logrus.Fields{
+				"status":       status,
+				"opening_time": openingCondition.OpeningTime(),
+			}).Warnln("The coop should be opened")
+
+			// Open the coop
+			err := coop.open(Scheduler)
+			if err != nil {
+				logrus.Errorf("error while opening the coop: %s", err)
+				return
+			}
+
+			logrus.Infoln("The coop has been opened")
+		} else if !shouldBePartial(time.Now(), openingCondition, closingCondition, partialCondition) {
+			logrus.WithFields(logrus.Fields{
+				"status":       status,
+				"closing_time": closingCondition.ClosingTime(),
+			}).Warnln("The coop should be closed")
+
+			// Close the coop
+			err := coop.close(Scheduler)
+			if err != nil {
+				logrus.Errorf("Error when closing the coop: %s", err)
+				return
+			}
+
+			logrus.Infoln("The coop has been closed")
 		}
 	case Opened:
-		if coop.shouldBeClosed(time.Now()) {
+		if heldOpen && !coop.release(openingCondition, closingCondition) {
+			logrus.Warningln("The door could not be closed, it is held opened until the next closing")
+		} else if shouldBePartial(time.Now(), openingCondition, closingCondition, partialCondition) {
+			coop.checkPartial(status, partialCondition)
+		} else if shouldBeClosed(time.Now(), openingCondition, closingCondition) {
 			logrus.WithFields(logrus.Fields{
-				"status":       coop.Status,
-				"opening_time": coop.OpeningCondition.OpeningTime(),
-				"closing_time": coop.ClosingCondition.ClosingTime(),
+				"status":       status,
+				"opening_time": openingCondition.OpeningTime(),
+				"closing_time": closingCondition.ClosingTime(),
 			}).Warnln("The coop should be closed")
 
 			// Close the coop
-			err := coop.close()
+			err := coop.close(Scheduler)
 			if err != nil {
 				logrus.Errorf("Error when closing the coop: %s", err)
 				return
@@ -324,12 +826,44 @@ func (coop *Coop) Check() {
 			logrus.Infoln("The coop has been closed")
 		}
 	default:
-		logrus.Errorf("Wrong status for the coop : %s", coop.Status)
+		logrus.Errorf("Wrong status for the coop : %s", status)
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"status":       coop.Status,
-		"opening_time": coop.OpeningCondition.OpeningTime(),
-		"closing_time": coop.ClosingCondition.ClosingTime(),
+		"status":       coop.Status(),
+		"opening_time": openingCondition.OpeningTime(),
+		"closing_time": closingCondition.ClosingTime(),
 	}).Debugln("Coop has been checked")
 }
+
+// release stops holding the door opened once the coop should be opened
+// again, so that it is closed at the next closing. It returns true if the door
+// is not held anymore.
+func (coop *Coop) release(openingCondition, closingCondition conditions.Condition) bool {
+	if !shouldBeOpened(time.Now(), openingCondition, closingCondition) {
+		return false
+	}
+
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	coop.heldOpen = false
+
+	return true
+}
+
+// checkPartial moves the door to the partial position on behalf of the scheduler.
+func (coop *Coop) checkPartial(status Status, partialCondition conditions.Condition) {
+	logrus.WithFields(logrus.Fields{
+		"status":       status,
+		"partial_time": partialCondition.ClosingTime(),
+	}).Warnln("The coop should be partially opened")
+
+	err := coop.openPartially(Scheduler)
+	if err != nil {
+		logrus.Errorf("Error when partially opening the coop: %s", err)
+		return
+	}
+
+	logrus.Infoln("The coop has been partially opened")
+}
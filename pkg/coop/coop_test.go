@@ -0,0 +1,771 @@
+package coop
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
+	"github.com/fallais/gocoop/pkg/coop/conditions/timebased"
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeDoor is a door that fails the test if two movements overlap.
+type fakeDoor struct {
+	t        *testing.T
+	running  int32
+	moves    int32
+	stop     chan struct{}
+	duration time.Duration
+	state    door.State
+	reason   door.EndReason
+	reversed bool
+	encoder  bool
+
+	calibrated bool
+
+	mu      sync.Mutex
+	targets [][2]float64
+}
+
+func newFakeDoor(t *testing.T) *fakeDoor {
+	return &fakeDoor{
+		t:        t,
+		stop:     make(chan struct{}, 1),
+		duration: time.Millisecond,
+	}
+}
+
+func (d *fakeDoor) move() (door.Movement, error) {
+	if atomic.AddInt32(&d.running, 1) != 1 {
+		d.t.Errorf("the motor is driven twice at the same time")
+	}
+	defer atomic.AddInt32(&d.running, -1)
+	atomic.AddInt32(&d.moves, 1)
+
+	start := time.Now()
+	select {
+	case <-d.stop:
+		return door.Movement{Duration: time.Since(start), EndReason: door.Stopped}, nil
+	case <-time.After(d.duration):
+		if d.reason != "" {
+			return door.Movement{Duration: time.Since(start), EndReason: d.reason, Reversed: d.reversed}, nil
+		}
+		return door.Movement{Duration: time.Since(start), EndReason: door.LimitSwitch}, nil
+	}
+}
+
+func (d *fakeDoor) Open() (door.Movement, error)  { return d.move() }
+func (d *fakeDoor) Close() (door.Movement, error) { return d.move() }
+func (d *fakeDoor) MoveTo(from, to float64) (door.Movement, error) {
+	d.mu.Lock()
+	d.targets = append(d.targets, [2]float64{from, to})
+	d.mu.Unlock()
+	return d.move()
+}
+func (d *fakeDoor) Sense() (door.State, error) {
+	if d.state == "" {
+		return door.StateUnknown, door.ErrNoSensor
+	}
+	return d.state, nil
+}
+func (d *fakeDoor) Position() (float64, error) {
+	switch {
+	case !d.encoder:
+		return 0, door.ErrNoEncoder
+	case !d.calibrated:
+		return 0, door.ErrNotCalibrated
+	}
+	return 100, nil
+}
+func (d *fakeDoor) Calibrate() error {
+	if !d.encoder {
+		return door.ErrNoEncoder
+	}
+	d.calibrated = true
+	return nil
+}
+func (d *fakeDoor) Stop() error {
+	select {
+	case d.stop <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func newTestCoop(t *testing.T, d *fakeDoor, status Status, isAutomatic bool) *Coop {
+	c, err := New(latitude, longitude, nil, d, "time_based", "00h00", "time_based", "23h59", "", "", nil, nil, nil, isAutomatic, false)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	c.ticker.Stop()
+	c.status = status
+
+	return c
+}
+
+func conditionsForTest(t *testing.T) (conditions.Condition, conditions.Condition) {
+	oc, err := timebased.NewTimeBasedCondition("00h00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := timebased.NewTimeBasedCondition("23h59")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return oc, cc
+}
+
+func TestTransitions(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+
+	err := c.Close("test")
+	if !errors.Is(err, ErrCoopAlreadyClosed) {
+		t.Fatalf("should be ErrCoopAlreadyClosed, it is %v", err)
+	}
+
+	err = c.Open("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+
+	err = c.Open("test")
+	if !errors.Is(err, ErrCoopAlreadyOpened) {
+		t.Fatalf("should be ErrCoopAlreadyOpened, it is %v", err)
+	}
+
+	var te *TransitionError
+	if !errors.As(err, &te) || te.From != Opened || te.To != Opening {
+		t.Fatalf("should be a TransitionError from opened to opening, it is %v", err)
+	}
+
+	oc, cc := conditionsForTest(t)
+	err = c.Update("test", Unknown, false, oc, cc, nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	err = c.Open("test")
+	if !errors.Is(err, ErrStatusUnknown) {
+		t.Fatalf("should be ErrStatusUnknown, it is %v", err)
+	}
+
+	err = c.Update("test", "flying", false, oc, cc, nil)
+	if err != ErrIncorrectStatus {
+		t.Fatalf("should be ErrIncorrectStatus, it is %v", err)
+	}
+
+	err = c.Update("test", Closed, true, oc, cc, nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	err = c.Open("test")
+	if err != ErrAutomaticModeEnabled {
+		t.Fatalf("should be ErrAutomaticModeEnabled, it is %v", err)
+	}
+}
+
+func TestUpdateWhileMoving(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Opening, false)
+
+	oc, cc := conditionsForTest(t)
+	err := c.Update("test", Closed, false, oc, cc, nil)
+	if !errors.Is(err, ErrCoopAlreadyOpening) {
+		t.Fatalf("should be ErrCoopAlreadyOpening, it is %v", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	d := newFakeDoor(t)
+	d.duration = time.Minute
+	c := newTestCoop(t, d, Closed, false)
+
+	done := make(chan error)
+	go func() {
+		done <- c.Open("test")
+	}()
+
+	// Wait for the door to move
+	for c.Status() != Opening {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := c.Stop("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Status() != Unknown {
+		t.Fatalf("should be unknown, it is %s", c.Status())
+	}
+}
+
+func TestConcurrentManualUse(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+	oc, cc := conditionsForTest(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(5)
+		go func() {
+			defer wg.Done()
+			c.Open("test")
+		}()
+		go func() {
+			defer wg.Done()
+			c.Close("test")
+		}()
+		go func() {
+			defer wg.Done()
+			c.Stop("test")
+		}()
+		go func() {
+			defer wg.Done()
+			c.Check()
+		}()
+		go func(i int) {
+			defer wg.Done()
+			status := Opened
+			if i%2 == 0 {
+				status = Closed
+			}
+			c.Update("test", status, false, oc, cc, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	switch c.Status() {
+	case Opened, Closed, Unknown:
+	default:
+		t.Fatalf("the door should not be moving anymore, it is %s", c.Status())
+	}
+}
+
+func TestConcurrentChecks(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, true)
+	oc, cc := conditionsForTest(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Check()
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%10 == 0 {
+				c.Update("test", Closed, true, oc, cc, nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Status() != Opened && c.Status() != Closed {
+		t.Fatalf("should be opened or closed, it is %s", c.Status())
+	}
+	if atomic.LoadInt32(&d.moves) == 0 {
+		t.Fatalf("the door should have been opened at least once")
+	}
+}
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := journal.NewFileJournal(filepath.Join(dir, "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+	c.journal = j
+
+	err = c.Open("alice")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	events, total, err := c.Events(journal.Query{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if total != 2 {
+		t.Fatalf("should be 2 events, it is %d", total)
+	}
+
+	last := events[0]
+	if last.From != string(Opening) || last.To != string(Opened) {
+		t.Fatalf("should be from opening to opened, it is from %s to %s", last.From, last.To)
+	}
+	if last.By != "alice" {
+		t.Fatalf("should be triggered by alice, it is %s", last.By)
+	}
+	if last.EndReason != string(door.LimitSwitch) || last.Duration <= 0 {
+		t.Fatalf("movement is incorrect: %+v", last)
+	}
+}
+
+func TestNamedDoor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := journal.NewFileJournal(filepath.Join(dir, "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The doors share the journal
+	main := newTestCoop(t, newFakeDoor(t), Closed, false)
+	main.journal = j
+	gate := newTestCoop(t, newFakeDoor(t), Closed, false)
+	gate.journal = j
+	gate.SetName("run_gate")
+
+	err = main.Open("alice")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	err = gate.Open("bob")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// The main door lists every event
+	_, total, err := main.Events(journal.Query{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if total != 4 {
+		t.Fatalf("should be 4 events, it is %d", total)
+	}
+
+	// The named door lists its own events
+	events, total, err := gate.Events(journal.Query{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if total != 2 {
+		t.Fatalf("should be 2 events, it is %d", total)
+	}
+	for _, ev := range events {
+		if ev.Door != "run_gate" || ev.By != "bob" {
+			t.Fatalf("event should be from the run gate: %+v", ev)
+		}
+	}
+}
+
+func TestOpenAsync(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+
+	done, err := c.OpenAsync("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	_, err = c.OpenAsync("test")
+	if !errors.Is(err, ErrCoopAlreadyOpening) && !errors.Is(err, ErrCoopAlreadyOpened) {
+		t.Fatalf("should be rejected, it is %v", err)
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+	events := c.Subscribe()
+
+	err := c.Open("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	for _, to := range []Status{Opening, Opened} {
+		select {
+		case ev := <-events:
+			if ev.To != string(to) {
+				t.Fatalf("should be %s, it is %s", to, ev.To)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("should receive the transition to %s", to)
+		}
+	}
+}
+
+func TestCheckUnknown(t *testing.T) {
+	tests := map[door.State]Status{
+		door.StateOpened: Opened,
+		door.StateClosed: Closed,
+	}
+
+	for state, expected := range tests {
+		d := newFakeDoor(t)
+		d.state = state
+		c := newTestCoop(t, d, Unknown, true)
+
+		c.Check()
+		if c.Status() != expected {
+			t.Errorf("should be %s when the door is %s, it is %s", expected, state, c.Status())
+		}
+		if atomic.LoadInt32(&d.moves) != 0 {
+			t.Errorf("should not move the door when it is %s", state)
+		}
+	}
+}
+
+func TestCheckMismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	j, err := journal.NewFileJournal(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newFakeDoor(t)
+	d.state = door.StateBetween
+	c := newTestCoop(t, d, Closed, false)
+	c.journal = j
+	before := testutil.ToFloat64(positionMismatches.WithLabelValues(string(Closed)))
+
+	// The mismatch is raised once
+	c.Check()
+	c.Check()
+	if got := testutil.ToFloat64(positionMismatches.WithLabelValues(string(Closed))) - before; got != 1 {
+		t.Fatalf("should count one mismatch, it counted %v", got)
+	}
+	events, _, _ := j.List(journal.Query{})
+	mismatches := 0
+	for _, ev := range events {
+		if ev.Type == journal.Mismatch {
+			mismatches++
+		}
+	}
+	if mismatches != 1 {
+		t.Fatalf("should record one mismatch, it recorded %d", mismatches)
+	}
+	if c.Status() != Closed {
+		t.Fatalf("should keep the status, it is %s", c.Status())
+	}
+	if atomic.LoadInt32(&d.moves) != 0 {
+		t.Fatal("should not move the door in manual mode")
+	}
+
+	// It is raised again once the door has been back in position
+	d.state = door.StateClosed
+	c.Check()
+	d.state = door.StateOpened
+	c.Check()
+	events, _, _ = j.List(journal.Query{})
+	if events[0].Type != journal.Mismatch {
+		t.Fatalf("should record a new mismatch, the last event is %s", events[0].Type)
+	}
+}
+
+func TestCheckMismatchRedrive(t *testing.T) {
+	d := newFakeDoor(t)
+	d.state = door.StateBetween
+	c := newTestCoop(t, d, Opened, true)
+	c.SetRedrive(true)
+
+	events := c.Subscribe()
+	c.Check()
+	if atomic.LoadInt32(&d.moves) != 1 {
+		t.Fatalf("should re-drive the door once, it moved %d times", atomic.LoadInt32(&d.moves))
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+
+	expected := []journal.Type{journal.Mismatch, journal.Transition, journal.Transition}
+	for _, typ := range expected {
+		ev := <-events
+		if ev.Type != typ {
+			t.Fatalf("should receive a %s event, it is %s", typ, ev.Type)
+		}
+	}
+
+	// The door is still stuck, it is not re-driven again
+	c.Check()
+	if atomic.LoadInt32(&d.moves) != 1 {
+		t.Fatal("should not re-drive the door again")
+	}
+}
+
+func TestStall(t *testing.T) {
+	d := newFakeDoor(t)
+	d.reason = door.Stall
+	c := newTestCoop(t, d, Closed, false)
+
+	err := c.Open("test")
+	if err == nil || !strings.Contains(err.Error(), motor.ErrStall.Error()) {
+		t.Fatalf("should be a stall, it is %v", err)
+	}
+	if c.Status() != Unknown {
+		t.Fatalf("should be unknown, it is %s", c.Status())
+	}
+}
+
+// fakeNotifier sends the messages to its channel.
+type fakeNotifier chan string
+
+func (n fakeNotifier) Notify(message string) error {
+	n <- message
+	return nil
+}
+func (n fakeNotifier) Type() string   { return "fake" }
+func (n fakeNotifier) Vendor() string { return "fake" }
+
+// failingNotifier always fails.
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(message string) error { return errors.New("unreachable") }
+func (failingNotifier) Type() string                { return "fake" }
+func (failingNotifier) Vendor() string              { return "failing" }
+
+func TestNotifierFailure(t *testing.T) {
+	c := newTestCoop(t, newFakeDoor(t), Opened, false)
+	c.notifiers = append(c.notifiers, failingNotifier{}, make(fakeNotifier, 1))
+
+	c.notify("test")
+	if got := testutil.ToFloat64(notifierFailures.WithLabelValues("failing")); got != 1 {
+		t.Fatalf("should count one failure, it counted %v", got)
+	}
+	if got := testutil.ToFloat64(notifierFailures.WithLabelValues("fake")); got != 0 {
+		t.Fatalf("should not count the successful notifications, it counted %v", got)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	d := newFakeDoor(t)
+	d.reason = door.Failure
+	c := newTestCoop(t, d, Opened, false)
+
+	err := c.Close("test")
+	if err == nil || !strings.Contains(err.Error(), door.ErrFailure.Error()) {
+		t.Fatalf("should be a failure, it is %v", err)
+	}
+	if c.Status() != Unknown {
+		t.Fatalf("should be unknown, it is %s", c.Status())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	j, err := journal.NewFileJournal(filepath.Join(dir, "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newFakeDoor(t)
+	d.reason = door.Timeout
+	c := newTestCoop(t, d, Opened, false)
+	c.journal = j
+	notifier := make(fakeNotifier, 1)
+	c.notifiers = append(c.notifiers, notifier)
+
+	err = c.Close("test")
+	if err == nil || !strings.Contains(err.Error(), motor.ErrTimeout.Error()) {
+		t.Fatalf("should be a timeout, it is %v", err)
+	}
+	if c.Status() != Unknown {
+		t.Fatalf("should be unknown, it is %s", c.Status())
+	}
+
+	select {
+	case message := <-notifier:
+		if message != fmt.Sprintf(TimeoutMessage, Closed) {
+			t.Fatalf("message is incorrect: %s", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("should notify the timeout")
+	}
+
+	events, _, _ := j.List(journal.Query{})
+	var errs []journal.Event
+	for _, ev := range events {
+		if ev.Type == journal.Error {
+			errs = append(errs, ev)
+		}
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, motor.ErrTimeout.Error()) {
+		t.Fatalf("should record the timeout as an error: %v", events)
+	}
+}
+
+func TestObstructedHeldOpen(t *testing.T) {
+	d := newFakeDoor(t)
+	d.reason = door.Obstructed
+	d.reversed = true
+	c := newTestCoop(t, d, Opened, false)
+
+	// The safety has given up and left the door opened
+	err := c.Close("test")
+	if err == nil || !strings.Contains(err.Error(), door.ErrObstructed.Error()) {
+		t.Fatalf("should be obstructed, it is %v", err)
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+
+	// The scheduler does not close the door again during the same night
+	night, err := timebased.NewTimeBasedCondition("00h00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oc, cc := conditionsForTest(t)
+	c.isAutomatic = true
+	c.closingCondition = night
+	c.Check()
+	c.Check()
+	if atomic.LoadInt32(&d.moves) != 1 {
+		t.Fatalf("should not close the door again, it moved %d times", atomic.LoadInt32(&d.moves))
+	}
+
+	// The door is closed at the next closing
+	c.closingCondition = cc
+	c.Check()
+	c.closingCondition = night
+	c.Check()
+	if atomic.LoadInt32(&d.moves) != 2 {
+		t.Fatalf("should close the door at the next closing, it moved %d times", atomic.LoadInt32(&d.moves))
+	}
+
+	// A manual command releases the door too
+	err = c.Update("test", Opened, false, oc, cc, nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.heldOpen {
+		t.Fatal("should not hold the door opened after a manual command")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Opened, false)
+
+	err := c.Calibrate("test")
+	if err != door.ErrNoEncoder {
+		t.Fatalf("should be ErrNoEncoder, it is %v", err)
+	}
+
+	d.encoder = true
+	err = c.Calibrate("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// The door has been closed then opened
+	if moves := atomic.LoadInt32(&d.moves); moves != 2 {
+		t.Fatalf("door should have moved twice, it has moved %d times", moves)
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+	position, err := c.Position()
+	if err != nil || position != 100 {
+		t.Fatalf("position should be 100, it is %f (%v)", position, err)
+	}
+
+	c.isAutomatic = true
+	_, err = c.CalibrateAsync("test")
+	if err != ErrAutomaticModeEnabled {
+		t.Fatalf("should be ErrAutomaticModeEnabled, it is %v", err)
+	}
+}
+
+func TestOpenPartially(t *testing.T) {
+	d := newFakeDoor(t)
+	c := newTestCoop(t, d, Closed, false)
+
+	err := c.OpenPartially("test")
+	if !errors.Is(err, ErrNoPartialPosition) {
+		t.Fatalf("should be ErrNoPartialPosition, it is %v", err)
+	}
+
+	c.SetPartialPosition(30)
+	err = c.OpenPartially("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Status() != Partial {
+		t.Fatalf("should be partial, it is %s", c.Status())
+	}
+
+	err = c.OpenPartially("test")
+	if !errors.Is(err, ErrCoopAlreadyPartial) {
+		t.Fatalf("should be ErrCoopAlreadyPartial, it is %v", err)
+	}
+
+	// The door leaves the partial position from where it is
+	err = c.Open("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if c.Status() != Opened {
+		t.Fatalf("should be opened, it is %s", c.Status())
+	}
+
+	err = c.OpenPartially("test")
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	expected := [][2]float64{{0, 30}, {30, 100}, {100, 30}}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.targets) != len(expected) {
+		t.Fatalf("door should have moved %d times to a position, it has moved %d times", len(expected), len(d.targets))
+	}
+	for i, target := range expected {
+		if d.targets[i] != target {
+			t.Fatalf("movement %d should be from %.0f to %.0f, it is from %.0f to %.0f", i, target[0], target[1], d.targets[i][0], d.targets[i][1])
+		}
+	}
+}
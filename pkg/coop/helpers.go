@@ -2,17 +2,19 @@ package coop
 
 import (
 	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/conditions"
 )
 
-func (coop *Coop) shouldBeClosed(date time.Time) bool {
+func shouldBeClosed(date time.Time, openingCondition, closingCondition conditions.Condition) bool {
 	// Check if the date if before the opening time (it is the morning)
-	openingTime := coop.OpeningCondition.OpeningTime()
+	openingTime := openingCondition.OpeningTime()
 	if date.Before(openingTime) {
 		return true
 	}
 
 	// Check if the date is after the closing time (it is the evening)
-	closingTime := coop.ClosingCondition.ClosingTime()
+	closingTime := closingCondition.ClosingTime()
 	if date.After(closingTime) {
 		return true
 	}
@@ -20,15 +22,40 @@ func (coop *Coop) shouldBeClosed(date time.Time) bool {
 	return false
 }
 
-func (coop *Coop) shouldBeOpened(date time.Time) bool {
+// shouldBePartial returns true if the door should be at the partial position:
+// during the night, from the time of the partial condition until the opening time.
+func shouldBePartial(date time.Time, openingCondition, closingCondition, partialCondition conditions.Condition) bool {
+	if partialCondition == nil || !shouldBeClosed(date, openingCondition, closingCondition) {
+		return false
+	}
+
+	partialTime := partialCondition.ClosingTime()
+	openingTime := openingCondition.OpeningTime()
+	closingTime := closingCondition.ClosingTime()
+
+	// The partial time is in the evening, it lasts until the next morning
+	if partialTime.After(closingTime) {
+		return !date.Before(partialTime) || date.Before(openingTime)
+	}
+
+	// The partial time is in the early morning
+	if partialTime.Before(openingTime) {
+		return !date.Before(partialTime) && date.Before(openingTime)
+	}
+
+	// The partial time is during the day, when the coop is opened
+	return false
+}
+
+func shouldBeOpened(date time.Time, openingCondition, closingCondition conditions.Condition) bool {
 	// Check if the date is before the opening time
-	openingTime := coop.OpeningCondition.OpeningTime()
+	openingTime := openingCondition.OpeningTime()
 	if date.Before(openingTime) {
 		return false
 	}
 
 	// Check if the date is after the closing time
-	closingTime := coop.ClosingCondition.ClosingTime()
+	closingTime := closingCondition.ClosingTime()
 	if date.After(closingTime) {
 		return false
 	}
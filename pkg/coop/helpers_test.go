@@ -14,24 +14,17 @@ func TestShouldBeClosed(t *testing.T) {
 	openingCondition, _ := timebased.NewTimeBasedCondition("08h30")
 	closingCondition, _ := timebased.NewTimeBasedCondition("18h30")
 
-	c := &Coop{
-		OpeningCondition: openingCondition,
-		ClosingCondition: closingCondition,
-		Latitude:         latitude,
-		Longitude:        longitude,
-	}
-
-	if c.shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 9, 0, 0, 0, time.Local)) {
+	if shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 9, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should not be closed")
 		t.Fail()
 	}
 
-	if !c.shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 8, 0, 0, 0, time.Local)) {
+	if !shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 8, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should be closed")
 		t.Fail()
 	}
 
-	if !c.shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 19, 0, 0, 0, time.Local)) {
+	if !shouldBeClosed(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 19, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should be closed")
 		t.Fail()
 	}
@@ -41,25 +34,59 @@ func TestShouldBeOpened(t *testing.T) {
 	openingCondition, _ := timebased.NewTimeBasedCondition("08h30")
 	closingCondition, _ := timebased.NewTimeBasedCondition("18h30")
 
-	c := &Coop{
-		OpeningCondition: openingCondition,
-		ClosingCondition: closingCondition,
-		Latitude:         latitude,
-		Longitude:        longitude,
-	}
-
-	if !c.shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 14, 0, 0, 0, time.Local)) {
+	if !shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 14, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should be opened")
 		t.Fail()
 	}
 
-	if c.shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 8, 0, 0, 0, time.Local)) {
+	if shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 8, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should not be opened")
 		t.Fail()
 	}
 
-	if c.shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 19, 0, 0, 0, time.Local)) {
+	if shouldBeOpened(time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 19, 0, 0, 0, time.Local), openingCondition, closingCondition) {
 		t.Errorf("Should not be opened")
 		t.Fail()
 	}
 }
+
+func TestShouldBePartial(t *testing.T) {
+	openingCondition, _ := timebased.NewTimeBasedCondition("08h30")
+	closingCondition, _ := timebased.NewTimeBasedCondition("18h30")
+	at := func(hour, min int) time.Time {
+		return time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), hour, min, 0, 0, time.Local)
+	}
+
+	if shouldBePartial(at(22, 0), openingCondition, closingCondition, nil) {
+		t.Errorf("Should not be partial without a partial condition")
+	}
+
+	// In the evening, until the next morning
+	evening, _ := timebased.NewTimeBasedCondition("21h00")
+	tests := map[time.Time]bool{
+		at(14, 0): false,
+		at(19, 0): false,
+		at(22, 0): true,
+		at(5, 0):  true,
+		at(9, 0):  false,
+	}
+	for date, expected := range tests {
+		if shouldBePartial(date, openingCondition, closingCondition, evening) != expected {
+			t.Errorf("Should be partial at %s: %t", date.Format("15h04"), expected)
+		}
+	}
+
+	// In the early morning, until the opening
+	morning, _ := timebased.NewTimeBasedCondition("06h00")
+	tests = map[time.Time]bool{
+		at(22, 0): false,
+		at(5, 0):  false,
+		at(7, 0):  true,
+		at(9, 0):  false,
+	}
+	for date, expected := range tests {
+		if shouldBePartial(date, openingCondition, closingCondition, morning) != expected {
+			t.Errorf("Should be partial at %s: %t", date.Format("15h04"), expected)
+		}
+	}
+}
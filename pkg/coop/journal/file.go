@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// fileJournal stores the events as JSON lines in a file. The events are also
+// kept in memory, so that the file is only read at startup.
+type fileJournal struct {
+	path   string
+	lastID int64
+	events []Event
+	sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewFileJournal returns a new Journal backed by the given file.
+func NewFileJournal(path string) (Journal, error) {
+	j := &fileJournal{
+		path: path,
+	}
+
+	// Remove the torn line of a write interrupted by a power cut, otherwise
+	// the next event would be appended to it
+	err := j.repair()
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the events and find the last ID
+	j.events, err = j.read()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range j.events {
+		if e.ID > j.lastID {
+			j.lastID = e.ID
+		}
+	}
+
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Append adds an event at the end of the file.
+func (j *fileJournal) Append(e Event) error {
+	j.Lock()
+	defer j.Unlock()
+
+	if e.ID == 0 {
+		e.ID = j.lastID + 1
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error while encoding the event: %s", err)
+	}
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("error while opening the journal: %s", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("error while writing the event: %s", err)
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("error while syncing the journal: %s", err)
+	}
+
+	j.lastID = e.ID
+	j.events = append(j.events, e)
+
+	return nil
+}
+
+// List returns the events matching the query, newest first.
+func (j *fileJournal) List(q Query) ([]Event, int, error) {
+	j.Lock()
+	defer j.Unlock()
+
+	// Filter, newest first
+	var matching []Event
+	for i := len(j.events) - 1; i >= 0; i-- {
+		if q.Match(j.events[i]) {
+			matching = append(matching, j.events[i])
+		}
+	}
+	total := len(matching)
+
+	// Page
+	if q.Offset >= total {
+		return []Event{}, total, nil
+	}
+	matching = matching[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(matching) {
+		matching = matching[:q.Limit]
+	}
+
+	return matching, total, nil
+}
+
+// repair truncates the file after its last complete line.
+func (j *fileJournal) repair() error {
+	data, err := ioutil.ReadFile(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("error while reading the journal: %s", err)
+	}
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return nil
+	}
+
+	logrus.Warningln("Removing the torn last line of the journal")
+
+	err = os.Truncate(j.path, int64(bytes.LastIndexByte(data, '\n')+1))
+	if err != nil {
+		return fmt.Errorf("error while truncating the journal: %s", err)
+	}
+
+	return nil
+}
+
+// read reads all the events of the file. Lines that cannot be decoded, for
+// example after a power cut during a write, are skipped.
+func (j *fileJournal) read() ([]Event, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error while opening the journal: %s", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			logrus.WithError(err).Warningln("Skipping a corrupted line of the journal")
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the journal: %s", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,105 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "journal.jsonl")
+	j, err := NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	start := time.Date(2023, 5, 1, 8, 0, 0, 0, time.Local)
+	for i := 0; i < 10; i++ {
+		err = j.Append(Event{
+			Time: start.Add(time.Duration(i) * time.Hour),
+			Type: Transition,
+			From: "closed",
+			To:   "opening",
+			By:   "scheduler",
+		})
+		if err != nil {
+			t.Fatalf("should not error: %s", err)
+		}
+	}
+
+	events, total, err := j.List(Query{Limit: 3})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if total != 10 {
+		t.Fatalf("should be 10, it is %d", total)
+	}
+	if len(events) != 3 {
+		t.Fatalf("should be 3, it is %d", len(events))
+	}
+	if events[0].ID != 10 {
+		t.Fatalf("newest event should be first, it is %d", events[0].ID)
+	}
+
+	events, total, _ = j.List(Query{From: start.Add(2 * time.Hour), To: start.Add(5 * time.Hour), Offset: 1})
+	if total != 3 {
+		t.Fatalf("should be 3, it is %d", total)
+	}
+	if len(events) != 2 || events[0].ID != 4 {
+		t.Fatalf("page is incorrect: %+v", events)
+	}
+
+	// IDs continue after a restart, corrupted lines are skipped
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0640)
+	f.WriteString(`{"id": 11, "ty`)
+	f.WriteString("\n")
+	f.Close()
+
+	j, err = NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	j.Append(Event{Type: Error, Message: "boom"})
+	events, total, _ = j.List(Query{Limit: 1})
+	if total != 11 || events[0].ID != 11 {
+		t.Fatalf("should be 11 events with last ID 11, got %d events and ID %d", total, events[0].ID)
+	}
+}
+
+func TestFileJournalTornLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "journal.jsonl")
+	j, _ := NewFileJournal(path)
+	j.Append(Event{Type: Error, Message: "first"})
+
+	// A power cut during a write leaves a line without newline
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0640)
+	f.WriteString(`{"id": 2, "ty`)
+	f.Close()
+
+	j, err = NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	j.Append(Event{Type: Error, Message: "second"})
+
+	// The next event is not glued to the torn line
+	j, _ = NewFileJournal(path)
+	events, total, _ := j.List(Query{})
+	if total != 2 || events[0].Message != "second" || events[0].ID != 2 {
+		t.Fatalf("events are incorrect: %+v", events)
+	}
+}
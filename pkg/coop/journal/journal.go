@@ -0,0 +1,83 @@
+package journal
+
+import (
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// Transition is when the status of the coop changes.
+	Transition Type = "transition"
+
+	// Configuration is when the coop is updated by a user.
+	Configuration Type = "configuration"
+
+	// Error is when an error occurs.
+	Error Type = "error"
+
+	// Mismatch is when the position of the door given by the limit switches
+	// does not match the status of the coop.
+	Mismatch Type = "mismatch"
+)
+
+// Event is an entry of the journal.
+type Event struct {
+	ID          int64         `json:"id"`
+	Time        time.Time     `json:"time"`
+	Type        Type          `json:"type"`
+	Door        string        `json:"door,omitempty"`
+	From        string        `json:"from,omitempty"`
+	To          string        `json:"to,omitempty"`
+	By          string        `json:"by,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	EndReason   string        `json:"end_reason,omitempty"`
+	PeakCurrent float64       `json:"peak_current,omitempty"`
+	Message     string        `json:"message,omitempty"`
+}
+
+// Query filters the events of the journal.
+type Query struct {
+	From   time.Time
+	To     time.Time
+	Door   string
+	Offset int
+	Limit  int
+}
+
+// Journal is an append-only journal of events.
+type Journal interface {
+	// Append adds an event to the journal. The ID and the time are set if
+	// they are empty.
+	Append(Event) error
+
+	// List returns the events matching the query, newest first, and the
+	// total number of matching events.
+	List(Query) ([]Event, int, error)
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Match returns true if the event matches the dates and the door of the query.
+func (q Query) Match(e Event) bool {
+	if q.Door != "" && e.Door != q.Door {
+		return false
+	}
+
+	if !q.From.IsZero() && e.Time.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && !e.Time.Before(q.To) {
+		return false
+	}
+
+	return true
+}
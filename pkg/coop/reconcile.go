@@ -0,0 +1,138 @@
+package coop
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/fallais/gocoop/pkg/door"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+// positionMismatches counts the mismatches between the status and the position of the door.
+var positionMismatches = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gocoop_door_position_mismatches_total",
+	Help: "Number of times the position of the door did not match the status of the coop.",
+}, []string{"status"})
+
+// expectedStates are the positions of the door given by the limit switches for the statuses that can be checked.
+var expectedStates = map[Status]door.State{
+	Opened:  door.StateOpened,
+	Closed:  door.StateClosed,
+	Partial: door.StateBetween,
+}
+
+// reconcile compares the status with the position of the door given by the
+// limit switches. A mismatch is recorded and notified once, until the door is
+// back where it should be. It returns true if the door has been re-driven.
+func (coop *Coop) reconcile(status Status, isAutomatic bool) bool {
+	expected, ok := expectedStates[status]
+	if !ok {
+		// The door is moving or its position is unknown
+		return false
+	}
+
+	state, err := coop.door.Sense()
+	if errors.Is(err, door.ErrNoSensor) {
+		return false
+	}
+	if err != nil {
+		logrus.WithError(err).Warningln("Cannot sense the position of the door")
+		return false
+	}
+
+	coop.mu.Lock()
+
+	// The door may have been moved while sensing it
+	if coop.status != status {
+		coop.mu.Unlock()
+		return false
+	}
+
+	// The position matches the status
+	if state == expected {
+		if coop.mismatch {
+			logrus.WithFields(logrus.Fields{
+				"status": status,
+			}).Infoln("The position of the door matches the status again")
+		}
+		coop.mismatch = false
+		coop.mu.Unlock()
+		return false
+	}
+
+	// The mismatch has already been raised
+	if coop.mismatch {
+		coop.mu.Unlock()
+		return false
+	}
+	coop.mismatch = true
+	redrive := coop.redrive && isAutomatic
+
+	logrus.WithFields(logrus.Fields{
+		"status":   status,
+		"position": state,
+		"redrive":  redrive,
+	}).Warningln("The position of the door does not match the status")
+	positionMismatches.WithLabelValues(string(status)).Inc()
+	coop.record(journal.Event{
+		Type:    journal.Mismatch,
+		By:      Scheduler,
+		Message: fmt.Sprintf("door position mismatch: the coop is %s but the door is %s", status, state),
+	})
+	coop.mu.Unlock()
+
+	go coop.notify(fmt.Sprintf(MismatchMessage, state, status))
+
+	if !redrive {
+		return false
+	}
+
+	err = coop.redriveTo(status, state)
+	if err != nil {
+		logrus.WithError(err).Errorln("Error while re-driving the door")
+	}
+
+	return true
+}
+
+// redriveTo runs the door from the sensed state to the position of the given
+// status, which is the current status of the coop. The transition table is
+// bypassed since the coop is already in the final status.
+func (coop *Coop) redriveTo(status Status, state door.State) error {
+	coop.mu.Lock()
+	if coop.status != status {
+		coop.mu.Unlock()
+		return nil
+	}
+
+	// The door is at a limit switch when it should be at the partial position
+	moving := Opening
+	from := Closed
+	switch {
+	case status == Closed:
+		moving = Closing
+	case status == Partial && state == door.StateOpened:
+		moving, from = Closing, Opened
+	}
+	run := coop.runner(from, status)
+
+	logrus.WithFields(logrus.Fields{
+		"status": status,
+	}).Infoln("Re-driving the door to its expected position")
+	coop.record(journal.Event{
+		Type:    journal.Transition,
+		From:    string(status),
+		To:      string(moving),
+		By:      Scheduler,
+		Message: "re-driving the door after a position mismatch",
+	})
+	coop.setStatus(moving)
+	coop.stopRequested = false
+	coop.mu.Unlock()
+
+	return coop.finish(Scheduler, status, run)
+}
@@ -0,0 +1,135 @@
+package coop
+
+import (
+	"time"
+
+	"github.com/fallais/gocoop/pkg/coop/journal"
+	"github.com/fallais/gocoop/pkg/coop/state"
+
+	"github.com/sirupsen/logrus"
+)
+
+// setStatus updates the status of the coop and saves the state.
+// The lock must be held by the caller.
+func (coop *Coop) setStatus(status Status) {
+	if coop.status != status {
+		coop.lastTransition = time.Now()
+	}
+	coop.status = status
+
+	err := coop.save()
+	if err != nil {
+		logrus.WithError(err).Errorln("Error while saving the state of the coop")
+	}
+}
+
+// record adds the event to the journal and sends it to the subscribers.
+func (coop *Coop) record(ev journal.Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ev.Door = coop.Name()
+
+	if coop.journal != nil {
+		err := coop.journal.Append(ev)
+		if err != nil {
+			logrus.WithError(err).Errorln("Error while recording the event into the journal")
+		}
+	}
+
+	coop.subMu.Lock()
+	defer coop.subMu.Unlock()
+
+	for _, ch := range coop.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			logrus.Warningln("A subscriber is too slow, dropping the event")
+		}
+	}
+}
+
+// save saves the current state of the coop into the store.
+// The lock must be held by the caller.
+func (coop *Coop) save() error {
+	if coop.store == nil {
+		return nil
+	}
+
+	st := &state.State{
+		Status:      string(coop.status),
+		IsAutomatic: coop.isAutomatic,
+		OpeningCondition: state.Condition{
+			Mode:  coop.openingCondition.Mode(),
+			Value: coop.openingCondition.Value(),
+		},
+		ClosingCondition: state.Condition{
+			Mode:  coop.closingCondition.Mode(),
+			Value: coop.closingCondition.Value(),
+		},
+		LastTransition:   coop.lastTransition,
+		PartialCondition: &state.Condition{},
+	}
+	if coop.partialCondition != nil {
+		st.PartialCondition.Mode = coop.partialCondition.Mode()
+		st.PartialCondition.Value = coop.partialCondition.Value()
+	}
+
+	return coop.store.Save(st)
+}
+
+// restore restores the state saved in the store. The configuration values are
+// kept for everything that cannot be restored.
+func (coop *Coop) restore() {
+	st, err := coop.store.Load()
+	if err == state.ErrNoState {
+		logrus.Infoln("No previous state to restore")
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Warningln("Error while loading the previous state, using the configuration")
+		return
+	}
+
+	coop.mu.Lock()
+	defer coop.mu.Unlock()
+
+	// Restore the status
+	switch Status(st.Status) {
+	case Opened, Closed, Partial:
+		coop.status = Status(st.Status)
+	default:
+		// The door was moving or unknown when the state was saved
+		coop.status = Unknown
+	}
+	coop.isAutomatic = st.IsAutomatic
+	coop.lastTransition = st.LastTransition
+
+	// Restore the conditions
+	oc, err := NewCondition(st.OpeningCondition.Mode, st.OpeningCondition.Value, coop.Latitude, coop.Longitude, coop.Light)
+	if err != nil {
+		logrus.WithError(err).Warningln("Error while restoring the opening condition, using the configuration")
+	} else {
+		coop.openingCondition = oc
+	}
+	cc, err := NewCondition(st.ClosingCondition.Mode, st.ClosingCondition.Value, coop.Latitude, coop.Longitude, coop.Light)
+	if err != nil {
+		logrus.WithError(err).Warningln("Error while restoring the closing condition, using the configuration")
+	} else {
+		coop.closingCondition = cc
+	}
+	if st.PartialCondition != nil {
+		pc, err := NewPartialCondition(st.PartialCondition.Mode, st.PartialCondition.Value, coop.Latitude, coop.Longitude, coop.Light)
+		if err != nil {
+			logrus.WithError(err).Warningln("Error while restoring the partial condition, using the configuration")
+		} else {
+			coop.partialCondition = pc
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"status":          coop.status,
+		"is_automatic":    coop.isAutomatic,
+		"last_transition": coop.lastTransition,
+	}).Infoln("Previous state has been restored")
+}
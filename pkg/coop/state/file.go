@@ -0,0 +1,110 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// fileStore stores the state as JSON in a file.
+type fileStore struct {
+	path string
+	sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewFileStore returns a new Store backed by the given file.
+func NewFileStore(path string) Store {
+	return &fileStore{
+		path: path,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Load reads the state from the file.
+func (s *fileStore) Load() (*State, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoState
+		}
+
+		return nil, fmt.Errorf("error while reading the state file: %s", err)
+	}
+
+	var st State
+	err = json.Unmarshal(data, &st)
+	if err != nil {
+		return nil, fmt.Errorf("state file is corrupted: %s", err)
+	}
+
+	return &st, nil
+}
+
+// Save writes the state to a temporary file, flushes it to the disk and then
+// renames it over the previous one, so that a power cut never leaves a
+// partially written state behind.
+func (s *fileStore) Save(st *State) error {
+	s.Lock()
+	defer s.Unlock()
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while encoding the state: %s", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error while creating the temporary state file: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error while writing the temporary state file: %s", err)
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error while syncing the temporary state file: %s", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("error while closing the temporary state file: %s", err)
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+	if err != nil {
+		return fmt.Errorf("error while replacing the state file: %s", err)
+	}
+
+	// Sync the directory so that the rename itself is durable
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	d.Sync()
+
+	return nil
+}
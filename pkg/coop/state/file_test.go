@@ -0,0 +1,71 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewFileStore(filepath.Join(dir, "state.json"))
+
+	_, err = s.Load()
+	if err != ErrNoState {
+		t.Fatalf("should be ErrNoState, it is %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	err = s.Save(&State{
+		Status:           "closed",
+		IsAutomatic:      true,
+		OpeningCondition: Condition{Mode: "time_based", Value: "08h00"},
+		ClosingCondition: Condition{Mode: "sun_based", Value: "30m0s"},
+		LastTransition:   now,
+	})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if st.Status != "closed" || !st.IsAutomatic {
+		t.Fatalf("state is incorrect: %+v", st)
+	}
+	if st.ClosingCondition.Value != "30m0s" {
+		t.Fatalf("should be 30m0s, it is %s", st.ClosingCondition.Value)
+	}
+	if !st.LastTransition.Equal(now) {
+		t.Fatalf("should be %s, it is %s", now, st.LastTransition)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("temporary files should be removed, got %d files", len(files))
+	}
+}
+
+func TestFileStoreCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocoop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+	ioutil.WriteFile(path, []byte(`{"status": "clo`), 0600)
+
+	_, err = NewFileStore(path).Load()
+	if err == nil || err == ErrNoState {
+		t.Fatalf("should raise a corruption error, it is %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package state
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoState is raised when no state has been saved yet.
+var ErrNoState = errors.New("no state has been saved yet")
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Condition is a persisted opening or closing condition.
+type Condition struct {
+	Mode  string `json:"mode"`
+	Value string `json:"value"`
+}
+
+// State is the persisted state of the coop. The partial condition is nil in
+// the states saved before it existed, its mode is empty when it is disabled.
+type State struct {
+	Status           string     `json:"status"`
+	IsAutomatic      bool       `json:"is_automatic"`
+	OpeningCondition Condition  `json:"opening_condition"`
+	ClosingCondition Condition  `json:"closing_condition"`
+	PartialCondition *Condition `json:"partial_condition,omitempty"`
+	LastTransition   time.Time  `json:"last_transition"`
+}
+
+// Store loads and saves the state of the coop.
+type Store interface {
+	Load() (*State, error)
+	Save(*State) error
+}
@@ -16,6 +16,10 @@ const (
 	// Closing when the coop is closing.
 	Closing Status = "closing"
 
+	// Partial when the door is stopped at the partial position, for example
+	// to let the air in while keeping the predators out.
+	Partial Status = "partial"
+
 	// Unknown when it is unknown.
 	Unknown Status = "unknown"
 )
@@ -0,0 +1,87 @@
+package coop
+
+import (
+	"fmt"
+
+	"github.com/fallais/gocoop/pkg/coop/journal"
+)
+
+// transitions is the table of the allowed transitions between statuses.
+var transitions = map[Status][]Status{
+	Unknown: {Opened, Closed, Closing, Partial},
+	Opened:  {Closing, Closed, Partial, Unknown},
+	Closed:  {Opening, Opened, Partial, Unknown},
+	Opening: {Opened, Partial, Unknown},
+	Closing: {Closed, Opened, Partial, Unknown},
+	Partial: {Opening, Closing, Opened, Closed, Unknown},
+}
+
+// TransitionError is raised when a transition is rejected by the state machine.
+type TransitionError struct {
+	From Status
+	To   Status
+	Err  error
+}
+
+// Error returns the message of the error.
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot go from %s to %s: %s", e.From, e.To, e.Err)
+}
+
+// Unwrap returns the reason of the rejection.
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// isAllowed returns true if the transition is in the transition table.
+func isAllowed(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rejection returns the reason why the transition is rejected.
+func rejection(from, to Status) error {
+	switch {
+	case from == Opening:
+		return ErrCoopAlreadyOpening
+	case from == Closing:
+		return ErrCoopAlreadyClosing
+	case from == Unknown && (to == Opening || to == Partial):
+		return ErrStatusUnknown
+	case from == Opened && to == Opening:
+		return ErrCoopAlreadyOpened
+	case from == Closed && to == Closing:
+		return ErrCoopAlreadyClosed
+	case from == Partial && to == Partial:
+		return ErrCoopAlreadyPartial
+	}
+
+	return ErrTransitionNotAllowed
+}
+
+// transition moves the coop to the given status if it is allowed, and records
+// it into the journal with the details of the given event.
+// The lock must be held by the caller.
+func (coop *Coop) transition(to Status, ev journal.Event) error {
+	if !isAllowed(coop.status, to) {
+		return &TransitionError{
+			From: coop.status,
+			To:   to,
+			Err:  rejection(coop.status, to),
+		}
+	}
+
+	ev.Type = journal.Transition
+	ev.From = string(coop.status)
+	ev.To = string(to)
+	coop.record(ev)
+
+	coop.setStatus(to)
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package currentsense
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc"
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultInterval is the default interval between two readings of the current.
+const DefaultInterval = 20 * time.Millisecond
+
+// DefaultInrush is the default time after the start of the motor during which
+// the current is not checked, the motor draws much more current to start.
+const DefaultInrush = 300 * time.Millisecond
+
+// DefaultIdleTime is the default time the current must stay below the idle
+// current for the motor to be considered idle.
+const DefaultIdleTime = 100 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a current sensor.
+type Settings struct {
+	// AmpsPerVolt converts the voltage read by the converter into a current.
+	AmpsPerVolt float64
+
+	// StallCurrent is the current, in amperes, above which the motor is
+	// considered stalled. 0 disables the detection.
+	StallCurrent float64
+
+	// IdleCurrent is the current, in amperes, below which the motor is
+	// considered cut by its internal end-stop. 0 disables the detection.
+	IdleCurrent float64
+
+	// IdleTime is the time the current must stay below the idle current.
+	IdleTime time.Duration
+
+	// Inrush is the time after the start during which the current is not checked.
+	Inrush time.Duration
+
+	// Interval is the interval between two readings.
+	Interval time.Duration
+}
+
+// Sensor measures the current of a motor on a channel of a converter.
+type Sensor struct {
+	adc      adc.ADC
+	channel  int
+	settings Settings
+
+	mu   sync.Mutex
+	peak float64
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// New returns a new Sensor on the channel of the converter.
+func New(a adc.ADC, channel int, settings Settings) *Sensor {
+	if settings.Interval <= 0 {
+		settings.Interval = DefaultInterval
+	}
+	if settings.IdleTime <= 0 {
+		settings.IdleTime = DefaultIdleTime
+	}
+
+	return &Sensor{
+		adc:      a,
+		channel:  channel,
+		settings: settings,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Watch measures the current until the context is done. The returned channel
+// receives motor.ErrStall if the current goes above the stall current, or
+// motor.ErrIdle if it stays below the idle current, it is never closed. The
+// peak current is reset.
+func (s *Sensor) Watch(ctx context.Context) <-chan error {
+	s.mu.Lock()
+	s.peak = 0
+	s.mu.Unlock()
+
+	stalled := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(s.settings.Interval)
+		defer ticker.Stop()
+
+		start := time.Now()
+		failing := false
+		var idleSince time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				volts, err := s.adc.Read(s.channel)
+				if err != nil {
+					// The door keeps moving, it is still protected by the timeout
+					if !failing {
+						logrus.WithError(err).Errorln("Error while reading the current of the motor")
+					}
+					failing = true
+					continue
+				}
+				failing = false
+
+				current := volts * s.settings.AmpsPerVolt
+				s.observe(current)
+
+				if s.settings.StallCurrent > 0 && now.Sub(start) >= s.settings.Inrush && current >= s.settings.StallCurrent {
+					logrus.WithFields(logrus.Fields{
+						"current":       current,
+						"stall_current": s.settings.StallCurrent,
+					}).Warningln("The motor has stalled")
+					stalled <- motor.ErrStall
+					return
+				}
+
+				// The current has dropped once the motor has started
+				if s.settings.IdleCurrent <= 0 || now.Sub(start) < s.settings.Inrush || current >= s.settings.IdleCurrent {
+					idleSince = time.Time{}
+					continue
+				}
+				if idleSince.IsZero() {
+					idleSince = now
+				}
+				if now.Sub(idleSince) >= s.settings.IdleTime {
+					logrus.WithFields(logrus.Fields{
+						"current":      current,
+						"idle_current": s.settings.IdleCurrent,
+					}).Infoln("The motor is idle")
+					stalled <- motor.ErrIdle
+					return
+				}
+			}
+		}
+	}()
+
+	return stalled
+}
+
+// PeakCurrent returns the highest current measured since Watch has been called, in amperes.
+func (s *Sensor) PeakCurrent() float64 {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.peak
+}
+
+// observe keeps the peak current.
+func (s *Sensor) observe(current float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current > s.peak {
+		s.peak = current
+	}
+}
@@ -0,0 +1,104 @@
+package currentsense
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc/adctest"
+	"github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestStall(t *testing.T) {
+	a := adctest.NewConverter(0.2)
+	s := New(a, 0, Settings{AmpsPerVolt: 10, StallCurrent: 5, Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stalled := s.Watch(ctx)
+
+	select {
+	case <-stalled:
+		t.Fatal("should not stall at 2A")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	a.Set(0.6)
+	select {
+	case err := <-stalled:
+		if err != motor.ErrStall {
+			t.Fatalf("should be a stall, it is %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("should stall at 6A")
+	}
+	if s.PeakCurrent() != 6 {
+		t.Fatalf("peak current should be 6A, it is %v", s.PeakCurrent())
+	}
+}
+
+func TestInrush(t *testing.T) {
+	a := adctest.NewConverter(1)
+	s := New(a, 0, Settings{AmpsPerVolt: 10, StallCurrent: 5, Inrush: 50 * time.Millisecond, Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	stalled := s.Watch(ctx)
+
+	// The inrush current is ignored, then the motor is still stalled
+	<-stalled
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("should ignore the current during the inrush")
+	}
+}
+
+func TestPeakCurrentReset(t *testing.T) {
+	a := adctest.NewConverter(0.3)
+	s := New(a, 0, Settings{AmpsPerVolt: 10, Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Watch(ctx)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if s.PeakCurrent() != 3 {
+		t.Fatalf("peak current should be 3A, it is %v", s.PeakCurrent())
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	s.Watch(ctx)
+	if s.PeakCurrent() != 0 {
+		t.Fatal("peak current should be reset")
+	}
+
+	var none *Sensor
+	if none.PeakCurrent() != 0 {
+		t.Fatal("nil sensor should have no peak current")
+	}
+}
+
+func TestIdle(t *testing.T) {
+	a := adctest.NewConverter(0.2)
+	s := New(a, 0, Settings{AmpsPerVolt: 10, IdleCurrent: 0.5, IdleTime: 10 * time.Millisecond, Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idle := s.Watch(ctx)
+
+	select {
+	case <-idle:
+		t.Fatal("should not be idle at 2A")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	a.Set(0.01)
+	select {
+	case err := <-idle:
+		if err != motor.ErrIdle {
+			t.Fatalf("should be idle, it is %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("should be idle at 0.1A")
+	}
+}
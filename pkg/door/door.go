@@ -2,35 +2,97 @@ package door
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fallais/gocoop/pkg/motor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
 )
 
+//------------------------------------------------------------------------------
+// Metrics
+//------------------------------------------------------------------------------
+
+var (
+	motorRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_motor_runs_total",
+		Help: "Number of runs of the motor.",
+	}, []string{"direction", "door", "coop"})
+	motorTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_motor_timeouts_total",
+		Help: "Number of runs of the motor that have reached the timeout.",
+	}, []string{"direction", "door", "coop"})
+	limitSwitchHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_limit_switch_hits_total",
+		Help: "Number of runs of the motor stopped by a limit switch.",
+	}, []string{"direction", "door", "coop"})
+	motorStalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_motor_stalls_total",
+		Help: "Number of runs of the motor stopped because it has stalled.",
+	}, []string{"direction", "door", "coop"})
+	motorObstructions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_motor_obstructions_total",
+		Help: "Number of runs of the motor stopped by the obstacle sensor.",
+	}, []string{"direction", "door", "coop"})
+	positionHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_door_position_hits_total",
+		Help: "Number of runs of the motor stopped by the encoder at the end of the travel.",
+	}, []string{"direction", "door", "coop"})
+	obstructions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_door_obstructions_total",
+		Help: "Number of times the door has been blocked while closing.",
+	}, []string{"door", "coop"})
+	missedSteps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_encoder_missed_steps_total",
+		Help: "Number of steps missed by the encoder while the motor runs.",
+	}, []string{"direction", "door", "coop"})
+	peakCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gocoop_motor_peak_current_amperes",
+		Help: "Highest current of the last run of the motor.",
+	}, []string{"direction", "door", "coop"})
+)
+
 //------------------------------------------------------------------------------
 // Structure
 //------------------------------------------------------------------------------
 
 // Door is a physical door manipulated with a motor.
 type door struct {
+	coop            string
+	name            string
 	motor           motor.Motor
+	sensor          Sensor
 	openingDuration time.Duration
 	closingDuration time.Duration
-}
+	safety          *Safety
+	odometer        *Odometer
 
-var cancelFunc      context.CancelFunc
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// NewDoor returns a new Door.
-func NewDoor(motor motor.Motor, openingDuration, closingDuration time.Duration) Door {
+// NewDoor returns a new Door of the coop with the ID, the name is empty for the main door
+// of the coop. They label the metrics of the door. The sensor can be nil if the door has no limit switches,
+// and the safety can be nil to let the door run until the duration has elapsed when it is blocked.
+// With a calibrated odometer, the door stops at the end of its travel and the durations become timeouts.
+func NewDoor(coop, name string, motor motor.Motor, sensor Sensor, openingDuration, closingDuration time.Duration, safety *Safety, odometer *Odometer) Door {
 	return &door{
+		coop:            coop,
+		name:            name,
 		motor:           motor,
+		sensor:          sensor,
 		openingDuration: openingDuration,
 		closingDuration: closingDuration,
+		safety:          safety,
+		odometer:        odometer,
 	}
 }
 
@@ -39,45 +101,122 @@ func NewDoor(motor motor.Motor, openingDuration, closingDuration time.Duration)
 //------------------------------------------------------------------------------
 
 // Open the door
-func (d *door) Open() error {
+func (d *door) Open() (Movement, error) {
 	logrus.Infoln("Opening the door")
 
 	// Create context
-	ctx, cancel := context.WithTimeout(context.Background(), d.openingDuration)
-	cancelFunc = cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	d.setCancel(cancel)
+	defer d.setCancel(nil)
 	defer cancel()
 
 	// Run the motor in forward
-	d.motor.Forward(ctx)
+	m := d.run(ctx, "open", d.openingDuration, d.motor.Forward, nil, 100)
 
-	logrus.Infoln("Door has been opened")
+	logrus.WithFields(logrus.Fields{
+		"duration":     m.Duration,
+		"end_reason":   m.EndReason,
+		"peak_current": m.PeakCurrent,
+	}).Infoln("Door has been opened")
 
-	return nil
+	return m, nil
 }
 
-// Close the door
-func (d *door) Close() error {
+// Close the door. With a safety, the door is reversed and closed again when it is blocked.
+func (d *door) Close() (Movement, error) {
 	logrus.Infoln("Closing the door")
 
 	// Create context
-	ctx, cancel := context.WithTimeout(context.Background(), d.closingDuration)
-	cancelFunc = cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	d.setCancel(cancel)
+	defer d.setCancel(nil)
 	defer cancel()
 
 	// Run the motor in backward
-	d.motor.Backward(ctx)
+	start := time.Now()
+	closing := func() Movement {
+		return d.run(ctx, "close", d.closingDuration, d.motor.Backward, d.safety.obstacle(), 0)
+	}
+	m := closing()
+	if d.safety != nil {
+		m = d.recoverClose(ctx, m, closing)
+		m.Duration = time.Since(start)
+	}
 
-	logrus.Infoln("Door has been closed")
+	logrus.WithFields(logrus.Fields{
+		"duration":     m.Duration,
+		"end_reason":   m.EndReason,
+		"peak_current": m.PeakCurrent,
+	}).Infoln("Door has been closed")
 
-	return nil
+	return m, nil
+}
+
+// MoveTo moves the door from a position to another, in percent from 0 when it
+// is closed to 100 when it is opened. With a calibrated encoder, the door stops
+// at the position measured by the encoder. Otherwise the motor runs for the
+// matching part of the opening or closing duration, unless the door moves to
+// one of its limit switches. With a safety, the door is reversed to fully open
+// when it is blocked while closing, and moved again from there.
+func (d *door) MoveTo(from, to float64) (Movement, error) {
+	calibrated := d.odometer.isCalibrated()
+	if calibrated {
+		from, _ = d.odometer.Position()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+	}).Infoln("Moving the door")
+
+	// Create context
+	ctx, cancel := context.WithCancel(context.Background())
+	d.setCancel(cancel)
+	defer d.setCancel(nil)
+	defer cancel()
+
+	var m Movement
+	start := time.Now()
+	switch {
+	case to > from:
+		m = d.run(ctx, "open", d.travel(d.openingDuration, to-from, to), d.motor.Forward, nil, to)
+	case to < from:
+		m = d.run(ctx, "close", d.travel(d.closingDuration, from-to, to), d.motor.Backward, d.safety.obstacle(), to)
+		if d.safety != nil {
+			// After a reversal, the door is moved again from fully open
+			m = d.recoverClose(ctx, m, func() Movement {
+				return d.run(ctx, "close", d.travel(d.closingDuration, 100-to, to), d.motor.Backward, d.safety.obstacle(), to)
+			})
+			m.Duration = time.Since(start)
+		}
+	default:
+		m = Movement{EndReason: Position}
+	}
+
+	// Between the limit switches, the position is reached when the duration has elapsed
+	if !calibrated && to > 0 && to < 100 && m.EndReason == Timeout {
+		m.EndReason = DurationElapsed
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"duration":     m.Duration,
+		"end_reason":   m.EndReason,
+		"peak_current": m.PeakCurrent,
+	}).Infoln("Door has been moved")
+
+	return m, nil
 }
 
 // Stop the door
 func (d *door) Stop() error {
 	logrus.Infoln("Emergency stopping the door")
 
-	if cancelFunc != nil {
-		cancelFunc()
+	d.mu.Lock()
+	cancel := d.cancel
+	d.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
 	} else {
 		d.motor.Stop()
 		logrus.Infoln("Door has been stopped")
@@ -85,3 +224,191 @@ func (d *door) Stop() error {
 
 	return nil
 }
+
+// Sense returns the position of the door given by its limit switches.
+func (d *door) Sense() (State, error) {
+	if d.sensor == nil {
+		return StateUnknown, ErrNoSensor
+	}
+
+	return d.sensor.Sense()
+}
+
+// Position returns the position of the door measured by its encoder, as a
+// percentage from 0 when it is closed to 100 when it is opened.
+func (d *door) Position() (float64, error) {
+	return d.odometer.Position()
+}
+
+// Calibrate starts learning the travel of the encoder: it is learnt during
+// the next opening from the closed limit switch to the opened one.
+func (d *door) Calibrate() error {
+	if d.odometer == nil {
+		return ErrNoEncoder
+	}
+
+	// The limit switches are the references of the calibration
+	state, err := d.Sense()
+	if err != nil {
+		return err
+	}
+	d.odometer.calibrate(state == StateClosed)
+
+	return nil
+}
+
+// run turns the motor until it stops by itself, the duration has elapsed, the
+// context is done, the encoder reaches the target position or the obstacle
+// sensor detects something.
+func (d *door) run(ctx context.Context, direction string, duration time.Duration, turn func(context.Context) error, obstacle ObstacleSensor, target float64) Movement {
+	if obstacle != nil && obstacle.IsActive() {
+		logrus.Warningln("There is an obstacle in the doorway")
+		m := Movement{EndReason: Obstructed}
+		d.observe(direction, m)
+		return m
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// Stop the motor as soon as there is an obstacle
+	var blocked int32
+	if obstacle != nil {
+		go func() {
+			obstacle.Wait(ctx)
+			if ctx.Err() == nil && obstacle.IsActive() {
+				logrus.Warningln("An obstacle has been detected in the doorway")
+				atomic.StoreInt32(&blocked, 1)
+				cancel()
+			}
+		}()
+	}
+
+	// Stop the motor at the target position
+	forward := direction == "open"
+	calibrated := d.odometer.isCalibrated()
+	missed := d.odometer.missedSteps()
+	d.odometer.start(forward)
+	var reached int32
+	if calibrated {
+		go func() {
+			ticker := time.NewTicker(PositionInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if d.odometer.hasReached(forward, target) {
+						atomic.StoreInt32(&reached, 1)
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	start := time.Now()
+	err := turn(ctx)
+	m := Movement{
+		Duration:    time.Since(start),
+		EndReason:   endReason(ctx, err),
+		PeakCurrent: d.peakCurrent(),
+		MissedSteps: d.odometer.missedSteps() - missed,
+	}
+	switch {
+	case atomic.LoadInt32(&blocked) == 1:
+		m.EndReason = Obstructed
+	case atomic.LoadInt32(&reached) == 1:
+		m.EndReason = Position
+	case calibrated && m.EndReason == DurationElapsed:
+		// The duration is only a timeout when the position is known
+		m.EndReason = Timeout
+	}
+	d.odometer.end(forward, m.EndReason)
+	d.observe(direction, m)
+
+	return m
+}
+
+// travel returns the duration of the movement of the percentage toward the
+// target. The limit switches stop the door at the ends of the travel, so the
+// full duration is then a timeout: the position the door starts from is only
+// estimated without encoder.
+func (d *door) travel(duration time.Duration, percentage, target float64) time.Duration {
+	if d.sensor != nil && (target <= 0 || target >= 100) {
+		return duration
+	}
+
+	return part(duration, percentage)
+}
+
+// part returns the part of the duration of a full travel needed to travel the percentage.
+func part(duration time.Duration, percentage float64) time.Duration {
+	return time.Duration(float64(duration) * percentage / 100)
+}
+
+// setCancel sets the function that cancels the current movement.
+func (d *door) setCancel(cancel context.CancelFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.cancel = cancel
+}
+
+// peakCurrent returns the highest current of the last run, 0 if the motor does not measure it.
+func (d *door) peakCurrent() float64 {
+	cs, ok := d.motor.(motor.CurrentSensor)
+	if !ok {
+		return 0
+	}
+
+	return cs.PeakCurrent()
+}
+
+// endReason returns the reason why the motor has stopped, from the error
+// returned by the motor and the state of its context.
+func endReason(ctx context.Context, err error) EndReason {
+	switch {
+	case ctx.Err() == context.Canceled:
+		return Stopped
+	case errors.Is(err, motor.ErrStall):
+		return Stall
+	case errors.Is(err, motor.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		// The motor was watching a limit switch that has never been reached
+		return Timeout
+	case err != nil:
+		return Failure
+	case ctx.Err() == context.DeadlineExceeded:
+		return DurationElapsed
+	default:
+		// The motor has returned by itself before the deadline
+		return LimitSwitch
+	}
+}
+
+// observe updates the metrics of the motor with the movement.
+func (d *door) observe(direction string, m Movement) {
+	motorRuns.WithLabelValues(direction, d.name, d.coop).Inc()
+
+	switch m.EndReason {
+	case Timeout:
+		motorTimeouts.WithLabelValues(direction, d.name, d.coop).Inc()
+	case LimitSwitch:
+		limitSwitchHits.WithLabelValues(direction, d.name, d.coop).Inc()
+	case Stall:
+		motorStalls.WithLabelValues(direction, d.name, d.coop).Inc()
+	case Obstructed:
+		motorObstructions.WithLabelValues(direction, d.name, d.coop).Inc()
+	case Position:
+		positionHits.WithLabelValues(direction, d.name, d.coop).Inc()
+	}
+	if m.MissedSteps > 0 {
+		missedSteps.WithLabelValues(direction, d.name, d.coop).Add(float64(m.MissedSteps))
+	}
+	if m.PeakCurrent > 0 {
+		peakCurrent.WithLabelValues(direction, d.name, d.coop).Set(m.PeakCurrent)
+	}
+}
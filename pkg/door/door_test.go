@@ -0,0 +1,239 @@
+package door
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeMotor stalls when closing the given number of times, then reaches the limit switch.
+type fakeMotor struct {
+	mu        sync.Mutex
+	stalls    int
+	forwards  int
+	backwards int
+}
+
+func (m *fakeMotor) Forward(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.forwards++
+	return nil
+}
+
+func (m *fakeMotor) Backward(ctx context.Context) error {
+	m.mu.Lock()
+	m.backwards++
+	stall := m.backwards <= m.stalls
+	m.mu.Unlock()
+
+	if stall {
+		return motor.ErrStall
+	}
+	return nil
+}
+
+func (m *fakeMotor) Stop() error { return nil }
+
+// fakeObstacle is an obstacle sensor that is active until it is cleared.
+type fakeObstacle struct {
+	mu     sync.Mutex
+	active bool
+}
+
+func (o *fakeObstacle) IsActive() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.active
+}
+
+func (o *fakeObstacle) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestCloseRetries(t *testing.T) {
+	m := &fakeMotor{stalls: 2}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, &Safety{Retries: 3, RetryDelay: time.Millisecond}, nil)
+
+	mv, err := d.Close()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if mv.EndReason != LimitSwitch {
+		t.Fatalf("should be closed after the retries, it ended with %s", mv.EndReason)
+	}
+	if m.backwards != 3 || m.forwards != 2 {
+		t.Fatalf("should close 3 times and reverse 2 times, it closed %d times and reversed %d times", m.backwards, m.forwards)
+	}
+}
+
+func TestCloseGiveUp(t *testing.T) {
+	m := &fakeMotor{stalls: 10}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, &Safety{Retries: 2, RetryDelay: time.Millisecond}, nil)
+
+	mv, _ := d.Close()
+	if mv.EndReason != Obstructed {
+		t.Fatalf("should be obstructed, it ended with %s", mv.EndReason)
+	}
+	if !mv.Reversed {
+		t.Fatal("should leave the door reversed to fully open")
+	}
+	if m.backwards != 3 || m.forwards != 3 {
+		t.Fatalf("should close 3 times and reverse 3 times, it closed %d times and reversed %d times", m.backwards, m.forwards)
+	}
+}
+
+func TestMoveToRetries(t *testing.T) {
+	m := &fakeMotor{stalls: 1}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, &Safety{Retries: 3, RetryDelay: time.Millisecond}, nil)
+
+	mv, err := d.MoveTo(100, 50)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if isBlocked(mv) {
+		t.Fatalf("should reach the position after the retry, it ended with %s", mv.EndReason)
+	}
+	if m.backwards != 2 || m.forwards != 1 {
+		t.Fatalf("should close 2 times and reverse once, it closed %d times and reversed %d times", m.backwards, m.forwards)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := &fakeMotor{stalls: 1}
+	d := NewDoor("metrics", "gate", m, nil, time.Second, time.Second, &Safety{Retries: 1, RetryDelay: time.Millisecond}, nil)
+
+	_, err := d.Close()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"runs of the closing", testutil.ToFloat64(motorRuns.WithLabelValues("close", "gate", "metrics")), 2},
+		{"runs of the reversal", testutil.ToFloat64(motorRuns.WithLabelValues("open", "gate", "metrics")), 1},
+		{"stalls", testutil.ToFloat64(motorStalls.WithLabelValues("close", "gate", "metrics")), 1},
+		{"limit switch hits", testutil.ToFloat64(limitSwitchHits.WithLabelValues("close", "gate", "metrics")), 1},
+		{"obstructions", testutil.ToFloat64(obstructions.WithLabelValues("gate", "metrics")), 1},
+		{"timeouts", testutil.ToFloat64(motorTimeouts.WithLabelValues("close", "gate", "metrics")), 0},
+	}
+	for _, tt := range tests {
+		if tt.value != tt.expected {
+			t.Errorf("%s should be %v, it is %v", tt.name, tt.expected, tt.value)
+		}
+	}
+}
+
+func TestCloseWithoutSafety(t *testing.T) {
+	m := &fakeMotor{stalls: 1}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, nil, nil)
+
+	mv, _ := d.Close()
+	if mv.EndReason != Stall {
+		t.Fatalf("should be a stall, it ended with %s", mv.EndReason)
+	}
+	if m.forwards != 0 {
+		t.Fatal("should not reverse the door")
+	}
+}
+
+func TestCloseObstacle(t *testing.T) {
+	m := &fakeMotor{}
+	obstacle := &fakeObstacle{active: true}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, &Safety{Retries: 1, RetryDelay: 10 * time.Millisecond, Obstacle: obstacle}, nil)
+
+	// The obstacle goes away while the door is waiting
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		obstacle.mu.Lock()
+		obstacle.active = false
+		obstacle.mu.Unlock()
+	}()
+
+	mv, _ := d.Close()
+	if mv.EndReason != LimitSwitch {
+		t.Fatalf("should be closed once the obstacle is gone, it ended with %s", mv.EndReason)
+	}
+	if m.backwards != 1 || m.forwards != 1 {
+		t.Fatalf("should close once and reverse once, it closed %d times and reversed %d times", m.backwards, m.forwards)
+	}
+}
+
+func TestStopWhileWaiting(t *testing.T) {
+	m := &fakeMotor{stalls: 1}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, &Safety{Retries: 1, RetryDelay: time.Minute}, nil)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		d.Stop()
+	}()
+
+	mv, _ := d.Close()
+	if mv.EndReason != Stopped {
+		t.Fatalf("should be stopped, it ended with %s", mv.EndReason)
+	}
+}
+
+// fakeLimits is a motor watching limit switches that are reached after the travel time.
+type fakeLimits struct {
+	travel time.Duration
+}
+
+func (m *fakeLimits) Forward(ctx context.Context) error  { return m.turn(ctx) }
+func (m *fakeLimits) Backward(ctx context.Context) error { return m.turn(ctx) }
+func (m *fakeLimits) Stop() error                        { return nil }
+
+func (m *fakeLimits) turn(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return motor.ErrTimeout
+	case <-time.After(m.travel):
+		return nil
+	}
+}
+
+// fakeSensor senses the door between its limit switches.
+type fakeSensor struct{}
+
+func (s fakeSensor) Sense() (State, error) { return StateBetween, nil }
+
+func TestMoveFromPartialToLimitSwitch(t *testing.T) {
+	// The door is slower than the estimate of the partial position
+	m := &fakeLimits{travel: 100 * time.Millisecond}
+	d := NewDoor("default", "", m, fakeSensor{}, 150*time.Millisecond, 150*time.Millisecond, nil, nil)
+
+	for _, to := range []float64{100, 0} {
+		mv, err := d.MoveTo(50, to)
+		if err != nil {
+			t.Fatalf("should not error: %s", err)
+		}
+		if mv.EndReason != LimitSwitch {
+			t.Fatalf("should reach the limit switch at %v, it ended with %s", to, mv.EndReason)
+		}
+	}
+}
+
+func TestMoveToPartial(t *testing.T) {
+	m := &fakeLimits{travel: time.Second}
+	d := NewDoor("default", "", m, fakeSensor{}, 200*time.Millisecond, 200*time.Millisecond, nil, nil)
+
+	mv, err := d.MoveTo(0, 50)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if mv.EndReason != DurationElapsed {
+		t.Fatalf("should stop when the duration has elapsed, it ended with %s", mv.EndReason)
+	}
+	if mv.Duration >= 200*time.Millisecond {
+		t.Fatalf("should run for half of the duration, it ran for %s", mv.Duration)
+	}
+}
@@ -1,8 +1,87 @@
 package door
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoSensor is raised when the door has no limit switches to sense its position.
+var ErrNoSensor = errors.New("door has no sensor")
+
+// ErrFailure is raised when the motor has failed while running the door.
+var ErrFailure = errors.New("motor has failed")
+
+// EndReason is the reason why a movement of the door has ended.
+type EndReason string
+
+const (
+	// LimitSwitch when the door has reached a limit switch.
+	LimitSwitch EndReason = "limit_switch"
+
+	// DurationElapsed when the opening or closing duration has elapsed.
+	DurationElapsed EndReason = "duration_elapsed"
+
+	// Position when the encoder has measured the target position.
+	Position EndReason = "position"
+
+	// Timeout when the duration has elapsed before reaching a limit switch or the end of the travel.
+	Timeout EndReason = "timeout"
+
+	// Stall when the motor has stalled before reaching a limit switch.
+	Stall EndReason = "stall"
+
+	// Obstructed when the obstacle sensor has detected something, or when the
+	// door is still blocked after all the retries of the safety.
+	Obstructed EndReason = "obstructed"
+
+	// Stopped when the door has been stopped.
+	Stopped EndReason = "stopped"
+
+	// Failure when the motor has failed.
+	Failure EndReason = "failure"
+)
+
+// State is the position of the door given by its limit switches.
+type State string
+
+const (
+	// StateOpened when the door has reached the opened limit switch.
+	StateOpened State = "opened"
+
+	// StateClosed when the door has reached the closed limit switch.
+	StateClosed State = "closed"
+
+	// StateBetween when the door is between the limit switches.
+	StateBetween State = "between"
+
+	// StateUnknown when the position of the door cannot be sensed.
+	StateUnknown State = "unknown"
+)
+
+// Movement describes how a movement of the door went. The peak current is
+// only known if the motor measures its current, the missed steps if the door
+// has an encoder that can tell. Reversed is true when the safety has given up
+// and left the door fully opened.
+type Movement struct {
+	Duration    time.Duration
+	EndReason   EndReason
+	PeakCurrent float64
+	MissedSteps int64
+	Reversed    bool
+}
+
+// Sensor senses the position of the door with its limit switches.
+type Sensor interface {
+	Sense() (State, error)
+}
+
 // Door operation contract.
 type Door interface {
-	Open() error
-	Close() error
+	Open() (Movement, error)
+	Close() (Movement, error)
+	MoveTo(from, to float64) (Movement, error)
 	Stop() error
+	Sense() (State, error)
+	Position() (float64, error)
+	Calibrate() error
 }
@@ -0,0 +1,245 @@
+package door
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoEncoder is raised when the door has no encoder to measure its position.
+var ErrNoEncoder = errors.New("door has no encoder")
+
+// ErrNotCalibrated is raised when the travel of the encoder has not been learnt yet.
+var ErrNotCalibrated = errors.New("door is not calibrated")
+
+// PositionInterval is the interval between two checks of the position while the door is moving.
+const PositionInterval = 5 * time.Millisecond
+
+// MaxMissedSteps is the number of steps the encoder can miss before its count
+// is not trusted anymore, until the door reaches a limit switch.
+const MaxMissedSteps = 20
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Encoder counts the steps of the shaft of the motor, the count increases while opening.
+type Encoder interface {
+	Count() int64
+	Reset(count int64)
+	SetDirection(direction int)
+	Missed() int64
+}
+
+// Odometer measures the position of the door with an encoder. The count is 0
+// when the door is closed and it is the travel when the door is opened. The
+// door is not calibrated anymore when the encoder has missed more than
+// MaxMissedSteps since the count has been set.
+type Odometer struct {
+	encoder      Encoder
+	onCalibrated func(travel int64)
+
+	mu          sync.Mutex
+	travel      int64
+	calibrating bool
+	zeroed      bool
+	missed      int64
+	drifted     bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewOdometer returns a new Odometer with the travel learnt by a previous
+// calibration, 0 if the door has never been calibrated. The function is called
+// with the new travel after every calibration, so that it can be persisted.
+func NewOdometer(encoder Encoder, travel int64, onCalibrated func(travel int64)) *Odometer {
+	return &Odometer{
+		encoder:      encoder,
+		travel:       travel,
+		onCalibrated: onCalibrated,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Position returns the position of the door as a percentage, from 0 when it
+// is closed to 100 when it is opened.
+func (o *Odometer) Position() (float64, error) {
+	if o == nil {
+		return 0, ErrNoEncoder
+	}
+
+	o.mu.Lock()
+	travel := o.travel
+	drifted := o.hasDrifted()
+	o.mu.Unlock()
+
+	if travel <= 0 || drifted {
+		return 0, ErrNotCalibrated
+	}
+
+	position := float64(o.encoder.Count()) * 100 / float64(travel)
+	switch {
+	case position < 0:
+		return 0, nil
+	case position > 100:
+		return 100, nil
+	}
+
+	return position, nil
+}
+
+// Travel returns the count of a full travel, 0 if the door is not calibrated.
+func (o *Odometer) Travel() int64 {
+	if o == nil {
+		return 0
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.travel
+}
+
+// isCalibrated returns true if the position can be used to stop the door.
+func (o *Odometer) isCalibrated() bool {
+	if o == nil {
+		return false
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.travel > 0 && !o.calibrating && !o.hasDrifted()
+}
+
+// calibrate starts learning the travel, from the closed limit switch to the
+// opened one. The door is already at the closed limit switch if closed is true.
+func (o *Odometer) calibrate(closed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	logrus.Infoln("Calibrating the encoder of the door")
+
+	o.calibrating = true
+	o.zeroed = closed
+	if closed {
+		o.reset(0)
+	}
+}
+
+// start tells the encoder the direction of the movement.
+func (o *Odometer) start(forward bool) {
+	if o == nil {
+		return
+	}
+
+	if forward {
+		o.encoder.SetDirection(1)
+	} else {
+		o.encoder.SetDirection(-1)
+	}
+}
+
+// hasReached returns true if the door has reached the target position, in
+// percent, in the direction.
+func (o *Odometer) hasReached(forward bool, target float64) bool {
+	if !o.isCalibrated() {
+		return false
+	}
+
+	count := int64(math.Round(float64(o.Travel()) * target / 100))
+	if forward {
+		return o.encoder.Count() >= count
+	}
+
+	return o.encoder.Count() <= count
+}
+
+// missedSteps returns the number of steps missed by the encoder.
+func (o *Odometer) missedSteps() int64 {
+	if o == nil {
+		return 0
+	}
+
+	return o.encoder.Missed()
+}
+
+// end corrects the count when the movement has ended on a limit switch, and
+// learns the travel at the end of a calibration.
+func (o *Odometer) end(forward bool, reason EndReason) {
+	if o == nil || reason != LimitSwitch {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// The closed limit switch is the origin
+	if !forward {
+		o.reset(0)
+		o.zeroed = true
+		return
+	}
+
+	if !o.calibrating {
+		if o.travel > 0 {
+			o.reset(o.travel)
+		}
+		return
+	}
+
+	// The door has travelled from the closed limit switch to the opened one
+	if !o.zeroed {
+		return
+	}
+	o.calibrating = false
+	count := o.encoder.Count()
+	if count <= 0 {
+		logrus.WithFields(logrus.Fields{
+			"count": count,
+		}).Errorln("The encoder does not count while opening the door, it may be inverted")
+		return
+	}
+	o.travel = count
+	logrus.WithFields(logrus.Fields{
+		"travel": o.travel,
+	}).Infoln("The encoder of the door has been calibrated")
+
+	if o.onCalibrated != nil {
+		o.onCalibrated(o.travel)
+	}
+}
+
+// reset sets the count, the missed steps are counted from now on. The lock
+// must be held by the caller.
+func (o *Odometer) reset(count int64) {
+	o.encoder.Reset(count)
+	o.missed = o.encoder.Missed()
+	o.drifted = false
+}
+
+// hasDrifted returns true if the encoder has missed too many steps since the
+// count has been set. The lock must be held by the caller.
+func (o *Odometer) hasDrifted() bool {
+	missed := o.encoder.Missed() - o.missed
+	if missed <= MaxMissedSteps {
+		return false
+	}
+
+	if !o.drifted {
+		o.drifted = true
+		logrus.WithFields(logrus.Fields{
+			"missed": missed,
+		}).Warningln("The encoder has missed too many steps, the position of the door is unknown until it reaches a limit switch")
+	}
+
+	return true
+}
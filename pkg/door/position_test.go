@@ -0,0 +1,249 @@
+package door
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeEncoder is an encoder moved by the travelMotor. It misses every step
+// while it is missing.
+type fakeEncoder struct {
+	mu      sync.Mutex
+	count   int64
+	missed  int64
+	missing bool
+}
+
+func (e *fakeEncoder) Count() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.count
+}
+
+func (e *fakeEncoder) Reset(count int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.count = count
+}
+
+func (e *fakeEncoder) SetDirection(direction int) {}
+
+func (e *fakeEncoder) Missed() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.missed
+}
+
+func (e *fakeEncoder) add(steps int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.count += steps
+	if e.missing {
+		e.missed++
+	}
+}
+
+func (e *fakeEncoder) miss(missed int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.missed += missed
+}
+
+// travelMotor moves the encoder one step per millisecond. With limit
+// switches, it stops at 0 and at the travel.
+type travelMotor struct {
+	encoder *fakeEncoder
+	travel  int64
+	limits  bool
+	stuck   bool
+}
+
+func (m *travelMotor) run(ctx context.Context, step int64) error {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if m.limits && ((step > 0 && m.encoder.Count() >= m.travel) || (step < 0 && m.encoder.Count() <= 0)) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if !m.stuck {
+				m.encoder.add(step)
+			}
+		}
+	}
+}
+
+func (m *travelMotor) Forward(ctx context.Context) error  { return m.run(ctx, 1) }
+func (m *travelMotor) Backward(ctx context.Context) error { return m.run(ctx, -1) }
+func (m *travelMotor) Stop() error                        { return nil }
+
+// Sense returns the state of the limit switches of the motor.
+func (m *travelMotor) Sense() (State, error) {
+	switch count := m.encoder.Count(); {
+	case count >= m.travel:
+		return StateOpened, nil
+	case count <= 0:
+		return StateClosed, nil
+	}
+	return StateBetween, nil
+}
+
+func TestCalibrate(t *testing.T) {
+	e := &fakeEncoder{count: 20}
+	m := &travelMotor{encoder: e, travel: 60, limits: true}
+
+	var learnt int64
+	o := NewOdometer(e, 0, func(travel int64) { learnt = travel })
+	d := NewDoor("default", "", m, m, time.Second, time.Second, nil, o)
+
+	_, err := d.Position()
+	if err != ErrNotCalibrated {
+		t.Fatalf("should be not calibrated, it is %v", err)
+	}
+
+	err = d.Calibrate()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	d.Close()
+	d.Open()
+
+	if learnt != 60 || o.Travel() != 60 {
+		t.Fatalf("travel should be 60, it is %d (persisted %d)", o.Travel(), learnt)
+	}
+	position, err := d.Position()
+	if err != nil || position != 100 {
+		t.Fatalf("position should be 100, it is %f (%v)", position, err)
+	}
+}
+
+func TestCalibrateWithoutEncoder(t *testing.T) {
+	d := NewDoor("default", "", &fakeMotor{}, nil, time.Second, time.Second, nil, nil)
+
+	if err := d.Calibrate(); err != ErrNoEncoder {
+		t.Fatalf("should be ErrNoEncoder, it is %v", err)
+	}
+	if _, err := d.Position(); err != ErrNoEncoder {
+		t.Fatalf("should be ErrNoEncoder, it is %v", err)
+	}
+}
+
+func TestPositionStop(t *testing.T) {
+	e := &fakeEncoder{}
+	m := &travelMotor{encoder: e}
+	d := NewDoor("default", "", m, nil, time.Second, time.Second, nil, NewOdometer(e, 40, nil))
+
+	mv, _ := d.Open()
+	if mv.EndReason != Position {
+		t.Fatalf("should be stopped by the position, it is %s", mv.EndReason)
+	}
+	if count := e.Count(); count < 40 || count > 50 {
+		t.Fatalf("should stop at the end of the travel, it is at %d", count)
+	}
+
+	mv, _ = d.Close()
+	if mv.EndReason != Position {
+		t.Fatalf("should be stopped by the position, it is %s", mv.EndReason)
+	}
+	if count := e.Count(); count > 0 || count < -10 {
+		t.Fatalf("should stop at the origin, it is at %d", count)
+	}
+}
+
+func TestPositionTimeout(t *testing.T) {
+	e := &fakeEncoder{}
+	m := &travelMotor{encoder: e, stuck: true}
+	d := NewDoor("default", "", m, nil, 50*time.Millisecond, 50*time.Millisecond, nil, NewOdometer(e, 40, nil))
+
+	mv, _ := d.Open()
+	if mv.EndReason != Timeout {
+		t.Fatalf("should reach the timeout, it is %s", mv.EndReason)
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	e := &fakeEncoder{}
+	m := &travelMotor{encoder: e}
+
+	// Timed travel
+	d := NewDoor("default", "", m, nil, 200*time.Millisecond, 200*time.Millisecond, nil, nil)
+	mv, _ := d.MoveTo(0, 25)
+	if mv.EndReason != DurationElapsed {
+		t.Fatalf("should be stopped by the duration, it is %s", mv.EndReason)
+	}
+	if mv.Duration < 40*time.Millisecond || mv.Duration > 150*time.Millisecond {
+		t.Fatalf("should run for a quarter of the opening duration, it has run for %s", mv.Duration)
+	}
+
+	// Encoder count
+	e.Reset(0)
+	d = NewDoor("default", "", m, nil, time.Second, time.Second, nil, NewOdometer(e, 40, nil))
+	mv, _ = d.MoveTo(0, 50)
+	if mv.EndReason != Position {
+		t.Fatalf("should be stopped by the position, it is %s", mv.EndReason)
+	}
+	if count := e.Count(); count < 20 || count > 30 {
+		t.Fatalf("should stop at the middle of the travel, it is at %d", count)
+	}
+
+	// The current position is measured by the encoder
+	mv, _ = d.MoveTo(100, 25)
+	if mv.EndReason != Position {
+		t.Fatalf("should be stopped by the position, it is %s", mv.EndReason)
+	}
+	if count := e.Count(); count > 10 || count < 0 {
+		t.Fatalf("should stop at a quarter of the travel, it is at %d", count)
+	}
+}
+
+func TestMissedSteps(t *testing.T) {
+	e := &fakeEncoder{}
+	m := &travelMotor{encoder: e, travel: 40, limits: true}
+	o := NewOdometer(e, 40, nil)
+	d := NewDoor("drift", "", m, m, time.Second, time.Second, nil, o)
+
+	// A few missed steps are tolerated
+	e.miss(MaxMissedSteps)
+	if _, err := d.Position(); err != nil {
+		t.Fatalf("should still be calibrated: %s", err)
+	}
+
+	// Too many, the count is not trusted anymore
+	e.miss(1)
+	if _, err := d.Position(); err != ErrNotCalibrated {
+		t.Fatalf("should not be calibrated anymore, error is %v", err)
+	}
+
+	// The limit switch sets the count again
+	mv, _ := d.Close()
+	if mv.EndReason != LimitSwitch {
+		t.Fatalf("should be stopped by the limit switch, it is %s", mv.EndReason)
+	}
+	if position, err := d.Position(); err != nil || position != 0 {
+		t.Fatalf("should be closed, it is at %f (%v)", position, err)
+	}
+
+	// The steps missed while the motor runs are counted, and the door is
+	// stopped by the limit switch once the count is not trusted anymore
+	counted := testutil.ToFloat64(missedSteps.WithLabelValues("open", "", "drift"))
+	e.missing = true
+	mv, _ = d.Open()
+	if mv.EndReason != LimitSwitch {
+		t.Fatalf("should be stopped by the limit switch, it is %s", mv.EndReason)
+	}
+	if mv.MissedSteps != 40 {
+		t.Fatalf("should have missed 40 steps, it has missed %d", mv.MissedSteps)
+	}
+	if missed := testutil.ToFloat64(missedSteps.WithLabelValues("open", "", "drift")) - counted; missed != 40 {
+		t.Fatalf("metric should have increased by 40, it has increased by %f", missed)
+	}
+}
@@ -0,0 +1,105 @@
+package door
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrObstructed is raised when the door cannot be closed because of an obstacle.
+var ErrObstructed = errors.New("door is obstructed")
+
+// DefaultRetryDelay is the default delay before closing the door again after an obstruction.
+const DefaultRetryDelay = time.Minute
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// ObstacleSensor detects an obstacle in the doorway, such as a beam-break sensor.
+type ObstacleSensor interface {
+	// IsActive returns true if there is an obstacle.
+	IsActive() bool
+
+	// Wait blocks until there is an obstacle or the context is done.
+	Wait(ctx context.Context) error
+}
+
+// Safety is the behaviour of the door when it is blocked while closing: it
+// is reversed to fully open, and closed again after a delay.
+type Safety struct {
+	// Retries is the number of times the door is closed again before giving up.
+	Retries int
+
+	// RetryDelay is the delay before closing the door again.
+	RetryDelay time.Duration
+
+	// Obstacle is an optional sensor that stops the door as soon as something is in the doorway.
+	Obstacle ObstacleSensor
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// obstacle returns the obstacle sensor, nil if there is no safety.
+func (s *Safety) obstacle() ObstacleSensor {
+	if s == nil {
+		return nil
+	}
+
+	return s.Obstacle
+}
+
+// isBlocked returns true if the movement has been ended by an obstacle.
+func isBlocked(m Movement) bool {
+	return m.EndReason == Stall || m.EndReason == Obstructed
+}
+
+// recoverClose reverses the door and closes it again with closing as long as it
+// is blocked, up to the number of retries. It returns the last closing movement.
+func (d *door) recoverClose(ctx context.Context, m Movement, closing func() Movement) Movement {
+	for attempt := 1; isBlocked(m); attempt++ {
+		obstructions.WithLabelValues(d.name, d.coop).Inc()
+		logrus.WithFields(logrus.Fields{
+			"attempt":    attempt,
+			"end_reason": m.EndReason,
+		}).Warningln("The door is blocked while closing, reversing it")
+
+		// Reverse to fully open
+		reverse := d.run(ctx, "open", d.openingDuration, d.motor.Forward, nil, 100)
+		switch reverse.EndReason {
+		case Stopped, Stall, Failure:
+			// The door cannot be reversed, it is left where it is
+			m.EndReason = reverse.EndReason
+			return m
+		}
+
+		// Give up
+		if attempt > d.safety.Retries {
+			logrus.WithFields(logrus.Fields{
+				"attempts": attempt,
+			}).Errorln("The door cannot be closed, giving up")
+			m.EndReason = Obstructed
+			m.Reversed = reverse.EndReason != Timeout
+			return m
+		}
+
+		// Wait before closing again
+		logrus.WithFields(logrus.Fields{
+			"delay": d.safety.RetryDelay,
+		}).Infoln("Closing the door again after a delay")
+		select {
+		case <-ctx.Done():
+			m.EndReason = Stopped
+			return m
+		case <-time.After(d.safety.RetryDelay):
+		}
+
+		m = closing()
+	}
+
+	return m
+}
@@ -0,0 +1,85 @@
+package encoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Calibration is the persisted calibration of an encoder.
+type Calibration struct {
+	// Travel is the count of a full travel of the door.
+	Travel int64 `json:"travel"`
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// LoadCalibration reads the calibration from the file. The travel is 0 if the
+// encoder has never been calibrated.
+func LoadCalibration(path string) (Calibration, error) {
+	var c Calibration
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+
+		return c, fmt.Errorf("error while reading the calibration file: %s", err)
+	}
+
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return c, fmt.Errorf("calibration file is corrupted: %s", err)
+	}
+
+	return c, nil
+}
+
+// SaveCalibration writes the calibration to a temporary file and then renames
+// it over the previous one, so that a power cut never leaves a partially
+// written calibration behind.
+func SaveCalibration(path string, c Calibration) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while encoding the calibration: %s", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error while creating the temporary calibration file: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error while writing the temporary calibration file: %s", err)
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error while syncing the temporary calibration file: %s", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("error while closing the temporary calibration file: %s", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error while replacing the calibration file: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package encoder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// steps gives the step of a quadrature encoder from the previous and the
+// current levels of its channels, indexed by previous<<2 | current where a
+// state is A<<1 | B. Invalid transitions, when both channels change at once,
+// are ignored.
+var steps = [16]int64{
+	0, -1, 1, 0,
+	1, 0, 0, -1,
+	-1, 0, 0, 1,
+	0, 1, -1, 0,
+}
+
+//------------------------------------------------------------------------------
+// Interfaces
+//------------------------------------------------------------------------------
+
+// Encoder counts the steps of the shaft of the motor.
+type Encoder interface {
+	// Count returns the number of steps since the last reset.
+	Count() int64
+
+	// Reset sets the count.
+	Reset(count int64)
+
+	// SetDirection tells the encoder in which direction the shaft turns, 1
+	// forward and -1 backward. It is only used by the encoders that cannot
+	// sense the direction.
+	SetDirection(direction int)
+
+	// Missed returns the number of steps missed since the encoder has been
+	// created, as far as the encoder can tell.
+	Missed() int64
+
+	// Close stops watching the pins.
+	Close() error
+}
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// counter is the count shared by the encoders.
+type counter struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	count     int64
+	direction int64
+	missed    int64
+}
+
+// Quadrature is an encoder with two channels in quadrature, it senses the
+// direction by itself. It can tell when it has missed steps: an edge that does
+// not change the level of its channel, or both channels changed at once.
+type Quadrature struct {
+	counter
+	invert bool
+}
+
+// Hall is an encoder with a single channel, such as a hall sensor. It counts
+// the rising edges in the direction given by the motor, and cannot tell when
+// it misses some.
+type Hall struct {
+	counter
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewQuadrature returns a new Quadrature watching the channels A and B until it is closed.
+// The count increases when A leads B, unless it is inverted.
+func NewQuadrature(a, b gpio.Pin, pull gpio.Pull, invert bool) (*Quadrature, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, levels, ordered, err := gpio.WatchGroup(ctx, []gpio.Pin{a, b}, gpio.BothEdges, pull)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("error while watching the channels of the encoder: %s", err)
+	}
+
+	q := &Quadrature{
+		counter: counter{
+			cancel:    cancel,
+			done:      make(chan struct{}),
+			direction: 1,
+		},
+		invert: invert,
+	}
+
+	go q.watch(a, b, events, ordered, levels[0], levels[1])
+
+	return q, nil
+}
+
+// NewHall returns a new Hall watching the pin until it is closed.
+func NewHall(pin gpio.Pin, pull gpio.Pull) (*Hall, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := pin.Watch(ctx, gpio.RisingEdge, pull)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("error while watching the hall sensor: %s", err)
+	}
+
+	h := &Hall{
+		counter: counter{
+			cancel:    cancel,
+			done:      make(chan struct{}),
+			direction: 1,
+		},
+	}
+
+	go h.watch(events)
+
+	return h, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Count returns the number of steps since the last reset.
+func (c *counter) Count() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.count
+}
+
+// Reset sets the count.
+func (c *counter) Reset(count int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.count = count
+}
+
+// SetDirection sets the direction in which the steps are counted.
+func (c *counter) SetDirection(direction int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if direction < 0 {
+		c.direction = -1
+	} else {
+		c.direction = 1
+	}
+}
+
+// Close stops watching the pins.
+func (c *counter) Close() error {
+	c.cancel()
+	<-c.done
+
+	return nil
+}
+
+// Missed returns the number of steps missed since the encoder has been created.
+func (c *counter) Missed() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.missed
+}
+
+// add adds the steps to the count, and the missed steps.
+func (c *counter) add(steps, missed int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.count += steps
+	c.missed += missed
+}
+
+// SetDirection does nothing, a quadrature encoder senses the direction by itself.
+func (q *Quadrature) SetDirection(direction int) {}
+
+// watch decodes the edges of the channels until they are closed. When the
+// edges are received in order, every edge gives the level of its channel.
+// Otherwise the edge of a channel can be received after a later edge of the
+// other one: the levels of both channels are read at every edge instead.
+func (q *Quadrature) watch(a, b gpio.Pin, events <-chan gpio.Event, ordered bool, levelA, levelB gpio.Level) {
+	defer close(q.done)
+
+	state := int(levelA)<<1 | int(levelB)
+	for ev := range events {
+		if ev.Pin == a.Number() {
+			levelA = levelOf(ev.Edge)
+		} else {
+			levelB = levelOf(ev.Edge)
+		}
+		if !ordered {
+			levelA, levelB = snapshot(a, b, levelA, levelB)
+		}
+
+		next := int(levelA)<<1 | int(levelB)
+		step := steps[state<<2|next]
+		var missed int64
+		switch {
+		case ordered && next == state:
+			// The previous edge of the channel has been missed
+			missed = 1
+		case step == 0 && next != state:
+			// Both channels have changed since the previous edge
+			missed = 2
+		}
+		if missed > 0 {
+			logrus.WithFields(logrus.Fields{
+				"missed": missed,
+			}).Debugln("The encoder has missed steps")
+		}
+		if q.invert {
+			step = -step
+		}
+		q.add(step, missed)
+		state = next
+	}
+}
+
+// watch counts the rising edges in the current direction until they are closed.
+func (h *Hall) watch(events <-chan gpio.Event) {
+	defer close(h.done)
+
+	for range events {
+		h.mu.Lock()
+		h.count += h.direction
+		h.mu.Unlock()
+	}
+}
+
+// snapshot returns the current levels of the channels, or the given levels
+// if they cannot be read.
+func snapshot(a, b gpio.Pin, levelA, levelB gpio.Level) (gpio.Level, gpio.Level) {
+	if level, err := a.Read(); err == nil {
+		levelA = level
+	}
+	if level, err := b.Read(); err == nil {
+		levelB = level
+	}
+
+	return levelA, levelB
+}
+
+// levelOf returns the level after the edge.
+func levelOf(edge gpio.Edge) gpio.Level {
+	if edge == gpio.RisingEdge {
+		return gpio.High
+	}
+
+	return gpio.Low
+}
@@ -0,0 +1,256 @@
+package encoder
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+)
+
+// waitFor fails the test if the condition is not met within a second.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition has not been met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// turn drives the channels through the sequence, one edge at a time.
+func turn(t *testing.T, e Encoder, a, b *gpio.FakePin, sequence [][2]gpio.Level) {
+	t.Helper()
+
+	for _, levels := range sequence {
+		before := e.Count()
+		if a.Level() != levels[0] {
+			a.Set(levels[0])
+		} else {
+			b.Set(levels[1])
+		}
+		waitFor(t, func() bool { return e.Count() != before })
+	}
+}
+
+// forward is a full cycle of the channels when A leads B.
+var forward = [][2]gpio.Level{
+	{gpio.High, gpio.Low},
+	{gpio.High, gpio.High},
+	{gpio.Low, gpio.High},
+	{gpio.Low, gpio.Low},
+}
+
+// backward is a full cycle of the channels when B leads A.
+var backward = [][2]gpio.Level{
+	{gpio.Low, gpio.High},
+	{gpio.High, gpio.High},
+	{gpio.High, gpio.Low},
+	{gpio.Low, gpio.Low},
+}
+
+func TestQuadrature(t *testing.T) {
+	chip := gpio.NewFake()
+	a, b := chip.FakePin(1), chip.FakePin(2)
+	q, err := NewQuadrature(a, b, gpio.PullDown, false)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer q.Close()
+
+	turn(t, q, a, b, forward)
+	turn(t, q, a, b, forward)
+	if q.Count() != 8 {
+		t.Fatalf("count should be 8, it is %d", q.Count())
+	}
+
+	// The direction is sensed by the encoder
+	q.SetDirection(1)
+	turn(t, q, a, b, backward)
+	if q.Count() != 4 {
+		t.Fatalf("count should be 4, it is %d", q.Count())
+	}
+
+	q.Reset(100)
+	if q.Count() != 100 {
+		t.Fatalf("count should be 100, it is %d", q.Count())
+	}
+}
+
+func TestQuadratureInvert(t *testing.T) {
+	chip := gpio.NewFake()
+	a, b := chip.FakePin(1), chip.FakePin(2)
+	q, err := NewQuadrature(a, b, gpio.PullDown, true)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer q.Close()
+
+	turn(t, q, a, b, forward)
+	if q.Count() != -4 {
+		t.Fatalf("count should be -4, it is %d", q.Count())
+	}
+}
+
+// manualPin is a pin whose level and edges are given by the test, so that
+// the edges can be delivered out of order.
+type manualPin struct {
+	gpio.Pin
+	number int
+	mu     sync.Mutex
+	level  gpio.Level
+	events chan gpio.Event
+}
+
+func (p *manualPin) Number() int {
+	return p.number
+}
+
+func (p *manualPin) Read() (gpio.Level, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.level, nil
+}
+
+func (p *manualPin) Watch(ctx context.Context, edge gpio.Edge, pull gpio.Pull) (<-chan gpio.Event, error) {
+	return p.events, nil
+}
+
+func (p *manualPin) set(level gpio.Level) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.level = level
+}
+
+// edge delivers an edge of the pin to the level it has now.
+func (p *manualPin) edge() {
+	edge := gpio.FallingEdge
+	if level, _ := p.Read(); level == gpio.High {
+		edge = gpio.RisingEdge
+	}
+	p.events <- gpio.Event{Edge: edge, Time: time.Now()}
+}
+
+func TestQuadratureOutOfOrder(t *testing.T) {
+	a := &manualPin{number: 1, events: make(chan gpio.Event)}
+	b := &manualPin{number: 2, events: make(chan gpio.Event)}
+	q, err := NewQuadrature(a, b, gpio.PullDown, false)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer func() {
+		close(a.events)
+		close(b.events)
+		q.Close()
+	}()
+
+	// Every change is received as an edge of the other channel
+	for _, levels := range forward {
+		before := q.Count()
+		changedA := a.level != levels[0]
+		a.set(levels[0])
+		b.set(levels[1])
+		if changedA {
+			b.edge()
+		} else {
+			a.edge()
+		}
+		waitFor(t, func() bool { return q.Count() == before+1 })
+	}
+	if q.Count() != 4 {
+		t.Fatalf("count should be 4, it is %d", q.Count())
+	}
+
+	// A rises then B rises before the edge of A is received: the steps are
+	// missed, both channels have changed
+	a.set(gpio.High)
+	b.set(gpio.High)
+	b.edge()
+	a.edge()
+	waitFor(t, func() bool { return q.Missed() == 2 })
+	if q.Count() != 4 {
+		t.Fatalf("count should be 4, it is %d", q.Count())
+	}
+
+	// The next step is decoded from the levels
+	a.set(gpio.Low)
+	a.edge()
+	waitFor(t, func() bool { return q.Count() == 5 })
+}
+
+func TestQuadratureMissed(t *testing.T) {
+	chip := gpio.NewFake()
+	a, b := chip.FakePin(1), chip.FakePin(2)
+	q := &Quadrature{
+		counter: counter{
+			cancel:    func() {},
+			done:      make(chan struct{}),
+			direction: 1,
+		},
+	}
+	events := make(chan gpio.Event)
+	go q.watch(a, b, events, true, gpio.Low, gpio.Low)
+
+	// The falling edge of A between the rising edges is missed
+	events <- gpio.Event{Pin: 1, Edge: gpio.RisingEdge}
+	events <- gpio.Event{Pin: 1, Edge: gpio.RisingEdge}
+	events <- gpio.Event{Pin: 2, Edge: gpio.RisingEdge}
+	close(events)
+	<-q.done
+
+	if q.Missed() != 1 {
+		t.Fatalf("should have missed 1 step, it has missed %d", q.Missed())
+	}
+	if q.Count() != 2 {
+		t.Fatalf("count should be 2, it is %d", q.Count())
+	}
+}
+
+func TestHall(t *testing.T) {
+	chip := gpio.NewFake()
+	pin := chip.FakePin(1)
+	h, err := NewHall(pin, gpio.PullDown)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer h.Close()
+
+	pulse := func(count int64) {
+		pin.Set(gpio.High)
+		waitFor(t, func() bool { return h.Count() == count })
+		pin.Set(gpio.Low)
+	}
+
+	pulse(1)
+	pulse(2)
+
+	// Only the rising edges are counted, in the direction of the motor
+	h.SetDirection(-1)
+	pulse(1)
+	pulse(0)
+	pulse(-1)
+}
+
+func TestCalibration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+
+	c, err := LoadCalibration(path)
+	if err != nil || c.Travel != 0 {
+		t.Fatalf("should not be calibrated yet, it is %d (%v)", c.Travel, err)
+	}
+
+	err = SaveCalibration(path, Calibration{Travel: 1234})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	c, err = LoadCalibration(path)
+	if err != nil || c.Travel != 1234 {
+		t.Fatalf("travel should be 1234, it is %d (%v)", c.Travel, err)
+	}
+}
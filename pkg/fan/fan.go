@@ -1,33 +1,70 @@
 package fan
 
 import (
-	"github.com/stianeikeland/go-rpio/v4"
+	"sync"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+
 	"github.com/sirupsen/logrus"
 )
 
 // Fan ...
 type Fan struct {
-	pin rpio.Pin
+	pin gpio.Pin
+
+	mu   sync.Mutex
+	isOn bool
 }
 
 // NewFan ...
-func NewFan(pin uint8) *Fan {
+func NewFan(pin gpio.Pin) *Fan {
 	f := &Fan{
-		pin: rpio.Pin(pin),
+		pin: pin,
+	}
+	if err := f.pin.Output(gpio.Low); err != nil {
+		logrus.Errorf("Error while configuring the pin of the fan: %s", err)
 	}
-	f.pin.Output()
-	f.pin.Low()
 	return f
 }
 
 // On ...
 func (f *Fan) On() {
-	logrus.Infoln("Fan is turned on")
-	f.pin.High()
+	f.set(true)
 }
 
 // Off ...
 func (f *Fan) Off() {
-	logrus.Infoln("Fan is turned off")
-	f.pin.Low()
-}
\ No newline at end of file
+	f.set(false)
+}
+
+// IsOn returns true if the fan is turned on.
+func (f *Fan) IsOn() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.isOn
+}
+
+// set turns the fan on or off.
+func (f *Fan) set(on bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if on != f.isOn {
+		if on {
+			logrus.Infoln("Fan is turned on")
+		} else {
+			logrus.Infoln("Fan is turned off")
+		}
+	}
+
+	level := gpio.Low
+	if on {
+		level = gpio.High
+	}
+	if err := f.pin.Output(level); err != nil {
+		logrus.Errorf("Error while setting the pin of the fan: %s", err)
+		return
+	}
+	f.isOn = on
+}
@@ -0,0 +1,27 @@
+package fan
+
+import (
+	"testing"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+)
+
+func TestFan(t *testing.T) {
+	chip := gpio.NewFake()
+	f := NewFan(chip.Pin(7))
+
+	pin := chip.FakePin(7)
+	if pin.Mode() != gpio.OutputMode || pin.Level() != gpio.Low {
+		t.Fatal("fan should be off at startup")
+	}
+
+	f.On()
+	if !f.IsOn() || pin.Level() != gpio.High {
+		t.Fatal("fan should be on")
+	}
+
+	f.Off()
+	if f.IsOn() || pin.Level() != gpio.Low {
+		t.Fatal("fan should be off")
+	}
+}
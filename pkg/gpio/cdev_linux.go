@@ -0,0 +1,473 @@
+//go:build linux
+// +build linux
+
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Flags, attributes and limits of the GPIO character device, see linux/gpio.h.
+const (
+	lineFlagInput        = 1 << 2
+	lineFlagOutput       = 1 << 3
+	lineFlagEdgeRising   = 1 << 4
+	lineFlagEdgeFalling  = 1 << 5
+	lineFlagBiasPullUp   = 1 << 8
+	lineFlagBiasPullDown = 1 << 9
+	lineFlagBiasDisabled = 1 << 10
+
+	lineAttrIDOutputValues = 2
+
+	lineEventRisingEdge = 1
+
+	linesMax    = 64
+	nameSize    = 32
+	numAttrsMax = 10
+)
+
+// Consumer is the name of the consumer of the lines, displayed by gpioinfo.
+const Consumer = "gocoop"
+
+// watchTimeout is the interval between two checks of the context while watching the edges.
+const watchTimeout = 100
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// lineAttribute is struct gpio_v2_line_attribute.
+type lineAttribute struct {
+	ID      uint32
+	Padding uint32
+	Value   uint64
+}
+
+// lineConfigAttribute is struct gpio_v2_line_config_attribute.
+type lineConfigAttribute struct {
+	Attr lineAttribute
+	Mask uint64
+}
+
+// lineConfig is struct gpio_v2_line_config.
+type lineConfig struct {
+	Flags    uint64
+	NumAttrs uint32
+	Padding  [5]uint32
+	Attrs    [numAttrsMax]lineConfigAttribute
+}
+
+// lineRequest is struct gpio_v2_line_request.
+type lineRequest struct {
+	Offsets         [linesMax]uint32
+	Consumer        [nameSize]byte
+	Config          lineConfig
+	NumLines        uint32
+	EventBufferSize uint32
+	Padding         [5]uint32
+	Fd              int32
+}
+
+// lineValues is struct gpio_v2_line_values.
+type lineValues struct {
+	Bits uint64
+	Mask uint64
+}
+
+// lineEvent is struct gpio_v2_line_event.
+type lineEvent struct {
+	Timestamp uint64
+	ID        uint32
+	Offset    uint32
+	Seqno     uint32
+	LineSeqno uint32
+	Padding   [6]uint32
+}
+
+// Requests of the GPIO character device.
+var (
+	getLineIoctl   = iowr(0x07, unsafe.Sizeof(lineRequest{}))
+	getValuesIoctl = iowr(0x0E, unsafe.Sizeof(lineValues{}))
+	setValuesIoctl = iowr(0x0F, unsafe.Sizeof(lineValues{}))
+)
+
+// cdevChip is a GPIO chip accessed through its character device, such as /dev/gpiochip0.
+type cdevChip struct {
+	f *os.File
+
+	mu   sync.Mutex
+	pins map[int]*cdevPin
+}
+
+// cdevPin is a line of the chip. The line is requested again every time the pin is configured.
+type cdevPin struct {
+	chip   *cdevChip
+	offset int
+
+	mu      sync.Mutex
+	fd      int
+	flags   uint64
+	pwmStop context.CancelFunc
+	pwmDone chan struct{}
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewCdev returns the GPIO chip of the character device.
+func NewCdev(path string) (Chip, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the GPIO chip: %s", err)
+	}
+
+	return &cdevChip{
+		f:    f,
+		pins: make(map[int]*cdevPin),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Pin returns the line with the offset.
+func (c *cdevChip) Pin(number int) Pin {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pins[number]
+	if !ok {
+		p = &cdevPin{
+			chip:   c,
+			offset: number,
+			fd:     -1,
+		}
+		c.pins[number] = p
+	}
+
+	return p
+}
+
+// Close releases the lines and closes the chip.
+func (c *cdevChip) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range c.pins {
+		p.mu.Lock()
+		p.release()
+		p.mu.Unlock()
+	}
+
+	return c.f.Close()
+}
+
+// Number returns the offset of the line.
+func (p *cdevPin) Number() int {
+	return p.offset
+}
+
+// Input configures the line as an input with the pull resistor.
+func (p *cdevPin) Input(pull Pull) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.request(lineFlagInput|bias(pull), Low)
+}
+
+// Output configures the line as an output at the level.
+func (p *cdevPin) Output(level Level) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.flags == lineFlagOutput && p.pwmStop == nil {
+		return setValue(p.fd, level)
+	}
+
+	return p.request(lineFlagOutput, level)
+}
+
+// Read returns the level of the line.
+func (p *cdevPin) Read() (Level, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fd < 0 {
+		return Low, fmt.Errorf("line %d is not configured", p.offset)
+	}
+
+	values := lineValues{Mask: 1}
+	err := ioctl(p.fd, getValuesIoctl, unsafe.Pointer(&values))
+	if err != nil {
+		return Low, fmt.Errorf("error while reading the line %d: %s", p.offset, err)
+	}
+
+	return Level(values.Bits & 1), nil
+}
+
+// Write sets the level of an output.
+func (p *cdevPin) Write(level Level) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.flags != lineFlagOutput {
+		return fmt.Errorf("line %d is not an output", p.offset)
+	}
+	p.stopPWM()
+
+	return setValue(p.fd, level)
+}
+
+// PWM drives the line with a software PWM, the character device has no
+// hardware PWM. It is precise enough for a motor, not for a servo.
+func (p *cdevPin) PWM(frequency int, dutyCycle float64) error {
+	switch {
+	case dutyCycle <= 0 || frequency <= 0:
+		return p.Output(Low)
+	case dutyCycle >= 1:
+		return p.Output(High)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.request(lineFlagOutput, Low)
+	if err != nil {
+		return err
+	}
+
+	period := time.Second / time.Duration(frequency)
+	high := time.Duration(float64(period) * dutyCycle)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.pwmStop = cancel
+	p.pwmDone = done
+
+	go func(fd int) {
+		defer close(done)
+
+		for ctx.Err() == nil {
+			setValue(fd, High)
+			time.Sleep(high)
+			setValue(fd, Low)
+			time.Sleep(period - high)
+		}
+	}(p.fd)
+
+	return nil
+}
+
+// Watch returns the edges of the input detected by the kernel. The pin must
+// not be configured again while it is watched.
+func (p *cdevPin) Watch(ctx context.Context, edge Edge, pull Pull) (<-chan Event, error) {
+	p.mu.Lock()
+	err := p.request(uint64(lineFlagInput)|bias(pull)|edgeFlags(edge), Low)
+	fd := p.fd
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event, EventBufferSize)
+	go func() {
+		defer close(events)
+		readEvents(ctx, fd, events)
+	}()
+
+	return events, nil
+}
+
+// watchGroup requests the lines together, so that the kernel queues their
+// edges in the order they happened. The lines are released when the context
+// is done, the pins must not be configured again while they are watched.
+func (p *cdevPin) watchGroup(ctx context.Context, pins []Pin, edge Edge, pull Pull) (<-chan Event, []Level, bool, error) {
+	if len(pins) > linesMax {
+		return nil, nil, false, nil
+	}
+
+	req := lineRequest{NumLines: uint32(len(pins))}
+	for i, pin := range pins {
+		line, ok := pin.(*cdevPin)
+		if !ok || line.chip != p.chip {
+			return nil, nil, false, nil
+		}
+		req.Offsets[i] = uint32(line.offset)
+
+		// The lines cannot be requested twice
+		line.mu.Lock()
+		line.release()
+		line.mu.Unlock()
+	}
+	copy(req.Consumer[:], Consumer)
+	req.Config.Flags = uint64(lineFlagInput) | bias(pull) | edgeFlags(edge)
+
+	err := ioctl(int(p.chip.f.Fd()), getLineIoctl, unsafe.Pointer(&req))
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("error while requesting the lines %v: %s", req.Offsets[:len(pins)], err)
+	}
+	fd := int(req.Fd)
+
+	values := lineValues{Mask: 1<<uint(len(pins)) - 1}
+	err = ioctl(fd, getValuesIoctl, unsafe.Pointer(&values))
+	if err != nil {
+		unix.Close(fd)
+		return nil, nil, false, fmt.Errorf("error while reading the lines %v: %s", req.Offsets[:len(pins)], err)
+	}
+	levels := make([]Level, len(pins))
+	for i := range levels {
+		levels[i] = Level(values.Bits >> uint(i) & 1)
+	}
+
+	events := make(chan Event, EventBufferSize*len(pins))
+	go func() {
+		defer close(events)
+		defer unix.Close(fd)
+		readEvents(ctx, fd, events)
+	}()
+
+	return events, levels, true, nil
+}
+
+// request requests the line with the flags. The lock must be held by the caller.
+func (p *cdevPin) request(flags uint64, level Level) error {
+	p.release()
+
+	req := lineRequest{NumLines: 1}
+	req.Offsets[0] = uint32(p.offset)
+	copy(req.Consumer[:], Consumer)
+	req.Config.Flags = flags
+	if flags&lineFlagOutput != 0 {
+		req.Config.NumAttrs = 1
+		req.Config.Attrs[0] = lineConfigAttribute{
+			Attr: lineAttribute{ID: lineAttrIDOutputValues, Value: uint64(level)},
+			Mask: 1,
+		}
+	}
+
+	err := ioctl(int(p.chip.f.Fd()), getLineIoctl, unsafe.Pointer(&req))
+	if err != nil {
+		return fmt.Errorf("error while requesting the line %d: %s", p.offset, err)
+	}
+	p.fd = int(req.Fd)
+	p.flags = flags
+
+	return nil
+}
+
+// release stops the PWM and releases the line. The lock must be held by the caller.
+func (p *cdevPin) release() {
+	p.stopPWM()
+
+	if p.fd >= 0 {
+		unix.Close(p.fd)
+		p.fd = -1
+		p.flags = 0
+	}
+}
+
+// stopPWM stops the software PWM. The lock must be held by the caller.
+func (p *cdevPin) stopPWM() {
+	if p.pwmStop == nil {
+		return
+	}
+
+	p.pwmStop()
+	<-p.pwmDone
+	p.pwmStop = nil
+	p.pwmDone = nil
+}
+
+// setValue sets the level of the line.
+func setValue(fd int, level Level) error {
+	values := lineValues{Bits: uint64(level), Mask: 1}
+	err := ioctl(fd, setValuesIoctl, unsafe.Pointer(&values))
+	if err != nil {
+		return fmt.Errorf("error while writing the line: %s", err)
+	}
+
+	return nil
+}
+
+// readEvents sends the edges of the line request until the context is done.
+func readEvents(ctx context.Context, fd int, events chan<- Event) {
+	var ev lineEvent
+	buf := (*[unsafe.Sizeof(lineEvent{})]byte)(unsafe.Pointer(&ev))[:]
+	for ctx.Err() == nil {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, watchTimeout)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+
+		_, err = unix.Read(fd, buf)
+		if err != nil {
+			return
+		}
+
+		e := FallingEdge
+		if ev.ID == lineEventRisingEdge {
+			e = RisingEdge
+		}
+		send(events, Event{Pin: int(ev.Offset), Edge: e, Time: time.Now()})
+	}
+}
+
+// edgeFlags returns the flags of the edge detection.
+func edgeFlags(edge Edge) uint64 {
+	switch edge {
+	case RisingEdge:
+		return lineFlagEdgeRising
+	case FallingEdge:
+		return lineFlagEdgeFalling
+	case BothEdges:
+		return lineFlagEdgeRising | lineFlagEdgeFalling
+	}
+
+	return 0
+}
+
+// bias returns the flags of the pull resistor.
+func bias(pull Pull) uint64 {
+	switch pull {
+	case PullUp:
+		return lineFlagBiasPullUp
+	case PullDown:
+		return lineFlagBiasPullDown
+	}
+
+	return lineFlagBiasDisabled
+}
+
+// iowr returns the number of a read/write request of the GPIO character device.
+func iowr(nr, size uintptr) uintptr {
+	return 3<<30 | size<<16 | 0xB4<<8 | nr
+}
+
+// ioctl calls the request on the file descriptor.
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
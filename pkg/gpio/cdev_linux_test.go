@@ -0,0 +1,33 @@
+//go:build linux
+// +build linux
+
+package gpio
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestLayout checks the structures against the sizes of linux/gpio.h.
+func TestLayout(t *testing.T) {
+	tests := map[string]struct {
+		size     uintptr
+		expected uintptr
+	}{
+		"gpio_v2_line_attribute":        {unsafe.Sizeof(lineAttribute{}), 16},
+		"gpio_v2_line_config_attribute": {unsafe.Sizeof(lineConfigAttribute{}), 24},
+		"gpio_v2_line_config":           {unsafe.Sizeof(lineConfig{}), 272},
+		"gpio_v2_line_request":          {unsafe.Sizeof(lineRequest{}), 592},
+		"gpio_v2_line_values":           {unsafe.Sizeof(lineValues{}), 16},
+		"gpio_v2_line_event":            {unsafe.Sizeof(lineEvent{}), 48},
+	}
+	for name, test := range tests {
+		if test.size != test.expected {
+			t.Errorf("size of %s should be %d, it is %d", name, test.expected, test.size)
+		}
+	}
+
+	if getLineIoctl != 0xC250B407 {
+		t.Errorf("GPIO_V2_GET_LINE_IOCTL is incorrect: %#x", getLineIoctl)
+	}
+}
@@ -0,0 +1,335 @@
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Mode is the mode of a fake pin.
+type Mode int
+
+const (
+	// Unset when the pin has not been configured.
+	Unset Mode = iota
+
+	// InputMode when the pin is an input.
+	InputMode
+
+	// OutputMode when the pin is an output.
+	OutputMode
+
+	// PWMMode when the pin is driven with a PWM.
+	PWMMode
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Fake is an in-memory GPIO chip, for the tests and the simulator.
+type Fake struct {
+	mu   sync.Mutex
+	pins map[int]*FakePin
+}
+
+// FakePin is a pin of the fake chip. The level of an input is set with Set,
+// as if it was driven by the outside.
+type FakePin struct {
+	number int
+
+	mu        sync.Mutex
+	mode      Mode
+	pull      Pull
+	level     Level
+	frequency int
+	dutyCycle float64
+	watchers  []*watcher
+	onWrite   func(Level)
+}
+
+// watcher is a watcher of the edges of a fake pin.
+type watcher struct {
+	ctx    context.Context
+	edge   Edge
+	events chan Event
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewFake returns a new Fake chip.
+func NewFake() *Fake {
+	return &Fake{
+		pins: make(map[int]*FakePin),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Pin returns the pin with the number.
+func (f *Fake) Pin(number int) Pin {
+	return f.FakePin(number)
+}
+
+// FakePin returns the pin with the number, to inspect it or drive it in the tests.
+func (f *Fake) FakePin(number int) *FakePin {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.pins[number]
+	if !ok {
+		p = &FakePin{number: number}
+		f.pins[number] = p
+	}
+
+	return p
+}
+
+// Close does nothing.
+func (f *Fake) Close() error {
+	return nil
+}
+
+// Number returns the number of the pin.
+func (p *FakePin) Number() int {
+	return p.number
+}
+
+// Input configures the pin as an input with the pull resistor. The level
+// follows the pull resistor until it is set.
+func (p *FakePin) Input(pull Pull) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mode != InputMode {
+		switch pull {
+		case PullUp:
+			p.level = High
+		case PullDown:
+			p.level = Low
+		}
+	}
+	p.mode = InputMode
+	p.pull = pull
+
+	return nil
+}
+
+// Output configures the pin as an output at the level.
+func (p *FakePin) Output(level Level) error {
+	p.mu.Lock()
+	p.mode = OutputMode
+	p.frequency = 0
+	p.dutyCycle = 0
+	p.mu.Unlock()
+
+	return p.write(level)
+}
+
+// Read returns the level of the pin.
+func (p *FakePin) Read() (Level, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.level, nil
+}
+
+// Write sets the level of an output.
+func (p *FakePin) Write(level Level) error {
+	p.mu.Lock()
+	mode := p.mode
+	p.mu.Unlock()
+
+	if mode != OutputMode {
+		return fmt.Errorf("pin %d is not an output", p.number)
+	}
+
+	return p.write(level)
+}
+
+// PWM drives the pin with the frequency and the duty cycle. The level is
+// high as long as the duty cycle is not 0.
+func (p *FakePin) PWM(frequency int, dutyCycle float64) error {
+	p.mu.Lock()
+	p.mode = PWMMode
+	p.frequency = frequency
+	p.dutyCycle = dutyCycle
+	p.mu.Unlock()
+
+	if dutyCycle > 0 {
+		return p.write(High)
+	}
+
+	return p.write(Low)
+}
+
+// Watch configures the pin as an input and returns its edges.
+func (p *FakePin) Watch(ctx context.Context, edge Edge, pull Pull) (<-chan Event, error) {
+	err := p.Input(pull)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		ctx:    ctx,
+		edge:   edge,
+		events: make(chan Event, EventBufferSize),
+	}
+
+	p.mu.Lock()
+	p.watchers = append(p.watchers, w)
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.unwatch(w)
+		close(w.events)
+	}()
+
+	return w.events, nil
+}
+
+// watchGroup watches the fake pins with a single watcher, their edges are sent
+// in the order they are set.
+func (p *FakePin) watchGroup(ctx context.Context, pins []Pin, edge Edge, pull Pull) (<-chan Event, []Level, bool, error) {
+	fakes := make([]*FakePin, len(pins))
+	for i, pin := range pins {
+		fake, ok := pin.(*FakePin)
+		if !ok {
+			return nil, nil, false, nil
+		}
+		fakes[i] = fake
+	}
+
+	w := &watcher{
+		ctx:    ctx,
+		edge:   edge,
+		events: make(chan Event, EventBufferSize*len(pins)),
+	}
+
+	levels := make([]Level, len(fakes))
+	for i, fake := range fakes {
+		err := fake.Input(pull)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		fake.mu.Lock()
+		fake.watchers = append(fake.watchers, w)
+		levels[i] = fake.level
+		fake.mu.Unlock()
+	}
+
+	go func() {
+		<-ctx.Done()
+		for _, fake := range fakes {
+			fake.unwatch(w)
+		}
+		close(w.events)
+	}()
+
+	return w.events, levels, true, nil
+}
+
+// Set sets the level of an input, as if it was driven by the outside, and
+// sends the edge to the watchers.
+func (p *FakePin) Set(level Level) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.level == level {
+		return
+	}
+	p.level = level
+
+	ev := Event{Pin: p.number, Edge: edgeOf(level), Time: time.Now()}
+	for _, w := range p.watchers {
+		if w.ctx.Err() == nil && matches(w.edge, ev.Edge) {
+			send(w.events, ev)
+		}
+	}
+}
+
+// OnWrite sets a function called every time the level of the output is written.
+func (p *FakePin) OnWrite(fn func(Level)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onWrite = fn
+}
+
+// Mode returns the mode of the pin.
+func (p *FakePin) Mode() Mode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.mode
+}
+
+// Pull returns the pull resistor of the input.
+func (p *FakePin) Pull() Pull {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pull
+}
+
+// Level returns the level of the pin.
+func (p *FakePin) Level() Level {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.level
+}
+
+// DutyCycle returns the duty cycle of the PWM.
+func (p *FakePin) DutyCycle() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.dutyCycle
+}
+
+// Frequency returns the frequency of the PWM.
+func (p *FakePin) Frequency() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.frequency
+}
+
+// unwatch removes the watcher.
+func (p *FakePin) unwatch(w *watcher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, other := range p.watchers {
+		if other == w {
+			p.watchers = append(p.watchers[:i], p.watchers[i+1:]...)
+			break
+		}
+	}
+}
+
+// write sets the level and calls the function watching the writes.
+func (p *FakePin) write(level Level) error {
+	p.mu.Lock()
+	p.level = level
+	onWrite := p.onWrite
+	p.mu.Unlock()
+
+	if onWrite != nil {
+		onWrite(level)
+	}
+
+	return nil
+}
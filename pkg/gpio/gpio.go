@@ -0,0 +1,242 @@
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Level is the level of a pin.
+type Level int
+
+const (
+	// Low level.
+	Low Level = 0
+
+	// High level.
+	High Level = 1
+)
+
+// Pull is the pull resistor of an input.
+type Pull int
+
+const (
+	// PullNone disables the pull resistor.
+	PullNone Pull = iota
+
+	// PullUp enables the pull-up resistor.
+	PullUp
+
+	// PullDown enables the pull-down resistor.
+	PullDown
+)
+
+// Edge is a change of the level of an input.
+type Edge int
+
+const (
+	// NoEdge does not detect any edge.
+	NoEdge Edge = iota
+
+	// RisingEdge when the level goes from low to high.
+	RisingEdge
+
+	// FallingEdge when the level goes from high to low.
+	FallingEdge
+
+	// BothEdges detects the rising and the falling edges.
+	BothEdges
+)
+
+// DefaultPollInterval is the default interval between two readings of an input
+// watched by polling. The edges shorter than the interval are missed, it is
+// enough for the limit switches but not for the encoders of fast motors.
+const DefaultPollInterval = time.Millisecond
+
+// EventBufferSize is the number of events buffered for each watcher.
+const EventBufferSize = 16
+
+//------------------------------------------------------------------------------
+// Interfaces
+//------------------------------------------------------------------------------
+
+// Event is an edge detected on an input.
+type Event struct {
+	Pin  int
+	Edge Edge
+	Time time.Time
+}
+
+// Pin is a pin of a GPIO chip.
+type Pin interface {
+	// Number returns the number of the pin on the chip.
+	Number() int
+
+	// Input configures the pin as an input with the pull resistor.
+	Input(pull Pull) error
+
+	// Output configures the pin as an output at the level.
+	Output(level Level) error
+
+	// Read returns the level of the pin.
+	Read() (Level, error)
+
+	// Write sets the level of an output.
+	Write(level Level) error
+
+	// PWM drives the pin with a frequency in Hertz and a duty cycle between 0 and 1.
+	PWM(frequency int, dutyCycle float64) error
+
+	// Watch configures the pin as an input and returns its edges until the context is done.
+	Watch(ctx context.Context, edge Edge, pull Pull) (<-chan Event, error)
+}
+
+// Chip is a GPIO chip.
+type Chip interface {
+	// Pin returns the pin with the number.
+	Pin(number int) Pin
+
+	// Close releases the chip and its pins.
+	Close() error
+}
+
+// groupWatcher is a pin that can be watched together with other pins of its
+// chip, with a single request.
+type groupWatcher interface {
+	// watchGroup returns false if the pins cannot be watched together.
+	watchGroup(ctx context.Context, pins []Pin, edge Edge, pull Pull) (<-chan Event, []Level, bool, error)
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// WatchGroup configures the pins as inputs and returns their edges on a single
+// channel until the context is done, with their levels when the watch starts.
+// It returns true if the edges are received in the order they happened, when
+// the chip watches the pins with a single request. Otherwise every pin is
+// watched on its own and the edges of different pins can be received out of
+// order.
+func WatchGroup(ctx context.Context, pins []Pin, edge Edge, pull Pull) (<-chan Event, []Level, bool, error) {
+	if len(pins) == 0 {
+		return nil, nil, false, fmt.Errorf("no pin to watch")
+	}
+
+	if g, ok := pins[0].(groupWatcher); ok {
+		events, levels, ok, err := g.watchGroup(ctx, pins, edge, pull)
+		if ok || err != nil {
+			return events, levels, ok, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	watched := make([]<-chan Event, len(pins))
+	for i, pin := range pins {
+		events, err := pin.Watch(ctx, edge, pull)
+		if err != nil {
+			cancel()
+			return nil, nil, false, fmt.Errorf("error while watching the pin %d: %s", pin.Number(), err)
+		}
+		watched[i] = events
+	}
+
+	levels := make([]Level, len(pins))
+	for i, pin := range pins {
+		level, err := pin.Read()
+		if err != nil {
+			cancel()
+			return nil, nil, false, fmt.Errorf("error while reading the pin %d: %s", pin.Number(), err)
+		}
+		levels[i] = level
+	}
+
+	// The edges are merged, the channel is closed once all the pins are closed
+	events := make(chan Event, EventBufferSize*len(pins))
+	var wg sync.WaitGroup
+	for i, pin := range pins {
+		wg.Add(1)
+		go func(number int, watched <-chan Event) {
+			defer wg.Done()
+
+			for ev := range watched {
+				ev.Pin = number
+				send(events, ev)
+			}
+		}(pin.Number(), watched[i])
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(events)
+	}()
+
+	return events, levels, false, nil
+}
+
+// poll watches the edges of an input by reading it at every interval.
+func poll(ctx context.Context, pin Pin, edge Edge, pull Pull, interval time.Duration) (<-chan Event, error) {
+	err := pin.Input(pull)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := pin.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event, EventBufferSize)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				level, err := pin.Read()
+				if err != nil || level == last {
+					continue
+				}
+				last = level
+
+				e := edgeOf(level)
+				if matches(edge, e) {
+					send(events, Event{Pin: pin.Number(), Edge: e, Time: now})
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// edgeOf returns the edge that leads to the level.
+func edgeOf(level Level) Edge {
+	if level == High {
+		return RisingEdge
+	}
+
+	return FallingEdge
+}
+
+// matches returns true if the edge is watched.
+func matches(watched, edge Edge) bool {
+	return watched == BothEdges || watched == edge
+}
+
+// send sends the event without blocking, it is dropped if the watcher is too slow.
+func send(events chan<- Event, ev Event) {
+	select {
+	case events <- ev:
+	default:
+	}
+}
@@ -0,0 +1,147 @@
+package gpio
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeWatch(t *testing.T) {
+	p := NewFake().FakePin(17)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := p.Watch(ctx, FallingEdge, PullUp)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if p.Level() != High {
+		t.Fatal("input should be pulled up")
+	}
+
+	p.Set(Low)
+	p.Set(High)
+	p.Set(Low)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-events:
+			if ev.Edge != FallingEdge {
+				t.Fatalf("should only receive falling edges, it is %v", ev.Edge)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("should receive the falling edge")
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestFakeWrite(t *testing.T) {
+	p := NewFake().FakePin(18)
+
+	err := p.Write(High)
+	if err == nil {
+		t.Fatal("should not write a pin that is not an output")
+	}
+
+	err = p.Output(High)
+	if err != nil || p.Level() != High || p.Mode() != OutputMode {
+		t.Fatalf("should be a high output, error is %v", err)
+	}
+
+	err = p.PWM(50, 0.4)
+	if err != nil || p.Mode() != PWMMode || p.Frequency() != 50 || p.DutyCycle() != 0.4 {
+		t.Fatalf("should be driven by the PWM, error is %v", err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	p := NewFake().FakePin(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := poll(ctx, p, BothEdges, PullDown, DefaultPollInterval)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	p.Set(High)
+	select {
+	case ev := <-events:
+		if ev.Edge != RisingEdge {
+			t.Fatalf("should be a rising edge, it is %v", ev.Edge)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("should detect the rising edge")
+	}
+}
+
+// plainPin hides the group watch of the pin.
+type plainPin struct {
+	Pin
+}
+
+func TestWatchGroup(t *testing.T) {
+	chip := NewFake()
+	a, b := chip.FakePin(5), chip.FakePin(6)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, levels, ordered, err := WatchGroup(ctx, []Pin{a, b}, BothEdges, PullUp)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if !ordered {
+		t.Fatal("the fake pins should be watched together")
+	}
+	if levels[0] != High || levels[1] != High {
+		t.Fatalf("inputs should be pulled up, they are %v", levels)
+	}
+
+	// The edges are received in the order they happened
+	a.Set(Low)
+	b.Set(Low)
+	a.Set(High)
+	for _, expected := range []Event{{Pin: 5, Edge: FallingEdge}, {Pin: 6, Edge: FallingEdge}, {Pin: 5, Edge: RisingEdge}} {
+		select {
+		case ev := <-events:
+			if ev.Pin != expected.Pin || ev.Edge != expected.Edge {
+				t.Fatalf("should be the edge %v of the pin %d, it is the edge %v of the pin %d", expected.Edge, expected.Pin, ev.Edge, ev.Pin)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("should receive the edge")
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+
+	// Otherwise the pins are watched on their own
+	ctx, cancel = context.WithCancel(context.Background())
+	events, levels, ordered, err = WatchGroup(ctx, []Pin{plainPin{a}, plainPin{b}}, BothEdges, PullUp)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ordered {
+		t.Fatal("the pins should be watched on their own")
+	}
+	if levels[0] != High || levels[1] != Low {
+		t.Fatalf("levels should be the current ones, they are %v", levels)
+	}
+
+	b.Set(High)
+	select {
+	case ev := <-events:
+		if ev.Pin != 6 || ev.Edge != RisingEdge {
+			t.Fatalf("should be the rising edge of the pin 6, it is the edge %v of the pin %d", ev.Edge, ev.Pin)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("should receive the edge")
+	}
+
+	cancel()
+	for range events {
+	}
+}
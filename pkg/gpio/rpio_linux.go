@@ -0,0 +1,143 @@
+//go:build linux
+// +build linux
+
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stianeikeland/go-rpio/v4"
+)
+
+// pwmCycle is the length of a PWM cycle, the duty cycle has a resolution of
+// 0.1%, fine enough for the pulse of a servo.
+const pwmCycle = 1000
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// rpioChip is the GPIO of a Raspberry Pi accessed through /dev/gpiomem with go-rpio.
+// It has no edge detection, the inputs are watched by polling.
+type rpioChip struct {
+	pollInterval time.Duration
+}
+
+// rpioPin is a pin of the Raspberry Pi, numbered as BCM.
+type rpioPin struct {
+	number       int
+	pin          rpio.Pin
+	pollInterval time.Duration
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewRPIO returns the GPIO of the Raspberry Pi. The memory is mapped once
+// and stays mapped until the chip is closed. The watched inputs are read at
+// every poll interval, DefaultPollInterval if it is not set.
+func NewRPIO(pollInterval time.Duration) (Chip, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	err := rpio.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the GPIO: %s", err)
+	}
+
+	return &rpioChip{
+		pollInterval: pollInterval,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Pin returns the pin with the BCM number.
+func (c *rpioChip) Pin(number int) Pin {
+	return &rpioPin{
+		number:       number,
+		pin:          rpio.Pin(number),
+		pollInterval: c.pollInterval,
+	}
+}
+
+// Close unmaps the memory of the GPIO.
+func (c *rpioChip) Close() error {
+	return rpio.Close()
+}
+
+// Number returns the BCM number of the pin.
+func (p *rpioPin) Number() int {
+	return p.number
+}
+
+// Input configures the pin as an input with the pull resistor.
+func (p *rpioPin) Input(pull Pull) error {
+	p.pin.Input()
+
+	switch pull {
+	case PullUp:
+		p.pin.PullUp()
+	case PullDown:
+		p.pin.PullDown()
+	default:
+		p.pin.PullOff()
+	}
+
+	return nil
+}
+
+// Output configures the pin as an output at the level.
+func (p *rpioPin) Output(level Level) error {
+	p.pin.Output()
+
+	return p.Write(level)
+}
+
+// Read returns the level of the pin.
+func (p *rpioPin) Read() (Level, error) {
+	if p.pin.Read() == rpio.High {
+		return High, nil
+	}
+
+	return Low, nil
+}
+
+// Write sets the level of an output.
+func (p *rpioPin) Write(level Level) error {
+	if level == High {
+		p.pin.High()
+	} else {
+		p.pin.Low()
+	}
+
+	return nil
+}
+
+// PWM drives the pin with the hardware PWM, only the pins 12, 13, 18 and 19
+// support it. A duty cycle of 0 or 1 sets the pin as a plain output.
+func (p *rpioPin) PWM(frequency int, dutyCycle float64) error {
+	switch {
+	case dutyCycle <= 0:
+		return p.Output(Low)
+	case dutyCycle >= 1:
+		return p.Output(High)
+	}
+
+	p.pin.Pwm()
+	p.pin.Freq(frequency * pwmCycle)
+	p.pin.DutyCycle(uint32(dutyCycle*pwmCycle+0.5), pwmCycle)
+
+	return nil
+}
+
+// Watch returns the edges of the input, detected by polling.
+func (p *rpioPin) Watch(ctx context.Context, edge Edge, pull Pull) (<-chan Event, error) {
+	return poll(ctx, p, edge, pull, p.pollInterval)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package gpio
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupported is raised when the GPIO is not supported by the operating system.
+var ErrUnsupported = errors.New("GPIO is only supported on Linux")
+
+// NewRPIO is not supported outside of Linux, use the fake chip instead.
+func NewRPIO(pollInterval time.Duration) (Chip, error) {
+	return nil, ErrUnsupported
+}
+
+// NewCdev is not supported outside of Linux, use the fake chip instead.
+func NewCdev(path string) (Chip, error) {
+	return nil, ErrUnsupported
+}
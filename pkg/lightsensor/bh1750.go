@@ -0,0 +1,76 @@
+package lightsensor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Instructions of the BH1750.
+const (
+	bh1750PowerOn = 0x01
+
+	// bh1750OneTimeHighRes measures once at 1 lux resolution, then powers down.
+	bh1750OneTimeHighRes = 0x20
+)
+
+// bh1750MeasurementTime is the maximum duration of a measurement at high resolution.
+const bh1750MeasurementTime = 180 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// bh1750 is a digital light sensor from 1 to 65535 lux, on an I2C bus.
+type bh1750 struct {
+	conn adc.Conn
+
+	mu sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewBH1750 returns a new BH1750.
+func NewBH1750(conn adc.Conn) Sensor {
+	return &bh1750{
+		conn: conn,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read starts a one-time measurement and returns the illuminance.
+func (s *bh1750) Read() (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.conn.Tx([]byte{bh1750PowerOn}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while powering on the BH1750: %s", err)
+	}
+	err = s.conn.Tx([]byte{bh1750OneTimeHighRes}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while starting the measurement of the BH1750: %s", err)
+	}
+
+	time.Sleep(bh1750MeasurementTime)
+
+	r := make([]byte, 2)
+	err = s.conn.Tx(nil, r)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the BH1750: %s", err)
+	}
+	raw := uint16(r[0])<<8 | uint16(r[1])
+
+	return float64(raw) / 1.2, nil
+}
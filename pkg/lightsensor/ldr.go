@@ -0,0 +1,72 @@
+package lightsensor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fallais/gocoop/pkg/adc"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// LDRSettings are the settings of a light dependent resistor. The LDR is
+// between the supply and the channel, the resistor is between the channel and
+// the ground, so the voltage rises with the light.
+type LDRSettings struct {
+	// Supply is the voltage of the divider, in volts.
+	Supply float64
+
+	// Resistor is the fixed resistor of the divider, in ohms.
+	Resistor float64
+
+	// R10 is the resistance of the LDR at 10 lux, in ohms.
+	R10 float64
+
+	// Gamma is the slope of the resistance of the LDR against the illuminance,
+	// on a logarithmic scale. It is given by the datasheet, around 0.7.
+	Gamma float64
+}
+
+// ldr is a light dependent resistor in a voltage divider, read by a converter.
+type ldr struct {
+	adc      adc.ADC
+	channel  int
+	settings LDRSettings
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewLDR returns a new LDR on the channel of the converter.
+func NewLDR(a adc.ADC, channel int, settings LDRSettings) Sensor {
+	return &ldr{
+		adc:      a,
+		channel:  channel,
+		settings: settings,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read returns the illuminance from the voltage of the divider.
+func (s *ldr) Read() (float64, error) {
+	volts, err := s.adc.Read(s.channel)
+	if err != nil {
+		return 0, err
+	}
+	if volts <= 0 {
+		return 0, nil
+	}
+	if volts >= s.settings.Supply {
+		return 0, fmt.Errorf("voltage of the LDR is above the supply: %.3fV", volts)
+	}
+
+	resistance := s.settings.Resistor * (s.settings.Supply - volts) / volts
+
+	return 10 * math.Pow(s.settings.R10/resistance, 1/s.settings.Gamma), nil
+}
@@ -0,0 +1,11 @@
+package lightsensor
+
+//------------------------------------------------------------------------------
+// Interfaces
+//------------------------------------------------------------------------------
+
+// Sensor is an ambient light sensor.
+type Sensor interface {
+	// Read returns the illuminance, in lux.
+	Read() (float64, error)
+}
@@ -0,0 +1,88 @@
+package lightsensor
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/fallais/gocoop/pkg/adc/adctest"
+)
+
+func TestBH1750(t *testing.T) {
+	conn := adctest.NewConn([]byte{0x01, 0x2C})
+	s := NewBH1750(conn)
+
+	lux, err := s.Read()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	writes := conn.Writes()
+	if !bytes.Equal(writes[0], []byte{0x01}) || !bytes.Equal(writes[1], []byte{0x20}) {
+		t.Fatalf("should power on and start a one-time measurement, it wrote %x", writes)
+	}
+	if math.Abs(lux-250) > 1e-9 {
+		t.Fatalf("should be 250 lux, it is %v", lux)
+	}
+}
+
+func TestTSL2561(t *testing.T) {
+	conn := adctest.NewConn(
+		[]byte{0x64, 0x00}, // broadband, little-endian
+		[]byte{0x0A, 0x00}, // infrared
+	)
+	s := NewTSL2561(conn)
+
+	lux, err := s.Read()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	writes := conn.Writes()
+	if !bytes.Equal(writes[0], []byte{0x80, 0x03}) || !bytes.Equal(writes[1], []byte{0x81, 0x02}) {
+		t.Fatalf("should power on at the low gain, it wrote %x", writes)
+	}
+	if !bytes.Equal(writes[len(writes)-1], []byte{0x80, 0x00}) {
+		t.Fatalf("should power off, it wrote %x", writes)
+	}
+
+	// Ratio of 0.1
+	expected := 0.0304*1600 - 0.062*1600*math.Pow(0.1, 1.4)
+	if math.Abs(lux-expected) > 1e-9 {
+		t.Fatalf("should be %v lux, it is %v", expected, lux)
+	}
+
+	if tsl2561Lux(0, 0) != 0 || tsl2561Lux(100, 200) != 0 {
+		t.Fatalf("should be dark")
+	}
+	if tsl2561Lux(math.MaxUint16, 100) < 30000 {
+		t.Fatalf("should be bright when saturated")
+	}
+}
+
+func TestLDR(t *testing.T) {
+	settings := LDRSettings{Supply: 3.3, Resistor: 10000, R10: 10000, Gamma: 0.7}
+
+	// The LDR is at 10k, its resistance at 10 lux
+	lux, err := NewLDR(adctest.NewConverter(1.65), 0, settings).Read()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if math.Abs(lux-10) > 1e-9 {
+		t.Fatalf("should be 10 lux, it is %v", lux)
+	}
+
+	// Brighter, the resistance is lower
+	lux, _ = NewLDR(adctest.NewConverter(3), 0, settings).Read()
+	if lux <= 10 {
+		t.Fatalf("should be brighter than 10 lux, it is %v", lux)
+	}
+
+	lux, _ = NewLDR(adctest.NewConverter(0), 0, settings).Read()
+	if lux != 0 {
+		t.Fatalf("should be dark, it is %v", lux)
+	}
+
+	_, err = NewLDR(adctest.NewConverter(3.3), 0, settings).Read()
+	if err == nil {
+		t.Fatalf("should error above the supply")
+	}
+}
@@ -0,0 +1,118 @@
+package lightsensor
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Registers of the TSL2561, the command bit is set and the word bit reads two bytes.
+const (
+	tsl2561Control = 0x80
+	tsl2561Timing  = 0x81
+	tsl2561Data0   = 0xAC
+	tsl2561Data1   = 0xAE
+)
+
+// tsl2561IntegrationTime is the integration time of 402ms, with a margin.
+const tsl2561IntegrationTime = 450 * time.Millisecond
+
+// tsl2561Scale scales the channels at the low gain to the nominal gain of the lux formula.
+const tsl2561Scale = 16
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// tsl2561 is a digital light sensor with a broadband and an infrared
+// photodiode, on an I2C bus. It is set to the low gain, so that it saturates
+// only in full sun.
+type tsl2561 struct {
+	conn adc.Conn
+
+	mu sync.Mutex
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewTSL2561 returns a new TSL2561.
+func NewTSL2561(conn adc.Conn) Sensor {
+	return &tsl2561{
+		conn: conn,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read powers the sensor on for an integration and returns the illuminance.
+func (s *tsl2561) Read() (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Power on, at the low gain and an integration time of 402ms
+	err := s.conn.Tx([]byte{tsl2561Control, 0x03}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while powering on the TSL2561: %s", err)
+	}
+	defer s.conn.Tx([]byte{tsl2561Control, 0x00}, nil)
+	err = s.conn.Tx([]byte{tsl2561Timing, 0x02}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while setting the timing of the TSL2561: %s", err)
+	}
+
+	time.Sleep(tsl2561IntegrationTime)
+
+	r := make([]byte, 2)
+	err = s.conn.Tx([]byte{tsl2561Data0}, r)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the TSL2561: %s", err)
+	}
+	broadband := uint16(r[1])<<8 | uint16(r[0])
+	err = s.conn.Tx([]byte{tsl2561Data1}, r)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the TSL2561: %s", err)
+	}
+	infrared := uint16(r[1])<<8 | uint16(r[0])
+
+	return tsl2561Lux(broadband, infrared), nil
+}
+
+// tsl2561Lux returns the illuminance of the channels, with the formula of the
+// datasheet for the T, FN and CL packages. A saturated channel returns the
+// maximum illuminance, the light is at least as bright.
+func tsl2561Lux(broadband, infrared uint16) float64 {
+	if broadband == math.MaxUint16 || infrared == math.MaxUint16 {
+		return 0.0304 * math.MaxUint16 * tsl2561Scale
+	}
+	if broadband == 0 {
+		return 0
+	}
+
+	ch0 := float64(broadband) * tsl2561Scale
+	ch1 := float64(infrared) * tsl2561Scale
+	ratio := ch1 / ch0
+
+	switch {
+	case ratio <= 0.5:
+		return 0.0304*ch0 - 0.062*ch0*math.Pow(ratio, 1.4)
+	case ratio <= 0.61:
+		return 0.0224*ch0 - 0.031*ch1
+	case ratio <= 0.8:
+		return 0.0128*ch0 - 0.0153*ch1
+	case ratio <= 1.3:
+		return 0.00146*ch0 - 0.00112*ch1
+	default:
+		return 0
+	}
+}
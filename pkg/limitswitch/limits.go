@@ -0,0 +1,78 @@
+package limitswitch
+
+import (
+	"errors"
+
+	"github.com/fallais/gocoop/pkg/door"
+)
+
+// ErrBothActive is raised when both limit switches are reached at the same time, one of them is faulty.
+var ErrBothActive = errors.New("both limit switches are active")
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Limits are the limit switches of a door. It is a door.Sensor.
+type Limits struct {
+	opened *Switch
+	closed *Switch
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewLimits returns the limit switches reached when the door is opened and when it is closed.
+func NewLimits(opened, closed *Switch) *Limits {
+	return &Limits{
+		opened: opened,
+		closed: closed,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Opened returns the switch reached when the door is opened, nil if the door has no limit switches.
+func (l *Limits) Opened() *Switch {
+	if l == nil {
+		return nil
+	}
+
+	return l.opened
+}
+
+// Closed returns the switch reached when the door is closed, nil if the door has no limit switches.
+func (l *Limits) Closed() *Switch {
+	if l == nil {
+		return nil
+	}
+
+	return l.closed
+}
+
+// Sense returns the physical position of the door.
+func (l *Limits) Sense() (door.State, error) {
+	opened, closed := l.opened.IsActive(), l.closed.IsActive()
+
+	switch {
+	case opened && closed:
+		return door.StateUnknown, ErrBothActive
+	case opened:
+		return door.StateOpened, nil
+	case closed:
+		return door.StateClosed, nil
+	}
+
+	return door.StateBetween, nil
+}
+
+// Close stops watching the limit switches.
+func (l *Limits) Close() error {
+	l.opened.Close()
+	l.closed.Close()
+
+	return nil
+}
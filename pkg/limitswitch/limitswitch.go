@@ -0,0 +1,215 @@
+package limitswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultDebounce is the time the level of a switch must be stable to be taken into account.
+const DefaultDebounce = 20 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a limit switch.
+type Settings struct {
+	// Debounce is the time the level must be stable, 0 disables the debouncing.
+	Debounce time.Duration
+
+	// Pull is the pull resistor of the input.
+	Pull gpio.Pull
+
+	// ActiveHigh is true if the switch drives the pin high when it is reached,
+	// by default it pulls the pin low.
+	ActiveHigh bool
+}
+
+// Switch is a limit switch watched with the edges of its pin.
+type Switch struct {
+	name     string
+	pin      gpio.Pin
+	settings Settings
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu      sync.Mutex
+	active  bool
+	changed chan struct{}
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// New returns a new Switch watching the pin until it is closed.
+func New(name string, pin gpio.Pin, settings Settings) (*Switch, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := pin.Watch(ctx, gpio.BothEdges, settings.Pull)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("error while watching the %s limit switch: %s", name, err)
+	}
+
+	s := &Switch{
+		name:     name,
+		pin:      pin,
+		settings: settings,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		changed:  make(chan struct{}),
+	}
+
+	// The switch may already be reached
+	err = s.update()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go s.watch(events)
+
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// IsActive returns true if the switch is reached. A nil switch is never reached.
+func (s *Switch) IsActive() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+// Wait blocks until the switch is reached or the context is done. It returns
+// motor.ErrTimeout if the deadline of the context is exceeded first.
+// A nil switch is never reached, Wait then returns nil when the context is done.
+func (s *Switch) Wait(ctx context.Context) error {
+	if s == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	for {
+		s.mu.Lock()
+		active, changed := s.active, s.changed
+		s.mu.Unlock()
+
+		if active {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return motor.ErrTimeout
+			}
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// Close stops watching the pin.
+func (s *Switch) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+
+	return nil
+}
+
+// watch updates the state of the switch once the level is stable after an edge.
+func (s *Switch) watch(events <-chan gpio.Event) {
+	defer close(s.done)
+
+	var timer *time.Timer
+	var settled <-chan time.Time
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+
+			if s.settings.Debounce <= 0 {
+				s.update()
+				continue
+			}
+
+			// Wait for the level to be stable again
+			if timer == nil {
+				timer = time.NewTimer(s.settings.Debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(s.settings.Debounce)
+			}
+			settled = timer.C
+		case <-settled:
+			settled = nil
+			s.update()
+		}
+	}
+}
+
+// update reads the level of the pin and wakes up the waiters if the state has changed.
+func (s *Switch) update() error {
+	level, err := s.pin.Read()
+	if err != nil {
+		logrus.WithError(err).Errorf("Error while reading the %s limit switch", s.name)
+		return fmt.Errorf("error while reading the %s limit switch: %s", s.name, err)
+	}
+
+	active := level == gpio.Low
+	if s.settings.ActiveHigh {
+		active = level == gpio.High
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if active == s.active {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"switch": s.name,
+		"active": active,
+	}).Debugln("Limit switch has changed")
+
+	s.active = active
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package limitswitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestPolarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		level    gpio.Level
+		active   bool
+	}{
+		{"active low released", Settings{Pull: gpio.PullUp}, gpio.High, false},
+		{"active low reached", Settings{Pull: gpio.PullUp}, gpio.Low, true},
+		{"active high released", Settings{Pull: gpio.PullDown, ActiveHigh: true}, gpio.Low, false},
+		{"active high reached", Settings{Pull: gpio.PullDown, ActiveHigh: true}, gpio.High, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chip := gpio.NewFake()
+			s, err := New("test", chip.Pin(1), tt.settings)
+			if err != nil {
+				t.Fatalf("should not error: %s", err)
+			}
+			defer s.Close()
+
+			if chip.FakePin(1).Pull() != tt.settings.Pull {
+				t.Fatal("pull resistor should be configured")
+			}
+
+			chip.FakePin(1).Set(tt.level)
+			waitFor(t, func() bool { return s.IsActive() == tt.active })
+		})
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	chip := gpio.NewFake()
+	s, err := New("test", chip.Pin(1), Settings{Pull: gpio.PullUp, Debounce: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer s.Close()
+
+	// The contact bounces before being stable
+	pin := chip.FakePin(1)
+	pin.Set(gpio.Low)
+	pin.Set(gpio.High)
+	pin.Set(gpio.Low)
+	pin.Set(gpio.High)
+	time.Sleep(20 * time.Millisecond)
+	if s.IsActive() {
+		t.Fatal("should not be active while bouncing")
+	}
+
+	pin.Set(gpio.Low)
+	waitFor(t, s.IsActive)
+}
+
+func TestWait(t *testing.T) {
+	chip := gpio.NewFake()
+	s, err := New("test", chip.Pin(1), Settings{Pull: gpio.PullUp})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = s.Wait(ctx)
+	if !errors.Is(err, motor.ErrTimeout) {
+		t.Fatalf("should time out, it returned %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go chip.FakePin(1).Set(gpio.Low)
+	err = s.Wait(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// A nil switch waits for the context
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	var none *Switch
+	if none.Wait(ctx) != nil || none.IsActive() {
+		t.Fatal("nil switch should never be reached")
+	}
+}
+
+func TestSense(t *testing.T) {
+	chip := gpio.NewFake()
+	opened, _ := New("open", chip.Pin(1), Settings{Pull: gpio.PullUp})
+	closed, _ := New("close", chip.Pin(2), Settings{Pull: gpio.PullUp})
+	limits := NewLimits(opened, closed)
+	defer limits.Close()
+
+	state, err := limits.Sense()
+	if err != nil || state != door.StateBetween {
+		t.Fatalf("should be between, it is %s (%v)", state, err)
+	}
+
+	chip.FakePin(2).Set(gpio.Low)
+	waitFor(t, closed.IsActive)
+	state, _ = limits.Sense()
+	if state != door.StateClosed {
+		t.Fatalf("should be closed, it is %s", state)
+	}
+
+	chip.FakePin(1).Set(gpio.Low)
+	waitFor(t, opened.IsActive)
+	_, err = limits.Sense()
+	if !errors.Is(err, ErrBothActive) {
+		t.Fatalf("should be an error, it is %v", err)
+	}
+}
+
+// waitFor waits until the condition is true.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition has not been met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,30 @@
+package limitswitchtest
+
+import (
+	"testing"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+)
+
+// New returns the limit switches on the opened and closed pins of the fake
+// chip. A switch is reached when its pin is set low, and the switches are
+// closed at the end of the test.
+func New(t testing.TB, chip *gpio.Fake, opened, closed int) *limitswitch.Limits {
+	t.Helper()
+
+	settings := limitswitch.Settings{Pull: gpio.PullUp}
+	o, err := limitswitch.New("open", chip.Pin(opened), settings)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	c, err := limitswitch.New("close", chip.Pin(closed), settings)
+	if err != nil {
+		o.Close()
+		t.Fatalf("should not error: %s", err)
+	}
+	limits := limitswitch.NewLimits(o, c)
+	t.Cleanup(func() { limits.Close() })
+
+	return limits
+}
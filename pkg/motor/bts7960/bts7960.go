@@ -1,31 +1,180 @@
 package bts7960
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fallais/gocoop/pkg/currentsense"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
 	"github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+
+	"github.com/sirupsen/logrus"
 )
 
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// pwmFrequency is the frequency of the PWM of the half bridges, in Hertz.
+const pwmFrequency = 100
+
 //------------------------------------------------------------------------------
 // Structure
 //------------------------------------------------------------------------------
 
 // Motor driver for BTS7960.
 type bts7960 struct {
-	forwardPWM    int
-	reversePWM    int
-	forwardEnable int
-	reverseEnable int
+	forwardPWM    gpio.Pin
+	reversePWM    gpio.Pin
+	forwardEnable gpio.Pin
+	reverseEnable gpio.Pin
+	limits        *limitswitch.Limits
+	forwardSense  *currentsense.Sensor
+	reverseSense  *currentsense.Sensor
+	ramp          *ramp.Ramp
+
+	mu        sync.Mutex
+	lastSense *currentsense.Sensor
 }
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// NewBTS7960 returns a new BTS7960 motor driver.
-func NewBTS7960(forwardPWM, reversePWM, forwardEnable, reverseEnable int) motor.Motor {
+// NewBTS7960 returns a new BTS7960 motor driver. Without limit switches, the motor runs until the duration has elapsed.
+// The current sensors on the IS pins of the half bridges are optional, they stop the motor as soon as it stalls.
+// Without a ramp, the motor starts and stops at full speed.
+func NewBTS7960(forwardPWM, reversePWM, forwardEnable, reverseEnable gpio.Pin, limits *limitswitch.Limits, forwardSense, reverseSense *currentsense.Sensor, r *ramp.Ramp) motor.Motor {
 	return &bts7960{
 		forwardPWM:    forwardPWM,
 		reversePWM:    reversePWM,
 		forwardEnable: forwardEnable,
 		reverseEnable: reverseEnable,
+		limits:        limits,
+		forwardSense:  forwardSense,
+		reverseSense:  reverseSense,
+		ramp:          r,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward.
+func (d *bts7960) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn motor forward")
+
+	return d.run(ctx, d.forwardPWM, d.forwardEnable, d.reversePWM, d.limits.Opened(), d.forwardSense)
+}
+
+// Backward turns the motor backward.
+func (d *bts7960) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn motor backward")
+
+	return d.run(ctx, d.reversePWM, d.reverseEnable, d.forwardPWM, d.limits.Closed(), d.reverseSense)
+}
+
+// run drives the half bridge until the limit switch is reached or the context is done, the other half bridge is kept low.
+func (d *bts7960) run(ctx context.Context, pwm, enable, otherPWM gpio.Pin, limit *limitswitch.Switch, sense *currentsense.Sensor) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		return nil
+	}
+
+	// Set the motor rotation
+	logrus.Infoln("Set the motor rotation")
+	err := otherPWM.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+	driveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	driven, err := d.ramp.Drive(driveCtx, pwm, pwmFrequency, 1)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+
+	// Enable the motor
+	logrus.Infoln("Start the motor")
+	err = enable.Output(gpio.High)
+	if err != nil {
+		return fmt.Errorf("error while starting the motor: %s", err)
+	}
+
+	// Wait
+	until, _ := ctx.Deadline()
+	logrus.Infoln("Wait until", until)
+	waitErr := d.wait(ctx, limit, sense)
+	cancel()
+	<-driven
+
+	// Disable the motor
+	logrus.Infoln("Stop the motor")
+	err = enable.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+	err = pwm.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+	logrus.Infoln("Motor has been stopped")
+
+	return waitErr
+}
+
+// wait waits for the limit switch while watching the current of the half bridge.
+func (d *bts7960) wait(ctx context.Context, limit *limitswitch.Switch, sense *currentsense.Sensor) error {
+	d.mu.Lock()
+	d.lastSense = sense
+	d.mu.Unlock()
+
+	if sense == nil {
+		return limit.Wait(ctx)
 	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stalled := sense.Watch(ctx)
+	reached := make(chan error, 1)
+	go func() {
+		reached <- limit.Wait(ctx)
+	}()
+
+	select {
+	case err := <-reached:
+		return err
+	case err := <-stalled:
+		return err
+	}
+}
+
+// PeakCurrent returns the highest current of the last run, 0 without current sensor.
+func (d *bts7960) PeakCurrent() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.lastSense.PeakCurrent()
+}
+
+// Stop the motor.
+func (d *bts7960) Stop() error {
+	logrus.Infoln("Stopping the motor")
+
+	err := d.forwardEnable.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+	err = d.reverseEnable.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+	logrus.Infoln("Motor has been stopped")
+
+	return nil
 }
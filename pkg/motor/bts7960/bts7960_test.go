@@ -0,0 +1,89 @@
+package bts7960
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc/adctest"
+	"github.com/fallais/gocoop/pkg/currentsense"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestForward(t *testing.T) {
+	chip := gpio.NewFake()
+	m := NewBTS7960(chip.Pin(1), chip.Pin(2), chip.Pin(3), chip.Pin(4), nil, nil, nil, nil)
+
+	// A previous run left the reverse half bridge driven
+	chip.FakePin(2).Output(gpio.High)
+
+	enabled := make(chan struct{}, 1)
+	chip.FakePin(3).OnWrite(func(level gpio.Level) {
+		if level == gpio.High {
+			enabled <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.Forward(ctx) }()
+
+	<-enabled
+	if chip.FakePin(1).Level() != gpio.High {
+		t.Error("forward PWM should be high")
+	}
+	if chip.FakePin(2).Level() != gpio.Low {
+		t.Error("reverse PWM should be low")
+	}
+	if chip.FakePin(4).Level() != gpio.Low {
+		t.Error("reverse enable should be low")
+	}
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(3).Level() != gpio.Low {
+		t.Fatal("forward enable should be low")
+	}
+}
+
+func TestStop(t *testing.T) {
+	chip := gpio.NewFake()
+	m := NewBTS7960(chip.Pin(1), chip.Pin(2), chip.Pin(3), chip.Pin(4), nil, nil, nil, nil)
+	chip.FakePin(3).Output(gpio.High)
+	chip.FakePin(4).Output(gpio.High)
+
+	err := m.Stop()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(3).Level() != gpio.Low || chip.FakePin(4).Level() != gpio.Low {
+		t.Fatal("both enables should be low")
+	}
+}
+
+func TestStall(t *testing.T) {
+	chip := gpio.NewFake()
+	sense := currentsense.New(adctest.NewConverter(1), 0, currentsense.Settings{AmpsPerVolt: 8.5, StallCurrent: 6, Interval: time.Millisecond})
+	m := NewBTS7960(chip.Pin(1), chip.Pin(2), chip.Pin(3), chip.Pin(4), nil, nil, sense, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.Backward(ctx)
+	if err != motor.ErrStall {
+		t.Fatalf("should stall, it returned %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop as soon as the motor stalls")
+	}
+	if chip.FakePin(4).Level() != gpio.Low {
+		t.Fatal("reverse enable should be low")
+	}
+	if peak := m.(motor.CurrentSensor).PeakCurrent(); peak != 8.5 {
+		t.Fatalf("peak current should be 8.5A, it is %v", peak)
+	}
+}
@@ -1,32 +1,127 @@
 package l293d
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
 	"github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+
+	"github.com/sirupsen/logrus"
 )
 
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// pwmFrequency is the frequency of the PWM of the enable pin, in Hertz.
+const pwmFrequency = 100
+
 //------------------------------------------------------------------------------
 // Structure
 //------------------------------------------------------------------------------
 
 // l293d is a motor driver.
 type l293d struct {
-	pinInput1  int
-	pinInput2  int
-	pinEnable1 int
-	pinInput3  int
-	pinInput4  int
-	pinEnable2 int
+	pinInput1  gpio.Pin
+	pinInput2  gpio.Pin
+	pinEnable1 gpio.Pin
+	limits     *limitswitch.Limits
+	ramp       *ramp.Ramp
 }
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// NewL293D returns a new L293D.
-func NewL293D(pinInput1, pinInput2, pinEnable1 int) motor.Motor {
+// NewL293D returns a new L293D. Without limit switches, the motor runs until the duration has elapsed.
+// Without a ramp, the motor starts and stops at full speed.
+func NewL293D(pinInput1, pinInput2, pinEnable1 gpio.Pin, limits *limitswitch.Limits, r *ramp.Ramp) motor.Motor {
 	return &l293d{
 		pinInput1:  pinInput1,
 		pinInput2:  pinInput2,
 		pinEnable1: pinEnable1,
+		limits:     limits,
+		ramp:       r,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward.
+func (motor *l293d) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn motor forward")
+
+	return motor.run(ctx, gpio.High, gpio.Low, motor.limits.Opened())
+}
+
+// Backward turns the motor backward.
+func (motor *l293d) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn motor backward")
+
+	return motor.run(ctx, gpio.Low, gpio.High, motor.limits.Closed())
+}
+
+// run turns the motor in the rotation given by the levels of the inputs until the limit switch is reached or the context is done.
+func (motor *l293d) run(ctx context.Context, input1, input2 gpio.Level, limit *limitswitch.Switch) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		return nil
+	}
+
+	// Set the motor rotation
+	logrus.Infoln("Set the motor rotation")
+	err := motor.pinInput1.Output(input1)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+	err = motor.pinInput2.Output(input2)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+
+	// Enable the motor
+	logrus.Infoln("Start the motor")
+	driveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	driven, err := motor.ramp.Drive(driveCtx, motor.pinEnable1, pwmFrequency, 1)
+	if err != nil {
+		return fmt.Errorf("error while starting the motor: %s", err)
+	}
+
+	// Wait
+	until, _ := ctx.Deadline()
+	logrus.Infoln("Wait until", until)
+	waitErr := limit.Wait(ctx)
+	cancel()
+	<-driven
+
+	// Disable the motor
+	logrus.Infoln("Stop the motor")
+	err = motor.pinEnable1.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
 	}
+
+	logrus.Infoln("Motor is stopped")
+
+	return waitErr
+}
+
+// Stop the motor.
+func (motor *l293d) Stop() error {
+	logrus.Infoln("Stopping the motor")
+
+	err := motor.pinEnable1.Output(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+
+	logrus.Infoln("Motor has been stopped")
+
+	return nil
 }
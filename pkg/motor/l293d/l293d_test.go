@@ -0,0 +1,85 @@
+package l293d
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch/limitswitchtest"
+)
+
+func TestDirections(t *testing.T) {
+	tests := []struct {
+		name   string
+		run    func(m *l293d, ctx context.Context) error
+		input1 gpio.Level
+		input2 gpio.Level
+	}{
+		{"forward", (*l293d).Forward, gpio.High, gpio.Low},
+		{"backward", (*l293d).Backward, gpio.Low, gpio.High},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chip := gpio.NewFake()
+			m := NewL293D(chip.Pin(1), chip.Pin(2), chip.Pin(3), nil, nil).(*l293d)
+
+			// The motor must be enabled while it runs
+			enabled := make(chan struct{}, 1)
+			chip.FakePin(3).OnWrite(func(level gpio.Level) {
+				if level == gpio.High {
+					enabled <- struct{}{}
+				}
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- tt.run(m, ctx) }()
+
+			<-enabled
+			if got := chip.FakePin(1).Level(); got != tt.input1 {
+				t.Errorf("input 1 should be %d, it is %d", tt.input1, got)
+			}
+			if got := chip.FakePin(2).Level(); got != tt.input2 {
+				t.Errorf("input 2 should be %d, it is %d", tt.input2, got)
+			}
+			cancel()
+
+			err := <-done
+			if err != nil {
+				t.Fatalf("should not error: %s", err)
+			}
+			if chip.FakePin(3).Level() != gpio.Low {
+				t.Fatal("motor should be disabled")
+			}
+		})
+	}
+}
+
+func TestLimitSwitch(t *testing.T) {
+	chip := gpio.NewFake()
+	limits := limitswitchtest.New(t, chip, 4, 5)
+	m := NewL293D(chip.Pin(1), chip.Pin(2), chip.Pin(3), limits, nil)
+
+	chip.FakePin(3).OnWrite(func(level gpio.Level) {
+		if level == gpio.High {
+			// The door reaches the top as soon as the motor starts
+			go chip.FakePin(4).Set(gpio.Low)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop on the limit switch before the deadline")
+	}
+	if chip.FakePin(3).Level() != gpio.Low {
+		t.Fatal("motor should be disabled")
+	}
+}
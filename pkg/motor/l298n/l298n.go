@@ -1,32 +1,131 @@
 package l298n
 
 import (
-	"github.com/fallais/gocoop/pkg/motor"
+	"context"
+	"fmt"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+
+	"github.com/sirupsen/logrus"
 )
 
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// pwmFrequency is the frequency of the PWM of the enable pin, in Hertz.
+const pwmFrequency = 50
+
 //------------------------------------------------------------------------------
 // Structure
 //------------------------------------------------------------------------------
 
 // l298n is a motor driver.
 type l298n struct {
-	pinInput1  int
-	pinInput2  int
-	pinEnable1 int
-	pinInput3  int
-	pinInput4  int
-	pinEnable2 int
+	pinInput1      gpio.Pin
+	pinInput2      gpio.Pin
+	pinEnable1     gpio.Pin
+	limits         *limitswitch.Limits
+	openDutyCycle  int
+	closeDutyCycle int
+	ramp           *ramp.Ramp
 }
 
 //------------------------------------------------------------------------------
 // Factory
 //------------------------------------------------------------------------------
 
-// Newl298n returns a new l298n.
-func NewL298N(pinInput1, pinInput2, pinEnable1 int) motor.Motor {
+// NewL298N returns a new l298n. The duty cycles are percentages of the full speed.
+// Without a ramp, the motor starts and stops at the duty cycle.
+func NewL298N(pinInput1, pinInput2, pinEnable1 gpio.Pin, limits *limitswitch.Limits, openDutyCycle, closeDutyCycle int, r *ramp.Ramp) motorpkg.Motor {
 	return &l298n{
-		pinInput1:  pinInput1,
-		pinInput2:  pinInput2,
-		pinEnable1: pinEnable1,
+		pinInput1:      pinInput1,
+		pinInput2:      pinInput2,
+		pinEnable1:     pinEnable1,
+		limits:         limits,
+		openDutyCycle:  openDutyCycle,
+		closeDutyCycle: closeDutyCycle,
+		ramp:           r,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward until the top limit switch is hit.
+func (motor *l298n) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn motor forward")
+
+	return motor.run(ctx, gpio.High, gpio.Low, motor.limits.Opened(), motor.openDutyCycle)
+}
+
+// Backward turns the motor backward until the bottom limit switch is hit.
+func (motor *l298n) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn motor backward")
+
+	return motor.run(ctx, gpio.Low, gpio.High, motor.limits.Closed(), motor.closeDutyCycle)
+}
+
+// run turns the motor until the limit switch is hit or the context is done.
+func (motor *l298n) run(ctx context.Context, input1, input2 gpio.Level, limit *limitswitch.Switch, dutyCycle int) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		return nil
+	}
+
+	// Set the motor rotation
+	logrus.Infoln("Set the motor rotation")
+	err := motor.pinInput1.Output(input1)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+	err = motor.pinInput2.Output(input2)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+
+	// Enable the motor
+	logrus.Infof("Start the motor: PWM DutyCycle=%v", dutyCycle)
+	driveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	driven, err := motor.ramp.Drive(driveCtx, motor.pinEnable1, pwmFrequency, float64(dutyCycle)/100)
+	if err != nil {
+		return fmt.Errorf("error while starting the motor: %s", err)
+	}
+
+	// Wait
+	until, isDeadlineSet := ctx.Deadline()
+	if isDeadlineSet {
+		logrus.Infoln("Wait until", until)
+	}
+	waitErr := limit.Wait(ctx)
+	cancel()
+	<-driven
+	if limit.IsActive() {
+		logrus.Infoln("Hit the limit switch")
 	}
+
+	err = motor.Stop()
+	if err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+// Stop the motor.
+func (motor *l298n) Stop() error {
+	logrus.Infoln("Stopping the motor")
+
+	err := motor.pinEnable1.PWM(pwmFrequency, 0)
+	if err != nil {
+		return fmt.Errorf("error while stopping the motor: %s", err)
+	}
+	logrus.Infoln("Motor has been stopped")
+
+	return nil
 }
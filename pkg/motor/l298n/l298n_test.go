@@ -0,0 +1,121 @@
+package l298n
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch/limitswitchtest"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+)
+
+// newTestMotor returns a motor on a fake chip: inputs on 1 and 2, enable on 3, limit switches on 4 and 5.
+func newTestMotor(t *testing.T) (*gpio.Fake, motorpkg.Motor) {
+	chip := gpio.NewFake()
+	limits := limitswitchtest.New(t, chip, 4, 5)
+
+	return chip, NewL298N(chip.Pin(1), chip.Pin(2), chip.Pin(3), limits, 80, 60, nil)
+}
+
+func TestForwardLimitSwitch(t *testing.T) {
+	chip, m := newTestMotor(t)
+
+	started := make(chan struct{}, 1)
+	chip.FakePin(3).OnWrite(func(level gpio.Level) {
+		if level == gpio.High {
+			started <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.Forward(ctx) }()
+
+	<-started
+	if chip.FakePin(1).Level() != gpio.High || chip.FakePin(2).Level() != gpio.Low {
+		t.Error("should turn forward")
+	}
+	if got := chip.FakePin(3).DutyCycle(); got != 0.8 {
+		t.Errorf("duty cycle should be 0.8, it is %v", got)
+	}
+	if got := chip.FakePin(3).Frequency(); got != pwmFrequency {
+		t.Errorf("frequency should be %d, it is %d", pwmFrequency, got)
+	}
+
+	// Hit the top limit switch
+	chip.FakePin(4).Set(gpio.Low)
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop on the limit switch before the deadline")
+	}
+	if chip.FakePin(3).DutyCycle() != 0 {
+		t.Fatal("motor should be stopped")
+	}
+}
+
+func TestBackwardTimeout(t *testing.T) {
+	chip, m := newTestMotor(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	err := m.Backward(ctx)
+	if !errors.Is(err, motorpkg.ErrTimeout) {
+		t.Fatalf("should time out, it returned %v", err)
+	}
+	if chip.FakePin(1).Level() != gpio.Low || chip.FakePin(2).Level() != gpio.High {
+		t.Error("should turn backward")
+	}
+	if chip.FakePin(3).DutyCycle() != 0 {
+		t.Fatal("motor should be stopped")
+	}
+}
+
+func TestAlreadyReached(t *testing.T) {
+	chip := gpio.NewFake()
+	chip.FakePin(5).Input(gpio.PullNone)
+
+	limits := limitswitchtest.New(t, chip, 4, 5)
+	m := NewL298N(chip.Pin(1), chip.Pin(2), chip.Pin(3), limits, 80, 60, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := m.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(3).Mode() != gpio.Unset {
+		t.Fatal("motor should not have been started")
+	}
+}
+
+func TestRamp(t *testing.T) {
+	chip := gpio.NewFake()
+	r, err := ramp.New(ramp.Settings{Acceleration: time.Second})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	m := NewL298N(chip.Pin(1), chip.Pin(2), chip.Pin(3), nil, 80, 60, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.Forward(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+	if d := chip.FakePin(3).DutyCycle(); d <= 0 || d >= 0.8 {
+		t.Fatalf("should be accelerating, the duty cycle is %v", d)
+	}
+
+	<-done
+	if chip.FakePin(3).DutyCycle() != 0 {
+		t.Fatal("motor should be stopped")
+	}
+}
@@ -0,0 +1,239 @@
+package linearactuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/currentsense"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	"github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/relay"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Wiring is the way the actuator is wired to the GPIO.
+type Wiring string
+
+const (
+	// HBridge drives the actuator with the two inputs of an H-bridge, and an optional enable pin.
+	HBridge Wiring = "hbridge"
+
+	// Relay drives the actuator with two relays reversing its polarity.
+	Relay Wiring = "relay"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a linear actuator.
+type Settings struct {
+	// Wiring is the way the actuator is wired, HBridge by default.
+	Wiring Wiring
+
+	// ActiveLow is true if the relays are energized by a low level.
+	ActiveLow bool
+
+	// DeadTime is the time between the release of a relay and the energizing
+	// of the other one, relay.DefaultDeadTime by default with relays.
+	DeadTime time.Duration
+
+	// TravelTime is the time of a full travel, the actuator is then at its
+	// end-stop. 0 runs the actuator until the duration of the door has elapsed.
+	TravelTime time.Duration
+}
+
+// linearActuator is a linear actuator with internal end-stops.
+type linearActuator struct {
+	interlock    *relay.Interlock
+	pinEnable    gpio.Pin
+	limits       *limitswitch.Limits
+	forwardSense *currentsense.Sensor
+	reverseSense *currentsense.Sensor
+	settings     Settings
+
+	mu        sync.Mutex
+	lastSense *currentsense.Sensor
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewLinearActuator returns a new linear actuator. The actuator is cut by its
+// internal end-stops, the end of the travel is detected when its current drops,
+// or once the travel time has elapsed. The enable pin, the limit switches and
+// the current sensors are optional.
+func NewLinearActuator(pinForward, pinBackward, pinEnable gpio.Pin, limits *limitswitch.Limits, forwardSense, reverseSense *currentsense.Sensor, settings Settings) (motor.Motor, error) {
+	switch settings.Wiring {
+	case "":
+		settings.Wiring = HBridge
+	case HBridge, Relay:
+	default:
+		return nil, fmt.Errorf("wiring does not exist: %s", settings.Wiring)
+	}
+	if settings.Wiring == HBridge && settings.ActiveLow {
+		return nil, errors.New("an H-bridge cannot be active low")
+	}
+	if settings.TravelTime < 0 {
+		return nil, fmt.Errorf("travel time must be positive: %s", settings.TravelTime)
+	}
+	if settings.Wiring == Relay && settings.DeadTime == 0 {
+		settings.DeadTime = relay.DefaultDeadTime
+	}
+
+	// The directions are never powered at the same time
+	interlock, err := relay.NewInterlock(pinForward, pinBackward, settings.ActiveLow, settings.DeadTime)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &linearActuator{
+		interlock:    interlock,
+		pinEnable:    pinEnable,
+		limits:       limits,
+		forwardSense: forwardSense,
+		reverseSense: reverseSense,
+		settings:     settings,
+	}
+
+	// The actuator is stopped until it moves
+	err = a.Stop()
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward extends the actuator until its end-stop.
+func (a *linearActuator) Forward(ctx context.Context) error {
+	logrus.Infoln("Extend the linear actuator")
+
+	return a.run(ctx, a.interlock.Forward, a.limits.Opened(), a.forwardSense)
+}
+
+// Backward retracts the actuator until its end-stop.
+func (a *linearActuator) Backward(ctx context.Context) error {
+	logrus.Infoln("Retract the linear actuator")
+
+	return a.run(ctx, a.interlock.Backward, a.limits.Closed(), a.reverseSense)
+}
+
+// run powers the actuator until the end of the travel or the context is done.
+func (a *linearActuator) run(ctx context.Context, energize func(context.Context) error, limit *limitswitch.Switch, sense *currentsense.Sensor) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		return nil
+	}
+
+	// Set the direction of the actuator
+	err := energize(ctx)
+	if err != nil {
+		a.Stop()
+		return fmt.Errorf("error while setting the direction of the actuator: %s", err)
+	}
+	if a.pinEnable != nil {
+		err = a.pinEnable.Output(gpio.High)
+		if err != nil {
+			return fmt.Errorf("error while starting the actuator: %s", err)
+		}
+	}
+
+	// Wait
+	until, _ := ctx.Deadline()
+	logrus.Infoln("Wait until", until)
+	waitErr := a.wait(ctx, limit, sense)
+
+	err = a.Stop()
+	if err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+// wait waits for the end of the travel : the limit switch, the drop of the
+// current or the travel time, whichever comes first.
+func (a *linearActuator) wait(ctx context.Context, limit *limitswitch.Switch, sense *currentsense.Sensor) error {
+	a.mu.Lock()
+	a.lastSense = sense
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var current <-chan error
+	if sense != nil {
+		current = sense.Watch(ctx)
+	}
+
+	var travelled <-chan time.Time
+	if a.settings.TravelTime > 0 {
+		timer := time.NewTimer(a.settings.TravelTime)
+		defer timer.Stop()
+		travelled = timer.C
+	}
+
+	reached := make(chan error, 1)
+	go func() {
+		reached <- limit.Wait(ctx)
+	}()
+
+	select {
+	case err := <-reached:
+		if err == nil && limit != nil && ctx.Err() == nil {
+			logrus.Infoln("Hit the limit switch")
+		}
+		return err
+	case err := <-current:
+		if errors.Is(err, motor.ErrIdle) {
+			logrus.Infoln("The actuator has reached its end-stop")
+			return nil
+		}
+		return err
+	case <-travelled:
+		logrus.Infoln("The actuator has travelled for the travel time")
+		return nil
+	}
+}
+
+// PeakCurrent returns the highest current of the last run, 0 without current sensor.
+func (a *linearActuator) PeakCurrent() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.lastSense.PeakCurrent()
+}
+
+// Stop the actuator, both directions are released.
+func (a *linearActuator) Stop() error {
+	logrus.Infoln("Stopping the actuator")
+
+	if a.pinEnable != nil {
+		err := a.pinEnable.Output(gpio.Low)
+		if err != nil {
+			return fmt.Errorf("error while stopping the actuator: %s", err)
+		}
+	}
+	err := a.interlock.Release()
+	if err != nil {
+		return fmt.Errorf("error while stopping the actuator: %s", err)
+	}
+	logrus.Infoln("Actuator has been stopped")
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package linearactuator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/adc/adctest"
+	"github.com/fallais/gocoop/pkg/currentsense"
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestTravelTime(t *testing.T) {
+	chip := gpio.NewFake()
+	a, err := NewLinearActuator(chip.Pin(1), chip.Pin(2), chip.Pin(3), nil, nil, nil, Settings{TravelTime: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	started := make(chan struct{}, 1)
+	chip.FakePin(3).OnWrite(func(level gpio.Level) {
+		if level == gpio.High {
+			started <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- a.Forward(ctx) }()
+
+	<-started
+	if chip.FakePin(1).Level() != gpio.High || chip.FakePin(2).Level() != gpio.Low {
+		t.Error("should extend")
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop after the travel time, before the deadline")
+	}
+	if chip.FakePin(1).Level() != gpio.Low || chip.FakePin(3).Level() != gpio.Low {
+		t.Fatal("actuator should be stopped")
+	}
+}
+
+func TestRelay(t *testing.T) {
+	chip := gpio.NewFake()
+	a, err := NewLinearActuator(chip.Pin(1), chip.Pin(2), nil, nil, nil, nil, Settings{Wiring: Relay, ActiveLow: true, TravelTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(1).Level() != gpio.High || chip.FakePin(2).Level() != gpio.High {
+		t.Fatal("relays should be released")
+	}
+
+	// Both relays are never energized at the same time
+	var mu sync.Mutex
+	both := false
+	check := func(gpio.Level) {
+		mu.Lock()
+		defer mu.Unlock()
+		if chip.FakePin(1).Level() == gpio.Low && chip.FakePin(2).Level() == gpio.Low {
+			both = true
+		}
+	}
+	chip.FakePin(1).OnWrite(check)
+	chip.FakePin(2).OnWrite(check)
+
+	err = a.Forward(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	err = a.Backward(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if both {
+		t.Fatal("both relays have been energized")
+	}
+}
+
+func TestCurrentDrop(t *testing.T) {
+	chip := gpio.NewFake()
+	converter := adctest.NewConverter(0.3)
+	sense := currentsense.New(converter, 0, currentsense.Settings{AmpsPerVolt: 10, StallCurrent: 8, IdleCurrent: 0.5, IdleTime: 5 * time.Millisecond, Interval: time.Millisecond})
+	a, err := NewLinearActuator(chip.Pin(1), chip.Pin(2), nil, nil, sense, sense, Settings{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- a.Backward(ctx) }()
+
+	// The end-stop cuts the actuator
+	time.Sleep(20 * time.Millisecond)
+	converter.Set(0)
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop when the current drops, before the deadline")
+	}
+	if got := a.(motor.CurrentSensor).PeakCurrent(); got != 3 {
+		t.Fatalf("peak current should be 3A, it is %v", got)
+	}
+
+	// The actuator is blocked
+	converter.Set(1)
+	err = a.Forward(ctx)
+	if !errors.Is(err, motor.ErrStall) {
+		t.Fatalf("should stall, it returned %v", err)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	chip := gpio.NewFake()
+	a, err := NewLinearActuator(chip.Pin(1), chip.Pin(2), nil, nil, nil, nil, Settings{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// Without travel time, the actuator runs until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err = a.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("should run until the deadline")
+	}
+
+	_, err = NewLinearActuator(chip.Pin(1), chip.Pin(2), nil, nil, nil, nil, Settings{Wiring: "pneumatic"})
+	if err == nil {
+		t.Fatal("should not accept an unknown wiring")
+	}
+}
@@ -1,6 +1,19 @@
 package motor
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrTimeout is raised when the motor ran until the deadline without reaching a limit switch.
+var ErrTimeout = errors.New("motor timeout")
+
+// ErrStall is raised when the motor has stalled, its current went above the stall current.
+var ErrStall = errors.New("motor stall")
+
+// ErrIdle is raised when the current of the motor has dropped below the idle
+// current, the motor has been cut by its internal end-stop.
+var ErrIdle = errors.New("motor idle")
 
 // Motor is an electrical motor or a linear actuator.
 type Motor interface {
@@ -8,3 +21,9 @@ type Motor interface {
 	Backward(context.Context) error
 	Stop() error
 }
+
+// CurrentSensor is implemented by the motors that measure their current.
+type CurrentSensor interface {
+	// PeakCurrent returns the highest current of the last run, in amperes.
+	PeakCurrent() float64
+}
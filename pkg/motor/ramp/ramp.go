@@ -0,0 +1,180 @@
+package ramp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Curve is the shape of the acceleration and the deceleration.
+type Curve string
+
+const (
+	// Linear changes the speed at a constant rate.
+	Linear Curve = "linear"
+
+	// SCurve starts and ends the changes of speed smoothly.
+	SCurve Curve = "s_curve"
+)
+
+// Step is the interval between two updates of the speed.
+const Step = 20 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a ramp.
+type Settings struct {
+	// Acceleration is the time to go from 0 to the speed.
+	Acceleration time.Duration
+
+	// Deceleration is the time to go from the speed to the approach speed.
+	Deceleration time.Duration
+
+	// Curve is the shape of the acceleration and the deceleration.
+	Curve Curve
+
+	// Approach is the last part of the travel, from 0 to 1, run at the approach speed.
+	Approach float64
+
+	// ApproachSpeed is the speed of the approach, from 0 to 1 of the speed.
+	ApproachSpeed float64
+
+	// TravelTime is the time of a full travel at full speed, it is used to
+	// estimate when the approach starts. By default it is the duration of the movement.
+	TravelTime time.Duration
+}
+
+// Ramp drives the speed of a motor with a PWM, to start and stop it smoothly.
+type Ramp struct {
+	settings Settings
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// New returns a new Ramp.
+func New(settings Settings) (*Ramp, error) {
+	switch settings.Curve {
+	case "":
+		settings.Curve = Linear
+	case Linear, SCurve:
+	default:
+		return nil, fmt.Errorf("curve does not exist: %s", settings.Curve)
+	}
+
+	if settings.Approach < 0 || settings.Approach > 1 {
+		return nil, fmt.Errorf("approach must be between 0 and 1: %v", settings.Approach)
+	}
+	if settings.ApproachSpeed < 0 || settings.ApproachSpeed > 1 {
+		return nil, fmt.Errorf("approach speed must be between 0 and 1: %v", settings.ApproachSpeed)
+	}
+
+	return &Ramp{
+		settings: settings,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Drive drives the pin with a PWM at the frequency, following the ramp up
+// to the speed, until the context is done. The returned channel is closed
+// once the pin is not driven anymore, the caller must then stop the motor.
+// Without a ramp, the pin is driven at the speed straight away.
+func (r *Ramp) Drive(ctx context.Context, pin gpio.Pin, frequency int, speed float64) (<-chan struct{}, error) {
+	done := make(chan struct{})
+
+	if r == nil {
+		close(done)
+		return done, pin.PWM(frequency, speed)
+	}
+
+	// The approach is estimated from the travel time
+	travel := r.settings.TravelTime
+	if deadline, ok := ctx.Deadline(); ok && travel == 0 {
+		travel = time.Until(deadline)
+	}
+
+	start := time.Now()
+	last := r.Speed(0, travel, speed)
+	err := pin.PWM(frequency, last)
+	if err != nil {
+		close(done)
+		return done, err
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(Step)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			s := r.Speed(time.Since(start), travel, speed)
+			if s == last {
+				continue
+			}
+			err := pin.PWM(frequency, s)
+			if err != nil {
+				logrus.WithError(err).Errorln("Error while changing the speed of the motor")
+			}
+			last = s
+		}
+	}()
+
+	return done, nil
+}
+
+// Speed returns the speed after the elapsed time, for a travel of the given
+// time at the given full speed.
+func (r *Ramp) Speed(elapsed, travel time.Duration, speed float64) float64 {
+	s := speed
+
+	// Soft start
+	if elapsed < r.settings.Acceleration {
+		s = speed * r.shape(float64(elapsed)/float64(r.settings.Acceleration))
+	}
+
+	// Slow approach
+	if r.settings.Approach > 0 && travel > 0 {
+		approachStart := time.Duration(float64(travel) * (1 - r.settings.Approach))
+		if elapsed >= approachStart {
+			progress := 1.0
+			if r.settings.Deceleration > 0 {
+				progress = math.Min(1, float64(elapsed-approachStart)/float64(r.settings.Deceleration))
+			}
+			approach := speed - speed*(1-r.settings.ApproachSpeed)*r.shape(progress)
+			s = math.Min(s, approach)
+		}
+	}
+
+	return s
+}
+
+// shape returns the progress of the change of speed along the curve, both from 0 to 1.
+func (r *Ramp) shape(progress float64) float64 {
+	if r.settings.Curve == SCurve {
+		return progress * progress * (3 - 2*progress)
+	}
+
+	return progress
+}
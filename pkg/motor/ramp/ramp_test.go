@@ -0,0 +1,96 @@
+package ramp
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+)
+
+func TestSpeed(t *testing.T) {
+	r, err := New(Settings{
+		Acceleration:  time.Second,
+		Deceleration:  time.Second,
+		Approach:      0.2,
+		ApproachSpeed: 0.25,
+	})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	tests := []struct {
+		elapsed time.Duration
+		speed   float64
+	}{
+		{0, 0},
+		{500 * time.Millisecond, 0.4},
+		{time.Second, 0.8},
+		{7 * time.Second, 0.8},
+		{8 * time.Second, 0.8},
+		{8500 * time.Millisecond, 0.5},
+		{9 * time.Second, 0.2},
+		{10 * time.Second, 0.2},
+	}
+	for _, tt := range tests {
+		got := r.Speed(tt.elapsed, 10*time.Second, 0.8)
+		if math.Abs(got-tt.speed) > 1e-9 {
+			t.Errorf("speed should be %v after %s, it is %v", tt.speed, tt.elapsed, got)
+		}
+	}
+}
+
+func TestSCurve(t *testing.T) {
+	r, _ := New(Settings{Acceleration: time.Second, Curve: SCurve})
+
+	if got := r.Speed(500*time.Millisecond, 0, 1); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("should be half of the speed in the middle of the ramp, it is %v", got)
+	}
+	if got := r.Speed(100*time.Millisecond, 0, 1); got >= 0.1 {
+		t.Errorf("should start slower than a linear ramp, it is %v", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Settings{Curve: "bounce"})
+	if err == nil {
+		t.Error("should not accept an unknown curve")
+	}
+
+	_, err = New(Settings{Approach: 2})
+	if err == nil {
+		t.Error("should not accept an approach over 1")
+	}
+}
+
+func TestDrive(t *testing.T) {
+	chip := gpio.NewFake()
+	pin := chip.FakePin(1)
+
+	// Without a ramp, the pin is driven at the speed straight away
+	var r *Ramp
+	done, err := r.Drive(context.Background(), pin, 100, 0.6)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	<-done
+	if pin.DutyCycle() != 0.6 || pin.Frequency() != 100 {
+		t.Fatalf("should be driven at 0.6 and 100Hz, it is %v and %dHz", pin.DutyCycle(), pin.Frequency())
+	}
+
+	// With a ramp, the speed increases
+	r, _ = New(Settings{Acceleration: 200 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done, _ = r.Drive(ctx, pin, 100, 1)
+	time.Sleep(100 * time.Millisecond)
+	if d := pin.DutyCycle(); d <= 0 || d >= 1 {
+		t.Fatalf("should be accelerating, the duty cycle is %v", d)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if d := pin.DutyCycle(); d != 1 {
+		t.Fatalf("should be at full speed, the duty cycle is %v", d)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,146 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// DefaultDeadTime is the default time between the release of a relay and the
+// energizing of the other one, a relay takes a few milliseconds to release.
+const DefaultDeadTime = 100 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Interlock switches the two relays reversing the polarity of a motor, so that
+// they are never energized at the same time : it would short the supply.
+type Interlock struct {
+	forward   gpio.Pin
+	backward  gpio.Pin
+	activeLow bool
+	deadTime  time.Duration
+
+	mu        sync.Mutex
+	energized gpio.Pin
+	released  time.Time
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewInterlock returns a new Interlock, both relays are released.
+func NewInterlock(forward, backward gpio.Pin, activeLow bool, deadTime time.Duration) (*Interlock, error) {
+	if deadTime < 0 {
+		return nil, fmt.Errorf("dead time must be positive: %s", deadTime)
+	}
+
+	i := &Interlock{
+		forward:   forward,
+		backward:  backward,
+		activeLow: activeLow,
+		deadTime:  deadTime,
+	}
+
+	err := i.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward energizes the forward relay.
+func (i *Interlock) Forward(ctx context.Context) error {
+	return i.energize(ctx, i.forward, i.backward)
+}
+
+// Backward energizes the backward relay.
+func (i *Interlock) Backward(ctx context.Context) error {
+	return i.energize(ctx, i.backward, i.forward)
+}
+
+// energize releases the other relay, waits for the dead time since the last
+// release, then energizes the relay. It gives up if the context is done first.
+func (i *Interlock) energize(ctx context.Context, pin, other gpio.Pin) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.energized == pin {
+		return nil
+	}
+
+	// Release the other relay
+	err := other.Output(i.level(false))
+	if err != nil {
+		return fmt.Errorf("error while releasing the relay: %s", err)
+	}
+	if i.energized == other {
+		i.energized = nil
+		i.released = time.Now()
+	}
+
+	// Wait for the relays to be released
+	if wait := time.Until(i.released.Add(i.deadTime)); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	err = pin.Output(i.level(true))
+	if err != nil {
+		return fmt.Errorf("error while energizing the relay: %s", err)
+	}
+	i.energized = pin
+
+	return nil
+}
+
+// Release releases both relays.
+func (i *Interlock) Release() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	err := i.forward.Output(i.level(false))
+	if err != nil {
+		return fmt.Errorf("error while releasing the relay: %s", err)
+	}
+	err = i.backward.Output(i.level(false))
+	if err != nil {
+		return fmt.Errorf("error while releasing the relay: %s", err)
+	}
+
+	if i.energized != nil {
+		i.energized = nil
+		i.released = time.Now()
+	}
+
+	return nil
+}
+
+// level returns the level of a relay that is energized or not.
+func (i *Interlock) level(energized bool) gpio.Level {
+	if energized == i.activeLow {
+		return gpio.Low
+	}
+
+	return gpio.High
+}
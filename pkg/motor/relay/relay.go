@@ -0,0 +1,119 @@
+package relay
+
+import (
+	"context"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a relay board.
+type Settings struct {
+	// ActiveLow is true if the relays are energized by a low level, as on most relay boards.
+	ActiveLow bool
+
+	// DeadTime is the time between the release of a relay and the energizing
+	// of the other one, DefaultDeadTime by default.
+	DeadTime time.Duration
+}
+
+// relay is a DC motor driven by two SPDT relays reversing its polarity.
+type relay struct {
+	interlock *Interlock
+	limits    *limitswitch.Limits
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewRelay returns a new relay motor driver. The motor runs at full speed, in
+// one direction when the forward relay is energized and in the other one when
+// the backward relay is. Without limit switches, the motor runs until the duration has elapsed.
+func NewRelay(pinForward, pinBackward gpio.Pin, limits *limitswitch.Limits, settings Settings) (motor.Motor, error) {
+	if settings.DeadTime == 0 {
+		settings.DeadTime = DefaultDeadTime
+	}
+
+	interlock, err := NewInterlock(pinForward, pinBackward, settings.ActiveLow, settings.DeadTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &relay{
+		interlock: interlock,
+		limits:    limits,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward.
+func (motor *relay) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn motor forward")
+
+	return motor.run(ctx, motor.interlock.Forward, motor.limits.Opened())
+}
+
+// Backward turns the motor backward.
+func (motor *relay) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn motor backward")
+
+	return motor.run(ctx, motor.interlock.Backward, motor.limits.Closed())
+}
+
+// run energizes the relay until the limit switch is hit or the context is done.
+func (motor *relay) run(ctx context.Context, energize func(context.Context) error, limit *limitswitch.Switch) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		return nil
+	}
+
+	// Start the motor
+	logrus.Infoln("Start the motor")
+	err := energize(ctx)
+	if err != nil {
+		motor.Stop()
+		return err
+	}
+
+	// Wait
+	until, isDeadlineSet := ctx.Deadline()
+	if isDeadlineSet {
+		logrus.Infoln("Wait until", until)
+	}
+	waitErr := limit.Wait(ctx)
+	if limit.IsActive() {
+		logrus.Infoln("Hit the limit switch")
+	}
+
+	err = motor.Stop()
+	if err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+// Stop the motor, both relays are released.
+func (motor *relay) Stop() error {
+	logrus.Infoln("Stopping the motor")
+
+	err := motor.interlock.Release()
+	if err != nil {
+		return err
+	}
+	logrus.Infoln("Motor has been stopped")
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch/limitswitchtest"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestForwardLimitSwitch(t *testing.T) {
+	chip := gpio.NewFake()
+	m, err := NewRelay(chip.Pin(1), chip.Pin(2), limitswitchtest.New(t, chip, 4, 5), Settings{ActiveLow: true})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(1).Level() != gpio.High || chip.FakePin(2).Level() != gpio.High {
+		t.Fatal("relays should be released")
+	}
+
+	started := make(chan struct{}, 1)
+	chip.FakePin(1).OnWrite(func(level gpio.Level) {
+		if level == gpio.Low {
+			started <- struct{}{}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.Forward(ctx) }()
+
+	<-started
+	if chip.FakePin(2).Level() != gpio.High {
+		t.Error("backward relay should be released")
+	}
+
+	// Hit the top limit switch
+	chip.FakePin(4).Set(gpio.Low)
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop on the limit switch before the deadline")
+	}
+	if chip.FakePin(1).Level() != gpio.High {
+		t.Fatal("motor should be stopped")
+	}
+}
+
+func TestBackwardTimeout(t *testing.T) {
+	chip := gpio.NewFake()
+	m, err := NewRelay(chip.Pin(1), chip.Pin(2), limitswitchtest.New(t, chip, 4, 5), Settings{})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	err = m.Backward(ctx)
+	if !errors.Is(err, motorpkg.ErrTimeout) {
+		t.Fatalf("should time out, it returned %v", err)
+	}
+	if chip.FakePin(2).Level() != gpio.Low {
+		t.Fatal("motor should be stopped")
+	}
+}
+
+func TestDeadTime(t *testing.T) {
+	chip := gpio.NewFake()
+	i, err := NewInterlock(chip.Pin(1), chip.Pin(2), false, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// Both relays are never energized at the same time
+	var mu sync.Mutex
+	both := false
+	var released, energized time.Time
+	chip.FakePin(1).OnWrite(func(level gpio.Level) {
+		mu.Lock()
+		defer mu.Unlock()
+		if level == gpio.Low && released.IsZero() {
+			released = time.Now()
+		}
+	})
+	chip.FakePin(2).OnWrite(func(level gpio.Level) {
+		mu.Lock()
+		defer mu.Unlock()
+		if level == gpio.High {
+			energized = time.Now()
+			both = both || chip.FakePin(1).Level() == gpio.High
+		}
+	})
+
+	ctx := context.Background()
+	err = i.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(1).Level() != gpio.High {
+		t.Fatal("forward relay should be energized")
+	}
+
+	// Reversing waits for the dead time
+	err = i.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if both {
+		t.Fatal("both relays have been energized")
+	}
+	if gap := energized.Sub(released); gap < 50*time.Millisecond {
+		t.Fatalf("should wait for the dead time, it has waited %s", gap)
+	}
+}
+
+func TestDeadTimeStopped(t *testing.T) {
+	chip := gpio.NewFake()
+	i, err := NewInterlock(chip.Pin(1), chip.Pin(2), false, time.Minute)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	err = i.Forward(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	err = i.Release()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// The movement is stopped during the dead time
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = i.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if chip.FakePin(2).Level() != gpio.Low {
+		t.Fatal("backward relay should not be energized")
+	}
+}
@@ -0,0 +1,258 @@
+package servo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// pwmFrequency is the frequency of the PWM of a servo, in Hertz.
+const pwmFrequency = 50
+
+// frame is the period of the PWM, the pulse is updated once per frame.
+const frame = time.Second / pwmFrequency
+
+// DefaultClosedPulse is the width of the pulse when the door is closed.
+const DefaultClosedPulse = 1000 * time.Microsecond
+
+// DefaultOpenedPulse is the width of the pulse when the door is opened.
+const DefaultOpenedPulse = 2000 * time.Microsecond
+
+// DefaultTravelTime is the time the servo takes to sweep from closed to opened.
+const DefaultTravelTime = time.Second
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a servo.
+type Settings struct {
+	// ClosedPulse is the width of the pulse when the door is closed.
+	ClosedPulse time.Duration
+
+	// OpenedPulse is the width of the pulse when the door is opened.
+	OpenedPulse time.Duration
+
+	// TravelTime is the time to sweep from closed to opened.
+	TravelTime time.Duration
+
+	// Hold keeps driving the servo once it has stopped, instead of releasing it.
+	Hold bool
+}
+
+// servo is a PWM servo, driving a sliding latch or a light door.
+type servo struct {
+	pin      gpio.Pin
+	limits   *limitswitch.Limits
+	settings Settings
+
+	mu       sync.Mutex
+	position float64
+	known    bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewServo returns a new servo. The servo sweeps from one pulse to the other,
+// it stays where it is when it is stopped before the end. Its position is
+// unknown until the first travel, the servo then goes straight to the end.
+func NewServo(pin gpio.Pin, limits *limitswitch.Limits, settings Settings) (motorpkg.Motor, error) {
+	if settings.ClosedPulse == 0 {
+		settings.ClosedPulse = DefaultClosedPulse
+	}
+	if settings.OpenedPulse == 0 {
+		settings.OpenedPulse = DefaultOpenedPulse
+	}
+	if settings.TravelTime == 0 {
+		settings.TravelTime = DefaultTravelTime
+	}
+
+	// Check the settings
+	for _, pulse := range []time.Duration{settings.ClosedPulse, settings.OpenedPulse} {
+		if pulse <= 0 || pulse >= frame {
+			return nil, fmt.Errorf("pulse must be between 0 and %s: %s", frame, pulse)
+		}
+	}
+	if settings.ClosedPulse == settings.OpenedPulse {
+		return nil, errors.New("closed and opened pulses must be different")
+	}
+	if settings.TravelTime < 0 {
+		return nil, fmt.Errorf("travel time must be positive: %s", settings.TravelTime)
+	}
+
+	return &servo{
+		pin:      pin,
+		limits:   limits,
+		settings: settings,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward sweeps the servo to the opened pulse.
+func (motor *servo) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn servo forward")
+
+	return motor.run(ctx, 1, motor.limits.Opened())
+}
+
+// Backward sweeps the servo to the closed pulse.
+func (motor *servo) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn servo backward")
+
+	return motor.run(ctx, 0, motor.limits.Closed())
+}
+
+// run sweeps the servo to the target, from 0 (closed) to 1 (opened), until it
+// is reached or the context is done. With a limit switch, it then waits for it.
+func (motor *servo) run(ctx context.Context, target float64, limit *limitswitch.Switch) error {
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		motor.setPosition(target, true)
+		return nil
+	}
+
+	motor.mu.Lock()
+	position, known := motor.position, motor.known
+	motor.mu.Unlock()
+
+	defer func() {
+		if !motor.settings.Hold {
+			motor.Stop()
+		}
+	}()
+
+	if known {
+		err := motor.sweep(ctx, position, target)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return motor.timeout(ctx, limit)
+		}
+	} else {
+		// The position is unknown, go straight to the target
+		err := motor.drive(target)
+		if err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(motor.settings.TravelTime)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return motor.timeout(ctx, limit)
+		case <-timer.C:
+		}
+	}
+	motor.setPosition(target, true)
+
+	// Wait for the limit switch
+	if limit != nil {
+		err := limit.Wait(ctx)
+		if err != nil {
+			return err
+		}
+		logrus.Infoln("Hit the limit switch")
+	}
+
+	return nil
+}
+
+// sweep moves the servo from the position to the target, one frame at a
+// time, until it is reached or the context is done. The servo stays where it
+// was when the context is done.
+func (motor *servo) sweep(ctx context.Context, position, target float64) error {
+	step := 1.0
+	if motor.settings.TravelTime > 0 {
+		step = float64(frame) / float64(motor.settings.TravelTime)
+	}
+
+	ticker := time.NewTicker(frame)
+	defer ticker.Stop()
+
+	for position != target {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if position < target {
+			position = math.Min(position+step, target)
+		} else {
+			position = math.Max(position-step, target)
+		}
+		err := motor.drive(position)
+		if err != nil {
+			motor.setPosition(0, false)
+			return err
+		}
+		motor.setPosition(position, true)
+	}
+
+	return nil
+}
+
+// timeout returns motor.ErrTimeout if the servo was watching a limit switch
+// until the deadline, nil if it has been stopped.
+func (motor *servo) timeout(ctx context.Context, limit *limitswitch.Switch) error {
+	if limit != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return motorpkg.ErrTimeout
+	}
+
+	return nil
+}
+
+// drive sends the pulse of the position to the servo.
+func (motor *servo) drive(position float64) error {
+	pulse := float64(motor.settings.ClosedPulse) + position*float64(motor.settings.OpenedPulse-motor.settings.ClosedPulse)
+
+	err := motor.pin.PWM(pwmFrequency, pulse/float64(frame))
+	if err != nil {
+		return fmt.Errorf("error while driving the servo: %s", err)
+	}
+
+	return nil
+}
+
+// setPosition sets the position of the servo, from 0 (closed) to 1 (opened).
+func (motor *servo) setPosition(position float64, known bool) {
+	motor.mu.Lock()
+	defer motor.mu.Unlock()
+
+	motor.position = position
+	motor.known = known
+}
+
+// Stop releases the servo, it keeps its position by friction. A holding servo keeps being driven.
+func (motor *servo) Stop() error {
+	logrus.Infoln("Stopping the servo")
+
+	if !motor.settings.Hold {
+		err := motor.pin.PWM(pwmFrequency, 0)
+		if err != nil {
+			return fmt.Errorf("error while stopping the servo: %s", err)
+		}
+	}
+	logrus.Infoln("Servo has been stopped")
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package servo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+)
+
+// pulseOf returns the width of the pulse driven on the pin.
+func pulseOf(pin *gpio.FakePin) time.Duration {
+	return time.Duration(math.Round(pin.DutyCycle()*float64(frame)/float64(time.Microsecond))) * time.Microsecond
+}
+
+func TestSweep(t *testing.T) {
+	chip := gpio.NewFake()
+	pin := chip.FakePin(1)
+	m, err := NewServo(pin, nil, Settings{TravelTime: 200 * time.Millisecond, Hold: true})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The position is unknown, the servo goes straight to the end
+	err = m.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := pulseOf(pin); got != DefaultClosedPulse {
+		t.Fatalf("pulse should be %s, it is %s", DefaultClosedPulse, got)
+	}
+
+	// Stopped halfway, it stays where it is
+	half, cancelHalf := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelHalf()
+	err = m.Forward(half)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := pulseOf(pin); got <= DefaultClosedPulse || got >= DefaultOpenedPulse {
+		t.Fatalf("pulse should be between the ends, it is %s", got)
+	}
+
+	err = m.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := pulseOf(pin); got != DefaultOpenedPulse {
+		t.Fatalf("pulse should be %s, it is %s", DefaultOpenedPulse, got)
+	}
+	if got := pin.Frequency(); got != pwmFrequency {
+		t.Fatalf("frequency should be %d, it is %d", pwmFrequency, got)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	chip := gpio.NewFake()
+	pin := chip.FakePin(1)
+	m, err := NewServo(pin, nil, Settings{TravelTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	err = m.Forward(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if pin.DutyCycle() != 0 {
+		t.Fatal("servo should be released")
+	}
+}
+
+func TestLimitSwitch(t *testing.T) {
+	chip := gpio.NewFake()
+	opened, _ := limitswitch.New("open", chip.Pin(4), limitswitch.Settings{Pull: gpio.PullUp})
+	limits := limitswitch.NewLimits(opened, nil)
+	defer limits.Close()
+	m, err := NewServo(chip.Pin(1), limits, Settings{TravelTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = m.Forward(ctx)
+	if !errors.Is(err, motorpkg.ErrTimeout) {
+		t.Fatalf("should time out without the limit switch, it returned %v", err)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	chip := gpio.NewFake()
+
+	_, err := NewServo(chip.Pin(1), nil, Settings{OpenedPulse: 25 * time.Millisecond})
+	if err == nil {
+		t.Fatal("should not accept a pulse longer than the frame")
+	}
+	_, err = NewServo(chip.Pin(1), nil, Settings{ClosedPulse: time.Millisecond, OpenedPulse: time.Millisecond})
+	if err == nil {
+		t.Fatal("should not accept the same pulses")
+	}
+}
@@ -0,0 +1,362 @@
+package stepper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+	"github.com/fallais/gocoop/pkg/motor/ramp"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Driver is a step/dir stepper driver.
+type Driver string
+
+const (
+	// A4988 is the Allegro A4988, up to 1/16 microstepping.
+	A4988 Driver = "a4988"
+
+	// DRV8825 is the Texas Instruments DRV8825, up to 1/32 microstepping.
+	DRV8825 Driver = "drv8825"
+)
+
+// DefaultSpeed is the speed of the motor, in full steps per second.
+const DefaultSpeed = 200
+
+// pulseWidth is the width of the step pulse, both drivers need at least 2µs.
+const pulseWidth = 2 * time.Microsecond
+
+// minSpeed is the lowest speed of the ramp, from 0 to 1 of the speed, so
+// that the motor never waits forever for its first step.
+const minSpeed = 0.1
+
+// microstepLevels are the levels of the microstep pins (MS1, MS2, MS3 for the
+// A4988, M0, M1, M2 for the DRV8825) for each microstepping.
+var microstepLevels = map[Driver]map[int][3]gpio.Level{
+	A4988: {
+		1:  {gpio.Low, gpio.Low, gpio.Low},
+		2:  {gpio.High, gpio.Low, gpio.Low},
+		4:  {gpio.Low, gpio.High, gpio.Low},
+		8:  {gpio.High, gpio.High, gpio.Low},
+		16: {gpio.High, gpio.High, gpio.High},
+	},
+	DRV8825: {
+		1:  {gpio.Low, gpio.Low, gpio.Low},
+		2:  {gpio.High, gpio.Low, gpio.Low},
+		4:  {gpio.Low, gpio.High, gpio.Low},
+		8:  {gpio.High, gpio.High, gpio.Low},
+		16: {gpio.Low, gpio.Low, gpio.High},
+		32: {gpio.High, gpio.Low, gpio.High},
+	},
+}
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Settings of a stepper motor.
+type Settings struct {
+	// Driver is the stepper driver, A4988 by default.
+	Driver Driver
+
+	// StepsPerTravel is the count of full steps of a full travel of the door.
+	StepsPerTravel int
+
+	// Microsteps is the microstepping of the driver, 1 (full steps) by default.
+	Microsteps int
+
+	// Speed is the speed of the motor, in full steps per second.
+	Speed float64
+
+	// Invert reverses the direction of the motor.
+	Invert bool
+
+	// Hold keeps the coils energized once the motor has stopped.
+	Hold bool
+}
+
+// stepper is a stepper motor driven by a step/dir driver.
+type stepper struct {
+	pinStep   gpio.Pin
+	pinDir    gpio.Pin
+	pinEnable gpio.Pin
+	limits    *limitswitch.Limits
+	settings  Settings
+	ramp      *ramp.Ramp
+
+	mu       sync.Mutex
+	position int64
+	known    bool
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewStepper returns a new stepper motor. The enable pin is optional, and so
+// are the microstep pins when the microstepping is hardwired on the driver.
+// Without limit switches, the motor counts its steps to know its position : the
+// first travel is a full travel, the door is then held by its mechanical stop.
+// Without a ramp, the motor starts and stops at the speed.
+func NewStepper(pinStep, pinDir, pinEnable gpio.Pin, microstepPins []gpio.Pin, limits *limitswitch.Limits, settings Settings, r *ramp.Ramp) (motorpkg.Motor, error) {
+	if settings.Driver == "" {
+		settings.Driver = A4988
+	}
+	if settings.Microsteps == 0 {
+		settings.Microsteps = 1
+	}
+	if settings.Speed == 0 {
+		settings.Speed = DefaultSpeed
+	}
+
+	// Check the settings
+	levels, ok := microstepLevels[settings.Driver]
+	if !ok {
+		return nil, fmt.Errorf("stepper driver does not exist: %s", settings.Driver)
+	}
+	microstep, ok := levels[settings.Microsteps]
+	if !ok {
+		return nil, fmt.Errorf("microstepping is not supported by the %s: %d", settings.Driver, settings.Microsteps)
+	}
+	if settings.StepsPerTravel <= 0 {
+		return nil, errors.New("steps per travel must be set")
+	}
+	if settings.Speed < 0 {
+		return nil, fmt.Errorf("speed must be positive: %v", settings.Speed)
+	}
+	if len(microstepPins) > len(microstep) {
+		return nil, fmt.Errorf("the %s has only %d microstep pins", settings.Driver, len(microstep))
+	}
+
+	// Set the microstepping
+	for i, pin := range microstepPins {
+		err := pin.Output(microstep[i])
+		if err != nil {
+			return nil, fmt.Errorf("error while setting the microstepping: %s", err)
+		}
+	}
+
+	// The driver is disabled until the motor turns
+	err := pinStep.Output(gpio.Low)
+	if err != nil {
+		return nil, fmt.Errorf("error while setting the step pin: %s", err)
+	}
+	if pinEnable != nil {
+		err = pinEnable.Output(gpio.High)
+		if err != nil {
+			return nil, fmt.Errorf("error while disabling the driver: %s", err)
+		}
+	}
+
+	return &stepper{
+		pinStep:   pinStep,
+		pinDir:    pinDir,
+		pinEnable: pinEnable,
+		limits:    limits,
+		settings:  settings,
+		ramp:      r,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward until the end of the travel, or the top limit switch.
+func (motor *stepper) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn motor forward")
+
+	return motor.run(ctx, true, motor.limits.Opened())
+}
+
+// Backward turns the motor backward until the start of the travel, or the bottom limit switch.
+func (motor *stepper) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn motor backward")
+
+	return motor.run(ctx, false, motor.limits.Closed())
+}
+
+// run steps the motor until the end of the travel or the limit switch is
+// reached, or the context is done. With a limit switch, the motor steps until
+// the switch is hit whatever its count.
+func (motor *stepper) run(ctx context.Context, forward bool, limit *limitswitch.Switch) error {
+	end := motor.travel()
+	if !forward {
+		end = 0
+	}
+
+	if limit.IsActive() {
+		logrus.Infoln("Limit switch is already reached")
+		motor.setPosition(end, true)
+		return nil
+	}
+
+	// Steps to the end of the travel
+	steps := motor.travel()
+	motor.mu.Lock()
+	if motor.known {
+		steps = end - motor.position
+		if steps < 0 {
+			steps = -steps
+		}
+	}
+	motor.mu.Unlock()
+	if steps == 0 && limit == nil {
+		logrus.Infoln("End of the travel is already reached")
+		return nil
+	}
+
+	// Set the motor rotation
+	logrus.Infoln("Set the motor rotation")
+	dir := gpio.Low
+	if forward != motor.settings.Invert {
+		dir = gpio.High
+	}
+	err := motor.pinDir.Output(dir)
+	if err != nil {
+		return fmt.Errorf("error while setting the motor rotation: %s", err)
+	}
+
+	// Enable the driver
+	err = motor.enable()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !motor.settings.Hold {
+			motor.Stop()
+		}
+	}()
+
+	// Step
+	rate := motor.settings.Speed * float64(motor.settings.Microsteps)
+	travel := time.Duration(float64(steps) / rate * float64(time.Second))
+	logrus.Infof("Start the motor: Steps=%d Rate=%.0f/s", steps, rate)
+
+	start := time.Now()
+	next := start
+	for done := int64(0); limit != nil || done < steps; done++ {
+		if limit.IsActive() {
+			logrus.Infoln("Hit the limit switch")
+			motor.setPosition(end, true)
+			return nil
+		}
+
+		// Wait for the next step
+		speed := 1.0
+		if motor.ramp != nil {
+			speed = motor.ramp.Speed(time.Since(start), travel, 1)
+			if speed < minSpeed {
+				speed = minSpeed
+			}
+		}
+		period := time.Duration(float64(time.Second) / (rate * speed))
+		next = next.Add(period)
+		if wait := time.Until(next); wait > 0 {
+			time.Sleep(wait)
+		} else if wait < -period {
+			// The steps are late, do not try to catch up
+			next = time.Now()
+		}
+
+		if ctx.Err() != nil {
+			if limit != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return motorpkg.ErrTimeout
+			}
+			return nil
+		}
+
+		err := motor.step()
+		if err != nil {
+			motor.forget()
+			return fmt.Errorf("error while stepping the motor: %s", err)
+		}
+		motor.move(forward)
+	}
+
+	logrus.Infoln("Reached the end of the travel")
+	motor.setPosition(end, true)
+
+	return nil
+}
+
+// step sends a pulse on the step pin.
+func (motor *stepper) step() error {
+	err := motor.pinStep.Write(gpio.High)
+	if err != nil {
+		return err
+	}
+	time.Sleep(pulseWidth)
+
+	return motor.pinStep.Write(gpio.Low)
+}
+
+// enable energizes the coils of the motor.
+func (motor *stepper) enable() error {
+	if motor.pinEnable == nil {
+		return nil
+	}
+
+	err := motor.pinEnable.Write(gpio.Low)
+	if err != nil {
+		return fmt.Errorf("error while enabling the driver: %s", err)
+	}
+
+	return nil
+}
+
+// travel returns the count of microsteps of a full travel.
+func (motor *stepper) travel() int64 {
+	return int64(motor.settings.StepsPerTravel) * int64(motor.settings.Microsteps)
+}
+
+// move counts a step in the direction.
+func (motor *stepper) move(forward bool) {
+	motor.mu.Lock()
+	defer motor.mu.Unlock()
+
+	if forward {
+		motor.position++
+	} else {
+		motor.position--
+	}
+}
+
+// setPosition sets the position of the motor, in microsteps from the start of the travel.
+func (motor *stepper) setPosition(position int64, known bool) {
+	motor.mu.Lock()
+	defer motor.mu.Unlock()
+
+	motor.position = position
+	motor.known = known
+}
+
+// forget forgets the position of the motor, the next travel is a full one.
+func (motor *stepper) forget() {
+	motor.setPosition(0, false)
+}
+
+// Stop the motor. The coils are released, unless the motor holds its position.
+func (motor *stepper) Stop() error {
+	logrus.Infoln("Stopping the motor")
+
+	if motor.pinEnable != nil && !motor.settings.Hold {
+		err := motor.pinEnable.Write(gpio.High)
+		if err != nil {
+			return fmt.Errorf("error while stopping the motor: %s", err)
+		}
+	}
+	logrus.Infoln("Motor has been stopped")
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package stepper
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/gpio"
+	"github.com/fallais/gocoop/pkg/limitswitch"
+	"github.com/fallais/gocoop/pkg/limitswitch/limitswitchtest"
+	motorpkg "github.com/fallais/gocoop/pkg/motor"
+)
+
+// countSteps counts the rising edges on the step pin, in the direction of the dir pin.
+func countSteps(chip *gpio.Fake) *int64 {
+	var steps int64
+	chip.FakePin(1).OnWrite(func(level gpio.Level) {
+		if level != gpio.High {
+			return
+		}
+		if chip.FakePin(2).Level() == gpio.High {
+			atomic.AddInt64(&steps, 1)
+		} else {
+			atomic.AddInt64(&steps, -1)
+		}
+	})
+
+	return &steps
+}
+
+// newTestMotor returns a stepper on a fake chip: step on 1, dir on 2, enable on 3, microstep pins on 6, 7 and 8.
+func newTestMotor(t *testing.T, chip *gpio.Fake, limits *limitswitch.Limits, settings Settings) motorpkg.Motor {
+	m, err := NewStepper(chip.Pin(1), chip.Pin(2), chip.Pin(3), []gpio.Pin{chip.Pin(6), chip.Pin(7), chip.Pin(8)}, limits, settings, nil)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	return m
+}
+
+func TestTravel(t *testing.T) {
+	chip := gpio.NewFake()
+	steps := countSteps(chip)
+	m := newTestMotor(t, chip, nil, Settings{StepsPerTravel: 50, Microsteps: 2, Speed: 2000})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The first travel is a full travel
+	err := m.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := atomic.LoadInt64(steps); got != -100 {
+		t.Fatalf("should step 100 microsteps backward, it is %d", got)
+	}
+
+	err = m.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := atomic.LoadInt64(steps); got != 0 {
+		t.Fatalf("should step 100 microsteps forward, it is %d", got)
+	}
+	if chip.FakePin(3).Level() != gpio.High {
+		t.Fatal("driver should be disabled")
+	}
+
+	// The end of the travel is already reached
+	err = m.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := atomic.LoadInt64(steps); got != 0 {
+		t.Fatalf("should not step, it is %d", got)
+	}
+}
+
+func TestStopped(t *testing.T) {
+	chip := gpio.NewFake()
+	steps := countSteps(chip)
+	m := newTestMotor(t, chip, nil, Settings{StepsPerTravel: 100, Speed: 1000})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.Backward(ctx)
+	atomic.StoreInt64(steps, 0)
+
+	// Stopped halfway, the motor goes back from where it is
+	half, cancelHalf := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelHalf()
+	err := m.Forward(half)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	forward := atomic.LoadInt64(steps)
+	if forward <= 0 || forward >= 100 {
+		t.Fatalf("should be stopped before the end, it has stepped %d times", forward)
+	}
+
+	err = m.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if got := atomic.LoadInt64(steps); got != 0 {
+		t.Fatalf("should be back to the start, it is at %d", got)
+	}
+}
+
+func TestLimitSwitch(t *testing.T) {
+	chip := gpio.NewFake()
+	steps := countSteps(chip)
+
+	limits := limitswitchtest.New(t, chip, 4, 5)
+	m := newTestMotor(t, chip, limits, Settings{StepsPerTravel: 10, Speed: 1000})
+
+	// The motor steps beyond its count until the limit switch
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.Forward(ctx) }()
+
+	for atomic.LoadInt64(steps) < 20 {
+		time.Sleep(time.Millisecond)
+	}
+	chip.FakePin(4).Set(gpio.Low)
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	// The limit switch is never reached
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelTimeout()
+	err = m.Backward(timeout)
+	if !errors.Is(err, motorpkg.ErrTimeout) {
+		t.Fatalf("should time out, it returned %v", err)
+	}
+}
+
+func TestMicrostepping(t *testing.T) {
+	tests := map[Driver]map[int][3]gpio.Level{
+		A4988:   {16: {gpio.High, gpio.High, gpio.High}},
+		DRV8825: {16: {gpio.Low, gpio.Low, gpio.High}, 32: {gpio.High, gpio.Low, gpio.High}},
+	}
+
+	for driver, microsteps := range tests {
+		for microstep, levels := range microsteps {
+			chip := gpio.NewFake()
+			newTestMotor(t, chip, nil, Settings{Driver: driver, StepsPerTravel: 10, Microsteps: microstep})
+			for i, level := range levels {
+				if got := chip.FakePin(6 + i).Level(); got != level {
+					t.Errorf("%s at 1/%d: pin %d should be %d, it is %d", driver, microstep, i, level, got)
+				}
+			}
+		}
+	}
+
+	chip := gpio.NewFake()
+	_, err := NewStepper(chip.Pin(1), chip.Pin(2), nil, nil, nil, Settings{Driver: A4988, StepsPerTravel: 10, Microsteps: 32}, nil)
+	if err == nil {
+		t.Fatal("should not support 1/32 microstepping on the A4988")
+	}
+	_, err = NewStepper(chip.Pin(1), chip.Pin(2), nil, nil, nil, Settings{}, nil)
+	if err == nil {
+		t.Fatal("should need the steps per travel")
+	}
+}
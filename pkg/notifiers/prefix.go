@@ -0,0 +1,38 @@
+package notifiers
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// prefixed is a notifier starting its messages with a prefix.
+type prefixed struct {
+	Notifier
+	prefix string
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// WithPrefix returns the notifiers starting their messages with the prefix,
+// such as the name of the coop when several coops are managed.
+func WithPrefix(notifiers []Notifier, prefix string) []Notifier {
+	var ns []Notifier
+	for _, n := range notifiers {
+		ns = append(ns, &prefixed{
+			Notifier: n,
+			prefix:   prefix,
+		})
+	}
+
+	return ns
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Notify sends the message starting with the prefix.
+func (p *prefixed) Notify(message string) error {
+	return p.Notifier.Notify(p.prefix + ": " + message)
+}
@@ -0,0 +1,69 @@
+package sim
+
+import (
+	"math"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Encoder is a simulated encoder on the shaft of the motor of a plant.
+type Encoder struct {
+	plant  *Plant
+	counts float64
+
+	mu     sync.Mutex
+	offset int64
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewEncoder returns a new Encoder that counts the given number of steps for a full travel of the plant.
+func NewEncoder(plant *Plant, counts int64) *Encoder {
+	return &Encoder{
+		plant:  plant,
+		counts: float64(counts),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Count returns the number of steps since the last reset.
+func (e *Encoder) Count() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.steps() + e.offset
+}
+
+// Reset sets the count.
+func (e *Encoder) Reset(count int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.offset = count - e.steps()
+}
+
+// SetDirection does nothing, the simulated encoder senses the direction by itself.
+func (e *Encoder) SetDirection(direction int) {}
+
+// Missed returns 0, the simulated encoder does not miss any step.
+func (e *Encoder) Missed() int64 {
+	return 0
+}
+
+// Close does nothing.
+func (e *Encoder) Close() error {
+	return nil
+}
+
+// steps returns the number of steps from the closed position to the position of the plant.
+func (e *Encoder) steps() int64 {
+	return int64(math.Round(e.plant.Position() * e.counts))
+}
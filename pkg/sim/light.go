@@ -0,0 +1,49 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Light is a simulated light sensor. The light is at its maximum at the peak
+// time of the day, and it is dark from six hours after the peak until six
+// hours before the next one.
+type Light struct {
+	max  float64
+	peak time.Duration
+	now  func() time.Time
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewLight returns a new Light with the maximum illuminance in lux at the peak time.
+func NewLight(max float64, peak time.Duration) *Light {
+	return &Light{
+		max:  max,
+		peak: peak,
+		now:  time.Now,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Read returns the illuminance, in lux.
+func (l *Light) Read() (float64, error) {
+	return l.At(l.now()), nil
+}
+
+// At returns the illuminance at the given time.
+func (l *Light) At(date time.Time) float64 {
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	phase := 2 * math.Pi * float64(date.Sub(midnight)-l.peak) / float64(24*time.Hour)
+
+	return math.Max(0, l.max*math.Cos(phase))
+}
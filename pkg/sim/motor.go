@@ -0,0 +1,55 @@
+package sim
+
+import (
+	"context"
+
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// simMotor is a simulated motor driving a plant.
+type simMotor struct {
+	plant *Plant
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewMotor returns a new Motor driving the plant. It stops when the plant
+// reaches a limit switch and returns motor.ErrTimeout if it never does.
+func NewMotor(plant *Plant) motor.Motor {
+	return &simMotor{
+		plant: plant,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// Forward turns the motor forward, it opens the door.
+func (m *simMotor) Forward(ctx context.Context) error {
+	logrus.Infoln("Turn simulated motor forward")
+
+	return m.plant.run(ctx, 1)
+}
+
+// Backward turns the motor backward, it closes the door.
+func (m *simMotor) Backward(ctx context.Context) error {
+	logrus.Infoln("Turn simulated motor backward")
+
+	return m.plant.run(ctx, -1)
+}
+
+// Stop the motor.
+func (m *simMotor) Stop() error {
+	logrus.Infoln("Simulated motor has been stopped")
+
+	return nil
+}
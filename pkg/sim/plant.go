@@ -0,0 +1,176 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/motor"
+
+	"github.com/sirupsen/logrus"
+)
+
+//------------------------------------------------------------------------------
+// Constants
+//------------------------------------------------------------------------------
+
+// Fault is a fault of the door that can be simulated.
+type Fault string
+
+const (
+	// NoFault when the door travels normally.
+	NoFault Fault = ""
+
+	// Stall when the motor is powered but the door does not move at all.
+	Stall Fault = "stall"
+
+	// Jam when the door gets stuck at the jam position.
+	Jam Fault = "jam"
+)
+
+// Step is the interval between two updates of the position of the door.
+const Step = 10 * time.Millisecond
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Plant is a simulated door with its limit switches. The position goes from
+// 0 when the door is closed to 1 when it is opened.
+type Plant struct {
+	travelTime time.Duration
+
+	mu          sync.Mutex
+	position    float64
+	fault       Fault
+	jamPosition float64
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewPlant returns a new Plant that travels from closed to opened in the given time.
+func NewPlant(travelTime time.Duration, position float64) *Plant {
+	return &Plant{
+		travelTime: travelTime,
+		position:   clamp(position),
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// SetFault sets the fault of the door. The jam position is only used by the Jam fault.
+func (p *Plant) SetFault(fault Fault, jamPosition float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"fault":        fault,
+		"jam_position": jamPosition,
+	}).Warningln("Simulating a fault of the door")
+
+	p.fault = fault
+	p.jamPosition = clamp(jamPosition)
+}
+
+// Position returns the position of the door, from 0 (closed) to 1 (opened).
+func (p *Plant) Position() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.position
+}
+
+// Sense returns the state of the limit switches.
+func (p *Plant) Sense() (door.State, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.position >= 1:
+		return door.StateOpened, nil
+	case p.position <= 0:
+		return door.StateClosed, nil
+	}
+
+	return door.StateBetween, nil
+}
+
+// run moves the door in the direction (1 to open, -1 to close) until it
+// reaches a limit switch or the context is done.
+func (p *Plant) run(ctx context.Context, direction float64) error {
+	ticker := time.NewTicker(Step)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		if p.hasReachedLimit(direction) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return motor.ErrTimeout
+			}
+			return nil
+		case now := <-ticker.C:
+			p.advance(direction, now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// advance moves the door in the direction for the elapsed time.
+func (p *Plant) advance(direction float64, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fault == Stall {
+		return
+	}
+
+	next := p.position + direction
+	if p.travelTime > 0 {
+		next = p.position + direction*float64(elapsed)/float64(p.travelTime)
+	}
+
+	// The door cannot go past the jam position
+	if p.fault == Jam {
+		if (direction > 0 && p.position <= p.jamPosition && next > p.jamPosition) ||
+			(direction < 0 && p.position >= p.jamPosition && next < p.jamPosition) {
+			next = p.jamPosition
+		}
+	}
+
+	p.position = clamp(next)
+}
+
+// hasReachedLimit returns true if the door has reached the limit switch in the direction.
+func (p *Plant) hasReachedLimit(direction float64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if direction > 0 {
+		return p.position >= 1
+	}
+
+	return p.position <= 0
+}
+
+// clamp returns the position between 0 and 1.
+func clamp(position float64) float64 {
+	switch {
+	case position < 0:
+		return 0
+	case position > 1:
+		return 1
+	}
+
+	return position
+}
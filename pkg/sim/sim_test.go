@@ -0,0 +1,151 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fallais/gocoop/pkg/door"
+	"github.com/fallais/gocoop/pkg/motor"
+)
+
+func TestMotor(t *testing.T) {
+	p := NewPlant(100*time.Millisecond, 0)
+	m := NewMotor(p)
+
+	state, _ := p.Sense()
+	if state != door.StateClosed {
+		t.Fatalf("should be closed, it is %s", state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := m.Forward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("should stop on the limit switch before the deadline")
+	}
+
+	state, _ = p.Sense()
+	if state != door.StateOpened {
+		t.Fatalf("should be opened, it is %s", state)
+	}
+}
+
+func TestMotorStopped(t *testing.T) {
+	p := NewPlant(time.Second, 1)
+	m := NewMotor(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	err := m.Backward(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+
+	state, _ := p.Sense()
+	if state != door.StateBetween {
+		t.Fatalf("should be between, it is %s", state)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	tests := map[string]struct {
+		fault    Fault
+		expected float64
+	}{
+		"stall": {Stall, 0},
+		"jam":   {Jam, 0.5},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := NewPlant(100*time.Millisecond, 0)
+			p.SetFault(test.fault, 0.5)
+			m := NewMotor(p)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			err := m.Forward(ctx)
+			if !errors.Is(err, motor.ErrTimeout) {
+				t.Fatalf("should time out, it is %v", err)
+			}
+			if p.Position() != test.expected {
+				t.Fatalf("position should be %v, it is %v", test.expected, p.Position())
+			}
+		})
+	}
+}
+
+func TestCurve(t *testing.T) {
+	c := Curve{Min: 50, Max: 86, Peak: 15 * time.Hour, HumidityMin: 40, HumidityMax: 80}
+
+	tests := []struct {
+		date        time.Time
+		temperature float64
+		humidity    float64
+	}{
+		{time.Date(2023, 6, 1, 15, 0, 0, 0, time.UTC), 86, 40},
+		{time.Date(2023, 6, 1, 3, 0, 0, 0, time.UTC), 50, 80},
+		{time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC), 68, 60},
+	}
+	for _, test := range tests {
+		temperature, humidity := c.At(test.date)
+		if math.Abs(temperature-test.temperature) > 0.001 || math.Abs(humidity-test.humidity) > 0.001 {
+			t.Errorf("at %s should be %v/%v, it is %v/%v", test.date, test.temperature, test.humidity, temperature, humidity)
+		}
+	}
+}
+
+func TestLight(t *testing.T) {
+	l := NewLight(10000, 13*time.Hour)
+
+	tests := []struct {
+		date time.Time
+		lux  float64
+	}{
+		{time.Date(2023, 6, 1, 13, 0, 0, 0, time.UTC), 10000},
+		{time.Date(2023, 6, 1, 7, 0, 0, 0, time.UTC), 0},
+		{time.Date(2023, 6, 1, 1, 0, 0, 0, time.UTC), 0},
+		{time.Date(2023, 6, 1, 17, 0, 0, 0, time.UTC), 5000},
+	}
+	for _, test := range tests {
+		if lux := l.At(test.date); math.Abs(lux-test.lux) > 0.001 {
+			t.Errorf("at %s should be %v lux, it is %v", test.date, test.lux, lux)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	p := NewPlant(100*time.Millisecond, 0.5)
+	e := NewEncoder(p, 400)
+	if e.Count() != 200 {
+		t.Fatalf("count should be 200, it is %d", e.Count())
+	}
+
+	e.Reset(0)
+	if e.Count() != 0 {
+		t.Fatalf("count should be 0, it is %d", e.Count())
+	}
+
+	// Calibrate the door with the encoder
+	d := door.NewDoor("default", "", NewMotor(p), p, time.Second, time.Second, nil, door.NewOdometer(e, 0, nil))
+	err := d.Calibrate()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	d.Close()
+	d.Open()
+
+	position, err := d.Position()
+	if err != nil {
+		t.Fatalf("should not error: %s", err)
+	}
+	if position != 100 {
+		t.Fatalf("position should be 100, it is %f", position)
+	}
+}
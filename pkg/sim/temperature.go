@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"math"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Structure
+//------------------------------------------------------------------------------
+
+// Curve is a daily curve of the temperature and the humidity. The temperature
+// is at its maximum at the peak time of the day and at its minimum twelve
+// hours later, the humidity does the opposite.
+type Curve struct {
+	Min         float64
+	Max         float64
+	Peak        time.Duration
+	HumidityMin float64
+	HumidityMax float64
+}
+
+// Temperature is a simulated temperature sensor following a curve.
+type Temperature struct {
+	curve Curve
+	now   func() time.Time
+}
+
+//------------------------------------------------------------------------------
+// Factory
+//------------------------------------------------------------------------------
+
+// NewTemperature returns a new Temperature following the curve.
+func NewTemperature(curve Curve) *Temperature {
+	return &Temperature{
+		curve: curve,
+		now:   time.Now,
+	}
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+// ReadTemp returns the temperature in Fahrenheit and the humidity.
+func (t *Temperature) ReadTemp() (float32, float32, error) {
+	temperature, humidity := t.curve.At(t.now())
+
+	return float32(temperature), float32(humidity), nil
+}
+
+// At returns the temperature and the humidity at the given time.
+func (c Curve) At(date time.Time) (float64, float64) {
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	phase := 2 * math.Pi * float64(date.Sub(midnight)-c.Peak) / float64(24*time.Hour)
+
+	temperature := (c.Max+c.Min)/2 + (c.Max-c.Min)/2*math.Cos(phase)
+	humidity := (c.HumidityMax+c.HumidityMin)/2 - (c.HumidityMax-c.HumidityMin)/2*math.Cos(phase)
+
+	return temperature, humidity
+}
@@ -1,6 +1,3 @@
-//go:build linux
-// +build linux
-
 package temperature
 
 import (
@@ -8,8 +5,11 @@ import (
 	"time"
 	"errors"
 
+	"github.com/fallais/gocoop/pkg/gpio"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
-	"github.com/stianeikeland/go-rpio/v4"
 )
 
 // some code taken from drr2 go-dht and tazerreloaded example for DHT22 using go-rpio.
@@ -26,7 +26,7 @@ type SensorType int
 type temperature struct {
 	name           string
 	sensorType	   string
-	pin 		   int
+	pin 		   gpio.Pin
 	boostPerfFlag  bool
 }
 
@@ -41,6 +41,20 @@ const (
 	AM2302 = DHT22
 )
 
+// errChecksum is raised when the checksum of the data sent by the sensor is incorrect.
+var errChecksum = errors.New("checksum error")
+
+var (
+	readRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_dht_read_retries_total",
+		Help: "Number of readings of the DHT sensor that have been retried.",
+	}, []string{"sensor"})
+	checksumErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gocoop_dht_checksum_errors_total",
+		Help: "Number of readings of the DHT sensor with an incorrect checksum.",
+	}, []string{"sensor"})
+)
+
 // Temperature operation contract.
 type Temperature interface {
 	ReadTemp() (float32, float32, error)
@@ -51,7 +65,7 @@ type Temperature interface {
 //------------------------------------------------------------------------------
 
 // NewTemperature returns a new Temperature.
-func NewTemperature(name, sensorType string, pin int) Temperature {
+func NewTemperature(name, sensorType string, pin gpio.Pin) Temperature {
 	return &temperature{
 		name:          name,
 		sensorType:    sensorType,
@@ -65,33 +79,45 @@ func (v SensorType) getRetryTimeout() time.Duration {
 	return 1500 * time.Millisecond
 }
 
-func readDHTXX(sensorType SensorType, pin rpio.Pin, handShakeDur time.Duration ) (temperature float32, 
+func readDHTXX(sensorType SensorType, pin gpio.Pin, handShakeDur time.Duration ) (temperature float32, 
 	humidity float32, err error) {
 	// let data line be pulled high by pull-up
-	pin.Input()
+	err = pin.Input(gpio.PullUp)
+	if err != nil {
+		return -1, -1, err
+	}
 	time.Sleep(1000 * time.Microsecond)
 	// pull data line low for 1100 micros to signal ready to read
-	pin.Output()
-	pin.Low()
+	err = pin.Output(gpio.Low)
+	if err != nil {
+		return -1, -1, err
+	}
 	time.Sleep(handShakeDur)
 	// leave data line floating again, sensor writes data now
-	pin.Input()
+	err = pin.Input(gpio.PullUp)
+	if err != nil {
+		return -1, -1, err
+	}
 	pos := 0
 	var now int64
-	level := rpio.Low
+	level := gpio.Low
 	lastChange := time.Now().UnixMicro()
 	syncCycles, dataCycles := make([]uint16, 50), make([]uint16, 50)
 
 	// wait for incoming pulses from sensor until buffer is full or timeout is reached
 	for {
 		now = time.Now().UnixMicro()
-		if pin.Read() != level {
-			if level == rpio.Low {
-				level = rpio.High
+		read, err := pin.Read()
+		if err != nil {
+			return -1, -1, err
+		}
+		if read != level {
+			if level == gpio.Low {
+				level = gpio.High
 				// level changed to HIGH, sync cycle
 				syncCycles[pos] = uint16(now - lastChange)
 			} else {
-				level = rpio.Low
+				level = gpio.Low
 				// level changed to LOW, data cycle
 				dataCycles[pos] = uint16(now - lastChange)
 				// increment position
@@ -129,9 +155,14 @@ func readDHTXX(sensorType SensorType, pin rpio.Pin, handShakeDur time.Duration )
 		}
 	}
 
+	return decode(sensorType, data)
+}
+
+// decode returns the temperature and the humidity of the data packet sent by the sensor.
+func decode(sensorType SensorType, data []uint8) (temperature float32, humidity float32, err error) {
 	// verify checksum
 	if data[4] != ((data[0] + data[1] + data[2] + data[3]) & 0xFF) {
-		return -1, -1, errors.New("checksum error")
+		return -1, -1, errChecksum
 	}
 
 	// calculate temperature and humidity
@@ -191,15 +222,12 @@ func (temp *temperature) ReadTemp() (float32, float32, error) {
 	}
 
 	// Read sensor data from specific pin, retrying 10 times in case of failure.
-	err := rpio.Open()
-	if err != nil {
-		return -1, -1, fmt.Errorf("Error opening GPIO: %s", err)
-	}
-	tempPin := rpio.Pin(temp.pin)
+	tempPin := temp.pin
 
 	for {
 		sensorTemp, sensorHumidity, err := readDHTXX(sensorType, tempPin, handshakeDur)
 		if err != nil {
+			temp.observe(err, retry > 0)
 			if retry > 0 {
 				logrus.Warning(err)
 				retry--
@@ -215,4 +243,15 @@ func (temp *temperature) ReadTemp() (float32, float32, error) {
 
 		return (sensorTemp * 9 / 5) + 32, sensorHumidity, nil
 	}
-}
\ No newline at end of file
+}
+
+// observe updates the metrics of the sensor with the error of a reading, and
+// whether the reading is retried.
+func (temp *temperature) observe(err error, retried bool) {
+	if errors.Is(err, errChecksum) {
+		checksumErrors.WithLabelValues(temp.name).Inc()
+	}
+	if retried {
+		readRetries.WithLabelValues(temp.name).Inc()
+	}
+}
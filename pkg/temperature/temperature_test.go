@@ -0,0 +1,56 @@
+package temperature
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		sensorType  SensorType
+		data        []uint8
+		temperature float32
+		humidity    float32
+	}{
+		{"DHT11", DHT11, []uint8{45, 0, 21, 0, 66}, 21, 45},
+		{"DHT22", DHT22, []uint8{0x02, 0x8C, 0x01, 0x5F, 0xEE}, 35.1, 65.2},
+		{"DHT22 negative", DHT22, []uint8{0x02, 0x8C, 0x80, 0x65, 0x73}, -10.1, 65.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			temperature, humidity, err := decode(tt.sensorType, tt.data)
+			if err != nil {
+				t.Fatalf("should not error: %s", err)
+			}
+			if temperature != tt.temperature || humidity != tt.humidity {
+				t.Fatalf("should be %v/%v, it is %v/%v", tt.temperature, tt.humidity, temperature, humidity)
+			}
+		})
+	}
+}
+
+func TestDecodeChecksum(t *testing.T) {
+	_, _, err := decode(DHT11, []uint8{45, 0, 21, 0, 67})
+	if !errors.Is(err, errChecksum) {
+		t.Fatalf("should be a checksum error, it is %v", err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	temp := &temperature{name: "inside"}
+
+	_, _, err := decode(DHT11, []uint8{45, 0, 21, 0, 67})
+	temp.observe(err, true)
+	temp.observe(errors.New("timeout"), false)
+
+	if got := testutil.ToFloat64(checksumErrors.WithLabelValues("inside")); got != 1 {
+		t.Fatalf("should count one checksum error, it counted %v", got)
+	}
+	if got := testutil.ToFloat64(readRetries.WithLabelValues("inside")); got != 1 {
+		t.Fatalf("should count one retry, it counted %v", got)
+	}
+}